@@ -0,0 +1,371 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math/big"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Issuer is stamped into every token BruinMarket signs
+const Issuer = "bruinmarket"
+
+// Kid used for the single key built from JWT_SECRET, and assumed for
+// tokens minted before key IDs were introduced
+const legacyKID = "default"
+
+// Last-resort secret for local development when nothing is configured
+const devSecret = "your-secret-key-change-this-in-production"
+
+// KeyConfig describes one signing key in the JWT_KEYS_FILE document
+type KeyConfig struct {
+	KID            string     `json:"kid"`
+	Alg            string     `json:"alg"`
+	Secret         string     `json:"secret,omitempty"`
+	SecretFile     string     `json:"secret_file,omitempty"`
+	PrivateKeyFile string     `json:"private_key_file,omitempty"`
+	PublicKeyFile  string     `json:"public_key_file,omitempty"`
+	RetireAt       *time.Time `json:"retire_at,omitempty"`
+}
+
+// KeysConfig is the top level of the JWT_KEYS_FILE document
+type KeysConfig struct {
+	SigningKID string      `json:"signing_kid"`
+	Keys       []KeyConfig `json:"keys"`
+}
+
+// Key is a loaded signing or verification key
+type Key struct {
+	KID      string
+	Method   jwt.SigningMethod
+	signKey  interface{}
+	verifKey interface{}
+	RetireAt *time.Time
+}
+
+// Retired reports whether the key should no longer verify tokens
+func (k *Key) Retired(now time.Time) bool {
+	return k.RetireAt != nil && !now.Before(*k.RetireAt)
+}
+
+// JWK is the public half of a key in RFC 7517 form
+type JWK struct {
+	KID string `json:"kid"`
+	Kty string `json:"kty"`
+	Alg string `json:"alg"`
+	Use string `json:"use"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+}
+
+// JWKSet is served from /.well-known/jwks.json
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+// KeyManager holds the active signing key plus every key that may still verify
+type KeyManager struct {
+	mu         sync.RWMutex
+	keys       map[string]*Key
+	signingKID string
+	now        func() time.Time // Swapped out by tests
+}
+
+// NewKeyManager builds a manager from already loaded keys
+func NewKeyManager(keys []*Key, signingKID string) (*KeyManager, error) {
+	m := &KeyManager{now: time.Now}
+	if err := m.set(keys, signingKID); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// LoadKeyManager reads keys from JWT_KEYS_FILE, falling back to a single
+// HS256 key from JWT_SECRET
+func LoadKeyManager() (*KeyManager, error) {
+	keys, signingKID, err := loadKeys()
+	if err != nil {
+		return nil, err
+	}
+	return NewKeyManager(keys, signingKID)
+}
+
+// Reload re-reads the key configuration, e.g. after a rotation on disk.
+// On error the current keys stay in place.
+func (m *KeyManager) Reload() error {
+	keys, signingKID, err := loadKeys()
+	if err != nil {
+		return err
+	}
+	return m.set(keys, signingKID)
+}
+
+func (m *KeyManager) set(keys []*Key, signingKID string) error {
+	byKID := make(map[string]*Key, len(keys))
+	for _, key := range keys {
+		if _, dup := byKID[key.KID]; dup {
+			return fmt.Errorf("duplicate key id %q", key.KID)
+		}
+		byKID[key.KID] = key
+	}
+
+	signing, ok := byKID[signingKID]
+	if !ok {
+		return fmt.Errorf("signing key %q is not configured", signingKID)
+	}
+	if signing.signKey == nil {
+		return fmt.Errorf("signing key %q has no private key", signingKID)
+	}
+	if signing.Retired(m.now()) {
+		return fmt.Errorf("signing key %q is retired", signingKID)
+	}
+
+	m.mu.Lock()
+	m.keys = byKID
+	m.signingKID = signingKID
+	m.mu.Unlock()
+	return nil
+}
+
+// Sign signs claims with the current signing key and tags the header with its
+// kid. It fails once the signing key has retired, until a reload brings in a
+// new one.
+func (m *KeyManager) Sign(claims jwt.Claims) (string, error) {
+	m.mu.RLock()
+	key := m.keys[m.signingKID]
+	m.mu.RUnlock()
+
+	if key.Retired(m.now()) {
+		return "", fmt.Errorf("signing key %q has been retired", key.KID)
+	}
+
+	token := jwt.NewWithClaims(key.Method, claims)
+	token.Header["kid"] = key.KID
+	return token.SignedString(key.signKey)
+}
+
+// Keyfunc resolves the verification key for a token by its kid header.
+// Pass it to jwt.ParseWithClaims together with ParserOptions.
+func (m *KeyManager) Keyfunc(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	if kid == "" {
+		kid = legacyKID
+	}
+
+	m.mu.RLock()
+	key, ok := m.keys[kid]
+	m.mu.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("unknown key id %q", kid)
+	}
+	if key.Retired(m.now()) {
+		return nil, fmt.Errorf("key %q has been retired", kid)
+	}
+	// Never let a token pick a different algorithm than its key was configured for
+	if token.Method.Alg() != key.Method.Alg() {
+		return nil, fmt.Errorf("unexpected signing method %q for key %q", token.Method.Alg(), kid)
+	}
+	return key.verifKey, nil
+}
+
+// ParserOptions restricts parsing to the algorithms currently configured and
+// to unexpired tokens BruinMarket issued
+func (m *KeyManager) ParserOptions() []jwt.ParserOption {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	seen := map[string]bool{}
+	methods := []string{}
+	for _, key := range m.keys {
+		alg := key.Method.Alg()
+		if !seen[alg] {
+			seen[alg] = true
+			methods = append(methods, alg)
+		}
+	}
+	return []jwt.ParserOption{jwt.WithValidMethods(methods), jwt.WithIssuer(Issuer), jwt.WithExpirationRequired()}
+}
+
+// JWKS returns the public keys other services can use to validate tokens.
+// Symmetric HS256 keys are never published.
+func (m *KeyManager) JWKS() JWKSet {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	now := m.now()
+	set := JWKSet{Keys: []JWK{}}
+	for _, key := range m.keys {
+		if key.Retired(now) {
+			continue
+		}
+		switch pub := key.verifKey.(type) {
+		case ed25519.PublicKey:
+			set.Keys = append(set.Keys, JWK{
+				KID: key.KID,
+				Kty: "OKP",
+				Alg: key.Method.Alg(),
+				Use: "sig",
+				Crv: "Ed25519",
+				X:   base64.RawURLEncoding.EncodeToString(pub),
+			})
+		case *rsa.PublicKey:
+			set.Keys = append(set.Keys, JWK{
+				KID: key.KID,
+				Kty: "RSA",
+				Alg: key.Method.Alg(),
+				Use: "sig",
+				N:   base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+				E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+			})
+		}
+	}
+
+	sort.Slice(set.Keys, func(i, j int) bool { return set.Keys[i].KID < set.Keys[j].KID })
+	return set
+}
+
+func loadKeys() ([]*Key, string, error) {
+	if path := os.Getenv("JWT_KEYS_FILE"); path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to read JWT_KEYS_FILE: %w", err)
+		}
+
+		var cfg KeysConfig
+		if err := json.Unmarshal(data, &cfg); err != nil {
+			return nil, "", fmt.Errorf("failed to parse JWT_KEYS_FILE: %w", err)
+		}
+		if len(cfg.Keys) == 0 {
+			return nil, "", fmt.Errorf("JWT_KEYS_FILE contains no keys")
+		}
+
+		keys := make([]*Key, 0, len(cfg.Keys))
+		for _, kc := range cfg.Keys {
+			key, err := ParseKey(kc)
+			if err != nil {
+				return nil, "", err
+			}
+			keys = append(keys, key)
+		}
+
+		signingKID := cfg.SigningKID
+		if signingKID == "" {
+			signingKID = cfg.Keys[0].KID
+		}
+		return keys, signingKID, nil
+	}
+
+	secret := os.Getenv("JWT_SECRET")
+	if secret == "" {
+		log.Printf("WARNING: neither JWT_KEYS_FILE nor JWT_SECRET is set, using the insecure development secret")
+		secret = devSecret
+	}
+
+	key, err := ParseKey(KeyConfig{KID: legacyKID, Alg: "HS256", Secret: secret})
+	if err != nil {
+		return nil, "", err
+	}
+	return []*Key{key}, legacyKID, nil
+}
+
+// ParseKey loads the key material described by a KeyConfig
+func ParseKey(kc KeyConfig) (*Key, error) {
+	if kc.KID == "" {
+		return nil, fmt.Errorf("key is missing a kid")
+	}
+
+	key := &Key{KID: kc.KID, RetireAt: kc.RetireAt}
+
+	switch kc.Alg {
+	case "HS256":
+		key.Method = jwt.SigningMethodHS256
+		secret := []byte(kc.Secret)
+		if kc.SecretFile != "" {
+			data, err := os.ReadFile(kc.SecretFile)
+			if err != nil {
+				return nil, fmt.Errorf("key %q: failed to read secret file: %w", kc.KID, err)
+			}
+			secret = data
+		}
+		if len(secret) == 0 {
+			return nil, fmt.Errorf("key %q: HS256 requires secret or secret_file", kc.KID)
+		}
+		key.signKey = secret
+		key.verifKey = secret
+
+	case "EdDSA":
+		key.Method = jwt.SigningMethodEdDSA
+		if kc.PrivateKeyFile != "" {
+			data, err := os.ReadFile(kc.PrivateKeyFile)
+			if err != nil {
+				return nil, fmt.Errorf("key %q: failed to read private key: %w", kc.KID, err)
+			}
+			priv, err := jwt.ParseEdPrivateKeyFromPEM(data)
+			if err != nil {
+				return nil, fmt.Errorf("key %q: %w", kc.KID, err)
+			}
+			edPriv, ok := priv.(ed25519.PrivateKey)
+			if !ok {
+				return nil, fmt.Errorf("key %q: not an Ed25519 private key", kc.KID)
+			}
+			key.signKey = edPriv
+			key.verifKey = edPriv.Public()
+		} else if kc.PublicKeyFile != "" {
+			data, err := os.ReadFile(kc.PublicKeyFile)
+			if err != nil {
+				return nil, fmt.Errorf("key %q: failed to read public key: %w", kc.KID, err)
+			}
+			pub, err := jwt.ParseEdPublicKeyFromPEM(data)
+			if err != nil {
+				return nil, fmt.Errorf("key %q: %w", kc.KID, err)
+			}
+			key.verifKey = pub
+		} else {
+			return nil, fmt.Errorf("key %q: EdDSA requires private_key_file or public_key_file", kc.KID)
+		}
+
+	case "RS256":
+		key.Method = jwt.SigningMethodRS256
+		if kc.PrivateKeyFile != "" {
+			data, err := os.ReadFile(kc.PrivateKeyFile)
+			if err != nil {
+				return nil, fmt.Errorf("key %q: failed to read private key: %w", kc.KID, err)
+			}
+			priv, err := jwt.ParseRSAPrivateKeyFromPEM(data)
+			if err != nil {
+				return nil, fmt.Errorf("key %q: %w", kc.KID, err)
+			}
+			key.signKey = priv
+			key.verifKey = &priv.PublicKey
+		} else if kc.PublicKeyFile != "" {
+			data, err := os.ReadFile(kc.PublicKeyFile)
+			if err != nil {
+				return nil, fmt.Errorf("key %q: failed to read public key: %w", kc.KID, err)
+			}
+			pub, err := jwt.ParseRSAPublicKeyFromPEM(data)
+			if err != nil {
+				return nil, fmt.Errorf("key %q: %w", kc.KID, err)
+			}
+			key.verifKey = pub
+		} else {
+			return nil, fmt.Errorf("key %q: RS256 requires private_key_file or public_key_file", kc.KID)
+		}
+
+	default:
+		return nil, fmt.Errorf("key %q: unsupported algorithm %q", kc.KID, kc.Alg)
+	}
+
+	return key, nil
+}
@@ -0,0 +1,240 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// writePEM writes a PEM block to a file in dir and returns its path
+func writePEM(t *testing.T, dir, name, blockType string, der []byte) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func mustParseKey(t *testing.T, kc KeyConfig) *Key {
+	t.Helper()
+	key, err := ParseKey(kc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+// edKey returns an EdDSA key with kid and its public key PEM
+func edKey(t *testing.T, kid string) (*Key, []byte) {
+	t.Helper()
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	privDER, _ := x509.MarshalPKCS8PrivateKey(priv)
+	pubDER, _ := x509.MarshalPKIXPublicKey(pub)
+	path := writePEM(t, t.TempDir(), kid+".pem", "PRIVATE KEY", privDER)
+	return mustParseKey(t, KeyConfig{KID: kid, Alg: "EdDSA", PrivateKeyFile: path}),
+		pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pubDER})
+}
+
+// rsaKey returns an RS256 key with kid and its public key PEM
+func rsaKey(t *testing.T, kid string) (*Key, []byte) {
+	t.Helper()
+	priv, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pubDER, _ := x509.MarshalPKIXPublicKey(&priv.PublicKey)
+	path := writePEM(t, t.TempDir(), kid+".pem", "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(priv))
+	return mustParseKey(t, KeyConfig{KID: kid, Alg: "RS256", PrivateKeyFile: path}),
+		pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pubDER})
+}
+
+// claims are valid BruinMarket claims for the next hour
+func claims() jwt.RegisteredClaims {
+	return jwt.RegisteredClaims{Issuer: Issuer, ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour))}
+}
+
+func verify(m *KeyManager, token string) error {
+	_, err := jwt.ParseWithClaims(token, &jwt.RegisteredClaims{}, m.Keyfunc, m.ParserOptions()...)
+	return err
+}
+
+func TestOldKeyVerifiesUntilItRetires(t *testing.T) {
+	now := time.Now()
+	retireAt := now.Add(time.Hour)
+	old := mustParseKey(t, KeyConfig{KID: "old", Alg: "HS256", Secret: "old-secret", RetireAt: &retireAt})
+	current := mustParseKey(t, KeyConfig{KID: "new", Alg: "HS256", Secret: "new-secret"})
+
+	before, err := NewKeyManager([]*Key{old}, "old")
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, err := before.Sign(claims())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// After rotating, tokens from the old key still verify until retire_at
+	m, err := NewKeyManager([]*Key{old, current}, "new")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := verify(m, token); err != nil {
+		t.Fatalf("before retire_at: %v", err)
+	}
+	m.now = func() time.Time { return retireAt.Add(time.Second) }
+	if err := verify(m, token); err == nil {
+		t.Fatal("token from a retired key verified")
+	}
+
+	// And the old key's manager won't sign with it any more either
+	before.now = m.now
+	if _, err := before.Sign(claims()); err == nil {
+		t.Fatal("signed with a retired key")
+	}
+}
+
+func TestAlgorithmMismatchIsRejected(t *testing.T) {
+	ed, edPub := edKey(t, "ed")
+	rs, rsPub := rsaKey(t, "rs")
+	hs := mustParseKey(t, KeyConfig{KID: "hs", Alg: "HS256", Secret: "shared-secret"})
+	m, err := NewKeyManager([]*Key{ed, rs, hs}, "ed")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The classic confusion attack: HMAC-sign with the published public
+	// key and point the kid at the asymmetric key
+	for kid, pub := range map[string][]byte{"ed": edPub, "rs": rsPub} {
+		forged := jwt.NewWithClaims(jwt.SigningMethodHS256, claims())
+		forged.Header["kid"] = kid
+		signed, err := forged.SignedString(pub)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := verify(m, signed); err == nil {
+			t.Fatalf("HS256 token with %s kid verified", kid)
+		}
+	}
+
+	// Genuine tokens still verify
+	token, err := m.Sign(claims())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := verify(m, token); err != nil {
+		t.Fatalf("genuine token: %v", err)
+	}
+}
+
+func TestForeignAndOpenEndedTokensAreRejected(t *testing.T) {
+	key := mustParseKey(t, KeyConfig{KID: "shared", Alg: "HS256", Secret: "shared-secret"})
+	m, err := NewKeyManager([]*Key{key}, "shared")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Another service sharing the kid and secret still can't mint our tokens
+	foreign := claims()
+	foreign.Issuer = "other-service"
+	noExpiry := claims()
+	noExpiry.ExpiresAt = nil
+	for name, c := range map[string]jwt.RegisteredClaims{"foreign iss": foreign, "no exp": noExpiry} {
+		token, err := m.Sign(c)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := verify(m, token); err == nil {
+			t.Fatalf("token with %s verified", name)
+		}
+	}
+
+	token, err := m.Sign(claims())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := verify(m, token); err != nil {
+		t.Fatalf("genuine token: %v", err)
+	}
+}
+
+func TestJWKSOmitsSymmetricKeys(t *testing.T) {
+	ed, _ := edKey(t, "ed")
+	rs, _ := rsaKey(t, "rs")
+	hs := mustParseKey(t, KeyConfig{KID: "hs", Alg: "HS256", Secret: "do-not-publish"})
+	m, err := NewKeyManager([]*Key{ed, rs, hs}, "hs")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	set := m.JWKS()
+	if len(set.Keys) != 2 || set.Keys[0].KID != "ed" || set.Keys[1].KID != "rs" {
+		t.Fatalf("JWKS: got %+v, want just ed and rs", set.Keys)
+	}
+	data, _ := json.Marshal(set)
+	if strings.Contains(string(data), `"hs"`) || strings.Contains(string(data), "do-not-publish") {
+		t.Fatalf("JWKS exposes the HS256 key: %s", data)
+	}
+}
+
+func TestReloadSwapsKeys(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keys.json")
+	writeKeys := func(cfg KeysConfig) {
+		t.Helper()
+		data, _ := json.Marshal(cfg)
+		if err := os.WriteFile(path, data, 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Setenv("JWT_KEYS_FILE", path)
+
+	writeKeys(KeysConfig{SigningKID: "k1", Keys: []KeyConfig{{KID: "k1", Alg: "HS256", Secret: "one"}}})
+	m, err := LoadKeyManager()
+	if err != nil {
+		t.Fatal(err)
+	}
+	oldToken, err := m.Sign(claims())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	writeKeys(KeysConfig{SigningKID: "k2", Keys: []KeyConfig{{KID: "k2", Alg: "HS256", Secret: "two"}}})
+	if err := m.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	token, err := m.Sign(claims())
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, _, _ := jwt.NewParser().ParseUnverified(token, &jwt.RegisteredClaims{})
+	if parsed.Header["kid"] != "k2" {
+		t.Fatalf("signed with kid %v after reload, want k2", parsed.Header["kid"])
+	}
+	if err := verify(m, oldToken); err == nil {
+		t.Fatal("token from a removed key still verifies")
+	}
+
+	// A broken file leaves the current keys in place
+	if err := os.WriteFile(path, []byte("{"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Reload(); err == nil {
+		t.Fatal("reloading a broken file succeeded")
+	}
+	if err := verify(m, token); err != nil {
+		t.Fatalf("after failed reload: %v", err)
+	}
+}
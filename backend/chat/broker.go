@@ -0,0 +1,42 @@
+package chat
+
+import "sync"
+
+// Broker carries pushes between backend instances, so a message saved on one
+// replica reaches the recipient's connections on every replica. Each Hub
+// subscribes once and delivers what it receives to its own connections.
+type Broker interface {
+	// Publish sends a JSON payload for userID to every subscriber,
+	// including the publishing instance's own
+	Publish(userID string, payload []byte) error
+	// Subscribe registers deliver to be called with each published push
+	Subscribe(deliver func(userID string, payload []byte))
+}
+
+// MemoryBroker is a Broker for a single instance: pushes go straight to the
+// subscribers in this process
+type MemoryBroker struct {
+	mu          sync.RWMutex
+	subscribers []func(userID string, payload []byte)
+}
+
+func NewMemoryBroker() *MemoryBroker {
+	return &MemoryBroker{}
+}
+
+func (b *MemoryBroker) Publish(userID string, payload []byte) error {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	for _, deliver := range b.subscribers {
+		deliver(userID, payload)
+	}
+	return nil
+}
+
+func (b *MemoryBroker) Subscribe(deliver func(userID string, payload []byte)) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.subscribers = append(b.subscribers, deliver)
+}
+
+var _ Broker = (*MemoryBroker)(nil)
@@ -0,0 +1,483 @@
+package chat
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"bruinmarket-backend/models"
+	"bruinmarket-backend/store"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+)
+
+// Config tunes the WebSocket connections the hub serves
+type Config struct {
+	// PingInterval is how often the server pings each connection. It must be
+	// shorter than PongTimeout.
+	PingInterval time.Duration
+	// PongTimeout is how long a connection can go without answering a ping
+	// (or sending anything) before it is dropped
+	PongTimeout time.Duration
+	// WriteTimeout bounds each write to a connection
+	WriteTimeout time.Duration
+	// MaxMessageSize is the largest frame accepted from a client, in bytes
+	MaxMessageSize int64
+	// SendBuffer is how many outgoing frames can queue for a connection
+	// before it is treated as too slow and dropped
+	SendBuffer int
+	// AwayAfter is how long a connected user can go without sending
+	// anything before they show as away
+	AwayAfter time.Duration
+}
+
+// DefaultConfig pings every 54 seconds and drops connections that haven't
+// answered within a minute
+func DefaultConfig() Config {
+	return Config{
+		PingInterval:   54 * time.Second,
+		PongTimeout:    60 * time.Second,
+		WriteTimeout:   10 * time.Second,
+		MaxMessageSize: 8 * 1024,
+		SendBuffer:     256,
+		AwayAfter:      5 * time.Minute,
+	}
+}
+
+// Client is one WebSocket connection. A user has one per open tab or device.
+// Send is only ever closed by Hub.Run, when the client is unregistered.
+type Client struct {
+	ID     string
+	UserID string
+	Conn   *websocket.Conn
+	Send   chan []byte
+	hub    *Hub
+	kick   sync.Once
+	// lastActive is when activity was last recorded; only readPump uses it
+	lastActive time.Time
+}
+
+type Hub struct {
+	// Clients holds each user's open connections
+	Clients       map[string]map[*Client]struct{}
+	Broadcast     chan []byte
+	Register      chan *Client
+	Unregister    chan *Client
+	mu            sync.RWMutex
+	broker        Broker
+	config        Config
+	presence      store.PresenceStore
+	conversations store.ConversationStore
+	messages      store.MessageStore
+}
+
+// WSMessage is a chat frame. Type is "message" for text sent by a user, or
+// one of the models.MessageOffer* types for offer events, which also carry
+// the offer. Clients sending a message only fill in Type, ConversationID,
+// Content and optionally ClientID; the server works out the rest.
+type WSMessage struct {
+	Type           string        `json:"type"`
+	ConversationID string        `json:"conversation_id"`
+	SenderID       string        `json:"sender_id"`
+	ReceiverID     string        `json:"receiver_id"`
+	Content        string        `json:"content"`
+	MessageID      string        `json:"message_id"`
+	CreatedAt      time.Time     `json:"created_at"`
+	OfferID        string        `json:"offer_id,omitempty"`
+	Offer          *models.Offer `json:"offer,omitempty"`
+	// ClientID is the sender's own ID for the frame, echoed on the
+	// confirmation or error so they can match them up
+	ClientID string `json:"client_id,omitempty"`
+}
+
+// Codes sent in error frames
+const (
+	ErrInvalidFrame   = "invalid_frame"
+	ErrUnknownType    = "unknown_type"
+	ErrInvalidMessage = "invalid_message"
+	ErrNotMember      = "not_member"
+	ErrInternal       = "internal_error"
+)
+
+// Frame types for typing indicators, relayed to the other member of the
+// conversation as a TypingFrame
+const (
+	TypeTypingStart = "typing_start"
+	TypeTypingStop  = "typing_stop"
+)
+
+// TypingFrame tells a user the other member of a conversation started or
+// stopped typing
+type TypingFrame struct {
+	Type           string `json:"type"`
+	ConversationID string `json:"conversation_id"`
+	UserID         string `json:"user_id"`
+}
+
+// ErrorFrame tells a client why a frame it sent was rejected
+type ErrorFrame struct {
+	Type     string `json:"type"` // Always "error"
+	Code     string `json:"code"`
+	Error    string `json:"error"`
+	ClientID string `json:"client_id,omitempty"`
+}
+
+// NewHub serves this instance's connections. Pushes go out through broker,
+// which hands them back to every instance's hub for delivery.
+func NewHub(conversations store.ConversationStore, messages store.MessageStore, presence store.PresenceStore, broker Broker, config Config) *Hub {
+	h := &Hub{
+		Clients:       make(map[string]map[*Client]struct{}),
+		Broadcast:     make(chan []byte),
+		Register:      make(chan *Client),
+		Unregister:    make(chan *Client),
+		broker:        broker,
+		config:        config,
+		presence:      presence,
+		conversations: conversations,
+		messages:      messages,
+	}
+	broker.Subscribe(h.deliver)
+	return h
+}
+
+func (h *Hub) Run() {
+	for {
+		select {
+		case client := <-h.Register:
+			h.mu.Lock()
+			if h.Clients[client.UserID] == nil {
+				h.Clients[client.UserID] = make(map[*Client]struct{})
+			}
+			h.Clients[client.UserID][client] = struct{}{}
+			h.mu.Unlock()
+
+		case client := <-h.Unregister:
+			// Only this connection goes; the user's other devices stay
+			h.mu.Lock()
+			if _, ok := h.Clients[client.UserID][client]; ok {
+				delete(h.Clients[client.UserID], client)
+				if len(h.Clients[client.UserID]) == 0 {
+					delete(h.Clients, client.UserID)
+				}
+				close(client.Send)
+			}
+			h.mu.Unlock()
+
+		case message := <-h.Broadcast:
+			h.mu.RLock()
+			for _, clients := range h.Clients {
+				for client := range clients {
+					client.push(message)
+				}
+			}
+			h.mu.RUnlock()
+		}
+	}
+}
+
+// SendToUser pushes a JSON-encoded payload to every connection the user has
+// open, on any instance
+func (h *Hub) SendToUser(userID string, payload []byte) {
+	if err := h.broker.Publish(userID, payload); err != nil {
+		// The user's connections here can still get it
+		log.Printf("Error publishing push to %s: %v", userID, err)
+		h.deliver(userID, payload)
+	}
+}
+
+// deliver pushes a payload to the user's connections on this instance
+func (h *Hub) deliver(userID string, payload []byte) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	disconnect := bytes.Equal(payload, disconnectPayload)
+	for client := range h.Clients[userID] {
+		if disconnect {
+			client.close(websocket.ClosePolicyViolation, "account suspended")
+		} else {
+			client.push(payload)
+		}
+	}
+}
+
+// disconnectPayload is published in place of a frame to drop a user's
+// connections on every instance. Clients never see it.
+var disconnectPayload = []byte(`{"type":"_disconnect"}`)
+
+// DisconnectUser closes every connection the user has open, on any
+// instance, e.g. once they have been suspended
+func (h *Hub) DisconnectUser(userID string) {
+	h.SendToUser(userID, disconnectPayload)
+}
+
+// Connections reports how many connections the user has open on this
+// instance
+func (h *Hub) Connections(userID string) int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return len(h.Clients[userID])
+}
+
+// push queues a payload without blocking. A connection whose buffer is full
+// has fallen too far behind, so it is disconnected and the client can
+// reconnect and catch up from the API. Callers hold h.mu or are the client's
+// own readPump, so Send can't be closed underneath them.
+func (c *Client) push(payload []byte) {
+	select {
+	case c.Send <- payload:
+	default:
+		c.close(websocket.CloseTryAgainLater, "send buffer full")
+	}
+}
+
+// close sends a close frame and shuts the connection. That ends readPump,
+// which unregisters the client; Hub.Run then closes Send. It doesn't wait,
+// since callers may hold h.mu and the close frame can take up to
+// WriteTimeout to go out.
+func (c *Client) close(code int, reason string) {
+	c.kick.Do(func() {
+		log.Printf("Closing WebSocket connection for %s: %s", c.UserID, reason)
+		go func() {
+			deadline := time.Now().Add(c.hub.config.WriteTimeout)
+			c.Conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, reason), deadline)
+			c.Conn.Close()
+		}()
+	})
+}
+
+// ServeClient registers an upgraded connection for userID and starts its pumps
+func (h *Hub) ServeClient(conn *websocket.Conn, userID string) {
+	client := &Client{
+		ID:     uuid.New().String(),
+		UserID: userID,
+		Conn:   conn,
+		Send:   make(chan []byte, h.config.SendBuffer),
+		hub:    h,
+	}
+
+	client.lastActive = time.Now()
+	if err := h.presence.AddConnection(client.ID, userID, client.lastActive); err != nil {
+		log.Printf("Error recording connection for %s: %v", userID, err)
+	}
+	h.Register <- client
+
+	go client.writePump()
+	go client.readPump()
+}
+
+func (c *Client) readPump() {
+	hub := c.hub
+	defer func() {
+		hub.Unregister <- c
+		c.Conn.Close()
+		if err := hub.presence.RemoveConnection(c.ID, c.UserID, time.Now()); err != nil {
+			log.Printf("Error removing connection for %s: %v", c.UserID, err)
+		}
+	}()
+
+	// Any frame or pong from the client shows it is still there
+	c.Conn.SetReadLimit(hub.config.MaxMessageSize)
+	c.Conn.SetReadDeadline(time.Now().Add(hub.config.PongTimeout))
+	c.Conn.SetPongHandler(func(string) error {
+		if err := hub.presence.TouchConnection(c.ID, c.UserID, time.Now(), false); err != nil {
+			log.Printf("Error updating connection for %s: %v", c.UserID, err)
+		}
+		return c.Conn.SetReadDeadline(time.Now().Add(hub.config.PongTimeout))
+	})
+
+	for {
+		_, data, err := c.Conn.ReadMessage()
+		if err != nil {
+			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseNormalClosure, websocket.CloseNoStatusReceived, websocket.CloseAbnormalClosure) {
+				log.Printf("WebSocket read error for %s: %v", c.UserID, err)
+			}
+			break
+		}
+		c.Conn.SetReadDeadline(time.Now().Add(hub.config.PongTimeout))
+		c.markActive()
+
+		var frame WSMessage
+		if err := json.Unmarshal(data, &frame); err != nil {
+			c.sendError("", ErrInvalidFrame, "frames must be JSON objects")
+			continue
+		}
+
+		switch frame.Type {
+		case models.MessageText:
+			hub.sendMessage(c, frame)
+		case TypeTypingStart, TypeTypingStop:
+			hub.relayTyping(c, frame)
+		default:
+			c.sendError(frame.ClientID, ErrUnknownType, fmt.Sprintf("unknown frame type %q", frame.Type))
+		}
+	}
+}
+
+// sendMessage stores a text message from c and delivers it to both members
+// of the conversation. Only the conversation and content come from the
+// frame: the sender is always the connection's user and the receiver is the
+// conversation's other member.
+func (h *Hub) sendMessage(c *Client, frame WSMessage) {
+	if strings.TrimSpace(frame.Content) == "" {
+		c.sendError(frame.ClientID, ErrInvalidMessage, "message content is required")
+		return
+	}
+
+	conversation, receiverID, ok := h.conversationFor(c, frame)
+	if !ok {
+		return
+	}
+
+	now := time.Now()
+	msg := &models.Message{
+		ID:             uuid.New().String(),
+		ConversationID: conversation.ID,
+		SenderID:       c.UserID,
+		ReceiverID:     receiverID,
+		Type:           models.MessageText,
+		Content:        frame.Content,
+		CreatedAt:      now,
+	}
+	if err := h.messages.CreateMessage(msg); err != nil {
+		log.Printf("Error saving message: %v", err)
+		c.sendError(frame.ClientID, ErrInternal, "failed to send message")
+		return
+	}
+
+	// Update conversation last message
+	if err := h.conversations.UpdateLastMessage(conversation.ID, msg.Content, now); err != nil {
+		log.Printf("Error updating conversation: %v", err)
+	}
+
+	out := WSMessage{
+		Type:           models.MessageText,
+		ConversationID: conversation.ID,
+		SenderID:       c.UserID,
+		ReceiverID:     receiverID,
+		Content:        msg.Content,
+		MessageID:      msg.ID,
+		CreatedAt:      now,
+	}
+	payload, _ := json.Marshal(out)
+	h.SendToUser(receiverID, payload)
+
+	// Send back to sender (confirmation with the message)
+	out.ClientID = frame.ClientID
+	payload, _ = json.Marshal(out)
+	h.SendToUser(c.UserID, payload)
+}
+
+// relayTyping tells the other member of the conversation that c's user
+// started or stopped typing. Nothing is stored.
+func (h *Hub) relayTyping(c *Client, frame WSMessage) {
+	conversation, receiverID, ok := h.conversationFor(c, frame)
+	if !ok {
+		return
+	}
+	payload, _ := json.Marshal(TypingFrame{Type: frame.Type, ConversationID: conversation.ID, UserID: c.UserID})
+	h.SendToUser(receiverID, payload)
+}
+
+// conversationFor loads the frame's conversation and works out who is on the
+// other end. It answers c with an error frame and returns false if c's user
+// isn't a member.
+func (h *Hub) conversationFor(c *Client, frame WSMessage) (*models.Conversation, string, bool) {
+	conversation, err := h.conversations.GetConversation(frame.ConversationID)
+	if err == store.ErrNotFound || (err == nil && !conversation.HasMember(c.UserID)) {
+		c.sendError(frame.ClientID, ErrNotMember, "you are not in this conversation")
+		return nil, "", false
+	}
+	if err != nil {
+		log.Printf("Error fetching conversation: %v", err)
+		c.sendError(frame.ClientID, ErrInternal, "failed to load conversation")
+		return nil, "", false
+	}
+	otherID := conversation.User1ID
+	if otherID == c.UserID {
+		otherID = conversation.User2ID
+	}
+	return conversation, otherID, true
+}
+
+// markActive records that the user sent something. Writes are spaced out so
+// a chatty client doesn't hit the store on every frame.
+func (c *Client) markActive() {
+	now := time.Now()
+	interval := c.hub.config.AwayAfter / 2
+	if interval > 30*time.Second {
+		interval = 30 * time.Second
+	}
+	if now.Sub(c.lastActive) < interval {
+		return
+	}
+	c.lastActive = now
+	if err := c.hub.presence.TouchConnection(c.ID, c.UserID, now, true); err != nil {
+		log.Printf("Error updating connection for %s: %v", c.UserID, err)
+	}
+}
+
+// Presence reports whether each of the users is online, away or offline,
+// keyed by user ID. Users that don't exist are left out.
+func (h *Hub) Presence(userIDs ...string) (map[string]models.Presence, error) {
+	now := time.Now()
+	// Live connections are touched at least every ping interval
+	records, err := h.presence.ListPresence(userIDs, now.Add(-2*h.config.PongTimeout))
+	if err != nil {
+		return nil, err
+	}
+
+	presence := make(map[string]models.Presence, len(records))
+	for _, p := range records {
+		p.Status = models.PresenceOffline
+		if p.Online {
+			p.Status = models.PresenceOnline
+			if p.LastActiveAt != nil && now.Sub(*p.LastActiveAt) > h.config.AwayAfter {
+				p.Status = models.PresenceAway
+			}
+		}
+		presence[p.UserID] = p
+	}
+	return presence, nil
+}
+
+// sendError queues an error frame for this connection only
+func (c *Client) sendError(clientID, code, message string) {
+	payload, _ := json.Marshal(ErrorFrame{Type: "error", Code: code, Error: message, ClientID: clientID})
+	c.push(payload)
+}
+
+// writePump is the only writer to the connection. It sends queued frames
+// and pings until Hub.Run closes Send or a write fails.
+func (c *Client) writePump() {
+	config := c.hub.config
+	ticker := time.NewTicker(config.PingInterval)
+	defer func() {
+		ticker.Stop()
+		c.Conn.Close()
+	}()
+
+	for {
+		select {
+		case message, ok := <-c.Send:
+			c.Conn.SetWriteDeadline(time.Now().Add(config.WriteTimeout))
+			if !ok {
+				// Unregistered by the hub
+				c.Conn.WriteMessage(websocket.CloseMessage, []byte{})
+				return
+			}
+			if err := c.Conn.WriteMessage(websocket.TextMessage, message); err != nil {
+				return
+			}
+		case <-ticker.C:
+			c.Conn.SetWriteDeadline(time.Now().Add(config.WriteTimeout))
+			if err := c.Conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				return
+			}
+		}
+	}
+}
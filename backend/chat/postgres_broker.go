@@ -0,0 +1,147 @@
+package chat
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// notifyChannel is the Postgres channel chat pushes travel on
+const notifyChannel = "chat_push"
+
+// maxNotifyPayload keeps NOTIFY payloads under Postgres' 8000 byte limit.
+// Bigger pushes are stored in chat_pushes and sent by reference.
+const maxNotifyPayload = 7900
+
+// How long spilled pushes are kept for the other instances to read
+const spilledPushTTL = 5 * time.Minute
+
+// envelope is a NOTIFY payload: either the push itself or the ID of the
+// chat_pushes row holding it
+type envelope struct {
+	UserID  string          `json:"user_id,omitempty"`
+	Payload json.RawMessage `json:"payload,omitempty"`
+	Ref     int64           `json:"ref,omitempty"`
+}
+
+// PostgresBroker relays pushes between instances with LISTEN/NOTIFY. Every
+// instance listens on the same channel, so each one sees every push and
+// delivers those for users connected to it.
+type PostgresBroker struct {
+	db          *sql.DB
+	listener    *pq.Listener
+	mu          sync.RWMutex
+	subscribers []func(userID string, payload []byte)
+}
+
+// NewPostgresBroker publishes through db and listens on a separate
+// connection to connStr, which lib/pq re-establishes if it drops
+func NewPostgresBroker(db *sql.DB, connStr string) (*PostgresBroker, error) {
+	listener := pq.NewListener(connStr, time.Second, time.Minute, func(event pq.ListenerEventType, err error) {
+		if err != nil {
+			log.Printf("Chat broker listener error: %v", err)
+		}
+	})
+	if err := listener.Listen(notifyChannel); err != nil {
+		listener.Close()
+		return nil, fmt.Errorf("failed to listen on %s: %w", notifyChannel, err)
+	}
+	return &PostgresBroker{db: db, listener: listener}, nil
+}
+
+func (b *PostgresBroker) Publish(userID string, payload []byte) error {
+	message, err := json.Marshal(envelope{UserID: userID, Payload: payload})
+	if err != nil {
+		return err
+	}
+	if len(message) > maxNotifyPayload {
+		var id int64
+		err := b.db.QueryRow(
+			"INSERT INTO chat_pushes (user_id, payload) VALUES ($1, $2) RETURNING id",
+			userID, string(payload),
+		).Scan(&id)
+		if err != nil {
+			return err
+		}
+		message, _ = json.Marshal(envelope{Ref: id})
+	}
+
+	_, err = b.db.Exec("SELECT pg_notify($1, $2)", notifyChannel, string(message))
+	return err
+}
+
+func (b *PostgresBroker) Subscribe(deliver func(userID string, payload []byte)) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.subscribers = append(b.subscribers, deliver)
+}
+
+// Run hands notifications to the subscribers until Close is called. It also
+// keeps the listening connection checked and clears out old spilled pushes.
+func (b *PostgresBroker) Run() {
+	ping := time.NewTicker(90 * time.Second)
+	defer ping.Stop()
+	cleanup := time.NewTicker(time.Minute)
+	defer cleanup.Stop()
+
+	for {
+		select {
+		case notification, ok := <-b.listener.Notify:
+			if !ok {
+				return
+			}
+			if notification == nil {
+				// lib/pq sends nil after reconnecting
+				log.Printf("Chat broker reconnected; pushes sent while it was disconnected were missed")
+				continue
+			}
+			b.dispatch(notification.Extra)
+
+		case <-ping.C:
+			go b.listener.Ping()
+
+		case <-cleanup.C:
+			cutoff := time.Now().Add(-spilledPushTTL)
+			if _, err := b.db.Exec("DELETE FROM chat_pushes WHERE created_at < $1", cutoff); err != nil {
+				log.Printf("Error clearing old chat pushes: %v", err)
+			}
+		}
+	}
+}
+
+// dispatch decodes a NOTIFY payload and passes the push to the subscribers
+func (b *PostgresBroker) dispatch(extra string) {
+	var message envelope
+	if err := json.Unmarshal([]byte(extra), &message); err != nil {
+		log.Printf("Ignoring malformed chat push: %v", err)
+		return
+	}
+	userID, payload := message.UserID, []byte(message.Payload)
+	if message.Ref != 0 {
+		var stored string
+		err := b.db.QueryRow("SELECT user_id, payload FROM chat_pushes WHERE id = $1", message.Ref).Scan(&userID, &stored)
+		if err != nil {
+			log.Printf("Error loading chat push %d: %v", message.Ref, err)
+			return
+		}
+		payload = []byte(stored)
+	}
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	for _, deliver := range b.subscribers {
+		deliver(userID, payload)
+	}
+}
+
+// Close stops listening, which also ends Run
+func (b *PostgresBroker) Close() error {
+	return b.listener.Close()
+}
+
+var _ Broker = (*PostgresBroker)(nil)
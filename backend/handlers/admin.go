@@ -0,0 +1,155 @@
+package handlers
+
+import (
+	"bruinmarket-backend/chat"
+	"bruinmarket-backend/models"
+	"bruinmarket-backend/store"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Page size limits for the admin user list
+const (
+	defaultUserListLimit = 50
+	maxUserListLimit     = 200
+)
+
+type AdminHandler struct {
+	users    store.UserStore
+	sessions store.SessionStore
+	hub      *chat.Hub
+}
+
+func NewAdminHandler(users store.UserStore, sessions store.SessionStore, hub *chat.Hub) *AdminHandler {
+	return &AdminHandler{
+		users:    users,
+		sessions: sessions,
+		hub:      hub,
+	}
+}
+
+// GetUsers lists accounts, newest first. ?search= matches part of the email
+// or name, ?role= filters by role and ?suspended=true shows suspended users.
+func (h *AdminHandler) GetUsers(c *gin.Context) {
+	filter := store.UserFilter{
+		Search:    c.Query("search"),
+		Role:      c.Query("role"),
+		Suspended: c.Query("suspended") == "true",
+		Limit:     defaultUserListLimit,
+	}
+	if filter.Role != "" && !models.ValidRole(filter.Role) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "role must be user, moderator or admin"})
+		return
+	}
+	if v := c.Query("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 1 || limit > maxUserListLimit {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be between 1 and 200"})
+			return
+		}
+		filter.Limit = limit
+	}
+
+	users, err := h.users.ListUsers(filter)
+	if err != nil {
+		log.Printf("Error listing users: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch users"})
+		return
+	}
+
+	c.JSON(http.StatusOK, users)
+}
+
+// SetUserRole changes a user's role with {"role": "user"|"moderator"|"admin"}.
+// Their current access tokens stop working, so they pick up the new role on
+// their next refresh.
+func (h *AdminHandler) SetUserRole(c *gin.Context) {
+	var input struct {
+		Role string `json:"role" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil || !models.ValidRole(input.Role) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "role must be user, moderator or admin"})
+		return
+	}
+
+	userID := c.Param("user_id")
+	if userID == c.GetString("user_id") {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "you cannot change your own role"})
+		return
+	}
+
+	err := h.users.SetUserRole(userID, input.Role)
+	if err == store.ErrNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+		return
+	}
+	if err != nil {
+		log.Printf("Error setting user role: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update role"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "role updated", "role": input.Role})
+}
+
+// SuspendUser locks a user out and signs them out everywhere
+func (h *AdminHandler) SuspendUser(c *gin.Context) {
+	userID := c.Param("user_id")
+	if userID == c.GetString("user_id") {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "you cannot suspend yourself"})
+		return
+	}
+	_, err := h.users.GetUserByID(userID)
+	if err == store.ErrNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database error"})
+		return
+	}
+
+	if !suspendUser(c, h.users, h.sessions, h.hub, userID) {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "user suspended"})
+}
+
+// UnsuspendUser lets a suspended user log in again
+func (h *AdminHandler) UnsuspendUser(c *gin.Context) {
+	err := h.users.UnsuspendUser(c.Param("user_id"))
+	if err == store.ErrNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": "no suspended user with that id"})
+		return
+	}
+	if err != nil {
+		log.Printf("Error unsuspending user: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to unsuspend user"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "user unsuspended"})
+}
+
+// suspendUser suspends the user, revokes their sessions and closes their chat
+// connections, writing the error response and returning false if that fails.
+// Suspending someone who is already suspended is not an error.
+func suspendUser(c *gin.Context, users store.UserStore, sessions store.SessionStore, hub *chat.Hub, userID string) bool {
+	now := time.Now()
+	err := users.SuspendUser(userID, now)
+	if err != nil && err != store.ErrNotFound {
+		log.Printf("Error suspending user: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to suspend user"})
+		return false
+	}
+	if err := sessions.RevokeUserSessions(userID, now); err != nil {
+		log.Printf("Error revoking suspended user's sessions: %v", err)
+	}
+	hub.DisconnectUser(userID)
+	return true
+}
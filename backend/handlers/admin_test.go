@@ -0,0 +1,157 @@
+package handlers
+
+import (
+	"net/http"
+	"testing"
+
+	"bruinmarket-backend/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRolesAndAdminRoutes(t *testing.T) {
+	env := newTestEnv(t)
+	admin := env.grantRole(t, env.signUp(t, "admin@ucla.edu", "Ada Admin"), models.RoleAdmin)
+	mod := env.grantRole(t, env.signUp(t, "mod@ucla.edu", "Mod Bruin"), models.RoleModerator)
+	joe := env.signUp(t, "joe@ucla.edu", "Joe Bruin")
+
+	claims, err := env.handlers.Auth.ParseToken(admin.Token)
+	if err != nil || claims.Role != models.RoleAdmin || admin.User.Role != models.RoleAdmin {
+		t.Fatalf("admin token: got %+v, %v", claims, err)
+	}
+
+	access := []struct {
+		token, path string
+		want        int
+	}{
+		{joe.Token, "/admin/reports", http.StatusForbidden},
+		{mod.Token, "/admin/reports", http.StatusOK},
+		{admin.Token, "/admin/reports", http.StatusOK},
+		{mod.Token, "/admin/users", http.StatusForbidden},
+		{admin.Token, "/admin/users", http.StatusOK},
+		{"", "/admin/reports", http.StatusUnauthorized},
+	}
+	for _, a := range access {
+		if w := env.do("GET", a.path, a.token, nil); w.Code != a.want {
+			t.Errorf("GET %s: got %d, want %d", a.path, w.Code, a.want)
+		}
+	}
+
+	// Changing a role revokes the old token; refreshing picks up the new role
+	if w := env.do("PATCH", "/admin/users/"+joe.User.ID+"/role", admin.Token, gin.H{"role": "moderator"}); w.Code != http.StatusOK {
+		t.Fatalf("set role: got %d %s", w.Code, w.Body.String())
+	}
+	if w := env.do("GET", "/auth/me", joe.Token, nil); w.Code != http.StatusUnauthorized {
+		t.Fatalf("token with old role: got %d, want 401", w.Code)
+	}
+	var refreshed AuthResponse
+	decode(t, env.do("POST", "/auth/refresh", "", gin.H{"refresh_token": joe.RefreshToken}), &refreshed)
+	if w := env.do("GET", "/admin/reports", refreshed.Token, nil); w.Code != http.StatusOK {
+		t.Fatalf("promoted user: got %d, want 200", w.Code)
+	}
+	if w := env.do("PATCH", "/admin/users/"+admin.User.ID+"/role", admin.Token, gin.H{"role": "user"}); w.Code != http.StatusBadRequest {
+		t.Fatalf("own role: got %d, want 400", w.Code)
+	}
+	if w := env.do("PATCH", "/admin/users/"+joe.User.ID+"/role", admin.Token, gin.H{"role": "owner"}); w.Code != http.StatusBadRequest {
+		t.Fatalf("unknown role: got %d, want 400", w.Code)
+	}
+
+	var moderators []models.User
+	decode(t, env.do("GET", "/admin/users?role=moderator", admin.Token, nil), &moderators)
+	if len(moderators) != 2 || moderators[0].ID != joe.User.ID || moderators[1].ID != mod.User.ID {
+		t.Fatalf("moderators: got %+v", moderators)
+	}
+
+	// Moderators can't suspend staff from a report, admins can
+	env.createPost(t, admin.Token, gin.H{"title": "Desk lamp", "description": "Bright", "price": 15, "category": "Furniture", "type": "selling"})
+	var staff models.Report
+	decode(t, env.do("POST", "/reports", mod.Token, gin.H{"target_type": "user", "target_id": joe.User.ID, "reason": models.ReportSpam}), &staff)
+	if w := env.do("POST", "/admin/reports/"+staff.ID+"/suspend", mod.Token, nil); w.Code != http.StatusForbidden {
+		t.Fatalf("moderator suspending staff: got %d, want 403", w.Code)
+	}
+
+	if w := env.do("POST", "/admin/users/"+joe.User.ID+"/suspend", admin.Token, nil); w.Code != http.StatusOK {
+		t.Fatalf("suspend: got %d %s", w.Code, w.Body.String())
+	}
+	login := gin.H{"email": "joe@ucla.edu", "password": "secret1"}
+	if w := env.do("POST", "/auth/login", "", login); w.Code != http.StatusForbidden {
+		t.Fatalf("suspended login: got %d, want 403", w.Code)
+	}
+	var suspended []models.User
+	decode(t, env.do("GET", "/admin/users?suspended=true&search=JOE", admin.Token, nil), &suspended)
+	if len(suspended) != 1 || suspended[0].SuspendedAt == nil {
+		t.Fatalf("suspended users: got %+v", suspended)
+	}
+	if w := env.do("POST", "/admin/users/"+joe.User.ID+"/unsuspend", admin.Token, nil); w.Code != http.StatusOK {
+		t.Fatalf("unsuspend: got %d", w.Code)
+	}
+	if w := env.do("POST", "/admin/users/"+joe.User.ID+"/unsuspend", admin.Token, nil); w.Code != http.StatusNotFound {
+		t.Fatalf("unsuspend twice: got %d, want 404", w.Code)
+	}
+	if w := env.do("POST", "/auth/login", "", login); w.Code != http.StatusOK {
+		t.Fatalf("login after unsuspending: got %d", w.Code)
+	}
+}
+
+func TestCategoryManagement(t *testing.T) {
+	env := newTestEnv(t)
+	admin := env.grantRole(t, env.signUp(t, "admin@ucla.edu", "Ada Admin"), models.RoleAdmin)
+	mod := env.grantRole(t, env.signUp(t, "mod@ucla.edu", "Mod Bruin"), models.RoleModerator)
+
+	var categories []models.Category
+	decode(t, env.do("GET", "/categories", "", nil), &categories)
+	if len(categories) != len(models.DefaultCategories) || categories[0].Name != "Clothing" {
+		t.Fatalf("default categories: got %+v", categories)
+	}
+
+	if w := env.do("POST", "/admin/categories", mod.Token, gin.H{"name": "Textbooks"}); w.Code != http.StatusForbidden {
+		t.Fatalf("moderator creating category: got %d, want 403", w.Code)
+	}
+	var created models.Category
+	decode(t, env.do("POST", "/admin/categories", admin.Token, gin.H{"name": " Textbooks "}), &created)
+	if created.Name != "Textbooks" || created.Position != len(models.DefaultCategories)+1 {
+		t.Fatalf("created: got %+v", created)
+	}
+	if w := env.do("POST", "/admin/categories", admin.Token, gin.H{"name": "Textbooks"}); w.Code != http.StatusConflict {
+		t.Fatalf("duplicate category: got %d, want 409", w.Code)
+	}
+
+	// Renaming moves the category's posts along
+	lamp := env.createPost(t, admin.Token, gin.H{"title": "Desk lamp", "description": "Bright", "price": 15, "category": "Decorations", "type": "selling"})
+	if w := env.do("PUT", "/admin/categories/Decorations", admin.Token, gin.H{"name": "Decor"}); w.Code != http.StatusOK {
+		t.Fatalf("rename: got %d %s", w.Code, w.Body.String())
+	}
+	var post models.Post
+	decode(t, env.do("GET", "/posts/"+lamp.ID, "", nil), &post)
+	if post.Category != "Decor" {
+		t.Fatalf("renamed post category: got %q", post.Category)
+	}
+	if w := env.do("PUT", "/admin/categories/Decor", admin.Token, gin.H{"name": "Textbooks"}); w.Code != http.StatusConflict {
+		t.Fatalf("rename onto existing: got %d, want 409", w.Code)
+	}
+
+	if w := env.do("DELETE", "/admin/categories/Decor", admin.Token, nil); w.Code != http.StatusOK {
+		t.Fatalf("delete: got %d", w.Code)
+	}
+	if w := env.do("DELETE", "/admin/categories/Decor", admin.Token, nil); w.Code != http.StatusNotFound {
+		t.Fatalf("delete twice: got %d, want 404", w.Code)
+	}
+
+	// Posts have to use a current category
+	if w := env.do("POST", "/posts", admin.Token, gin.H{"title": "Vase", "description": "Blue", "price": 5, "category": "Decor", "type": "selling"}); w.Code != http.StatusBadRequest {
+		t.Fatalf("post in deleted category: got %d, want 400", w.Code)
+	}
+	env.createPost(t, admin.Token, gin.H{"title": "Calculus textbook", "description": "Barely used", "price": 60, "category": "Textbooks", "type": "selling"})
+	edit := gin.H{"title": "Desk lamp", "description": "Bright", "price": 12, "category": "Decor", "type": "selling"}
+	if w := env.do("PUT", "/posts/"+lamp.ID, admin.Token, edit); w.Code != http.StatusOK {
+		t.Fatalf("editing a post left in a deleted category: got %d %s", w.Code, w.Body.String())
+	}
+	edit["category"] = "Lamps"
+	if w := env.do("PUT", "/posts/"+lamp.ID, admin.Token, edit); w.Code != http.StatusBadRequest {
+		t.Fatalf("moving a post to an unknown category: got %d, want 400", w.Code)
+	}
+	edit["category"] = "Furniture"
+	if w := env.do("PUT", "/posts/"+lamp.ID, admin.Token, edit); w.Code != http.StatusOK {
+		t.Fatalf("moving a post to a known category: got %d %s", w.Code, w.Body.String())
+	}
+}
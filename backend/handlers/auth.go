@@ -1,39 +1,67 @@
 package handlers
 
 import (
+	"bruinmarket-backend/auth"
 	"bruinmarket-backend/models"
-	"bruinmarket-backend/services"
+	"bruinmarket-backend/store"
 	"crypto/rand"
-	"database/sql"
+	"crypto/sha256"
 	"encoding/hex"
+	"errors"
 	"log"
 	"net/http"
-	"strconv"
 	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 	"golang.org/x/crypto/bcrypt"
 )
 
 type Claims struct {
-	UserID string `json:"user_id"`
-	Email  string `json:"email"`
+	UserID       string `json:"user_id"`
+	Email        string `json:"email"`
+	Role         string `json:"role"`
+	SessionID    string `json:"session_id"`
+	TokenVersion int    `json:"token_version"`
 	jwt.RegisteredClaims
 }
 
+// Mailer sends the account emails. *services.EmailService satisfies it.
+type Mailer interface {
+	SendVerificationEmail(toEmail, toName, token string) error
+	SendWelcomeEmail(toEmail, toName string) error
+	SendPasswordResetEmail(toEmail, toName, token string) error
+}
+
+type AuthResponse struct {
+	Token        string      `json:"token"`
+	RefreshToken string      `json:"refresh_token"`
+	User         models.User `json:"user"`
+}
+
 type AuthHandler struct {
-	db           *sql.DB
-	emailService *services.EmailService
-	jwtSecret    []byte
+	users           store.UserStore
+	sessions        store.SessionStore
+	transactions    store.TransactionStore
+	mailer          Mailer
+	keys            *auth.KeyManager
+	accessTokenTTL  time.Duration
+	refreshTokenTTL time.Duration
 }
 
-func NewAuthHandler(db *sql.DB, emailService *services.EmailService, jwtSecret []byte) *AuthHandler {
+// NewAuthHandler wires the auth endpoints. mailer may be nil, in which case
+// emails are logged as unsent.
+func NewAuthHandler(users store.UserStore, sessions store.SessionStore, transactions store.TransactionStore, mailer Mailer, keys *auth.KeyManager, accessTokenTTL, refreshTokenTTL time.Duration) *AuthHandler {
 	return &AuthHandler{
-		db:           db,
-		emailService: emailService,
-		jwtSecret:    jwtSecret,
+		users:           users,
+		sessions:        sessions,
+		transactions:    transactions,
+		mailer:          mailer,
+		keys:            keys,
+		accessTokenTTL:  accessTokenTTL,
+		refreshTokenTTL: refreshTokenTTL,
 	}
 }
 
@@ -46,29 +74,189 @@ func generateToken() (string, error) {
 	return hex.EncodeToString(bytes), nil
 }
 
-// Generate JWT token
-func (h *AuthHandler) generateJWT(userID int, email string) (string, error) {
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, Claims{
-		UserID: strconv.Itoa(userID),
-		Email:  email,
+// hashToken returns the hex SHA-256 of a reset or refresh token. Only the hash
+// is stored so a leaked database can't be used to take over accounts.
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// describeDevice makes a short human readable label from a User-Agent header
+func describeDevice(userAgent string) string {
+	ua := strings.ToLower(userAgent)
+	switch {
+	case strings.Contains(ua, "iphone"):
+		return "iPhone"
+	case strings.Contains(ua, "ipad"):
+		return "iPad"
+	case strings.Contains(ua, "android"):
+		return "Android"
+	case strings.Contains(ua, "macintosh"), strings.Contains(ua, "mac os"):
+		return "Mac"
+	case strings.Contains(ua, "windows"):
+		return "Windows"
+	case strings.Contains(ua, "linux"):
+		return "Linux"
+	default:
+		return "Unknown device"
+	}
+}
+
+// Generate a short-lived access token for a session
+func (h *AuthHandler) generateJWT(user *models.User, sessionID string) (string, error) {
+	return h.keys.Sign(Claims{
+		UserID:       user.ID,
+		Email:        user.Email,
+		Role:         user.Role,
+		SessionID:    sessionID,
+		TokenVersion: user.TokenVersion,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(24 * time.Hour * 7)),
+			Issuer:    auth.Issuer,
+			Subject:   user.ID,
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(h.accessTokenTTL)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 		},
 	})
+}
 
-	tokenString, err := token.SignedString(h.jwtSecret)
+// startSession records a new login session and returns an access token plus
+// the refresh token the client uses to renew it.
+func (h *AuthHandler) startSession(c *gin.Context, user *models.User, device string) (string, string, error) {
+	refreshToken, err := generateToken()
 	if err != nil {
-		return "", err
+		return "", "", err
+	}
+
+	userAgent := c.GetHeader("User-Agent")
+	if device == "" {
+		device = describeDevice(userAgent)
+	}
+
+	now := time.Now()
+	session := &models.Session{
+		ID:               uuid.New().String(),
+		UserID:           user.ID,
+		RefreshTokenHash: hashToken(refreshToken),
+		Device:           device,
+		IPAddress:        c.ClientIP(),
+		UserAgent:        userAgent,
+		CreatedAt:        now,
+		LastSeenAt:       now,
+		ExpiresAt:        now.Add(h.refreshTokenTTL),
+	}
+	if err := h.sessions.CreateSession(session); err != nil {
+		return "", "", err
 	}
 
-	return tokenString, nil
+	accessToken, err := h.generateJWT(user, session.ID)
+	if err != nil {
+		return "", "", err
+	}
+
+	return accessToken, refreshToken, nil
+}
+
+// ParseToken verifies an access token and checks that its session is still
+// live and that it was issued after the user's most recent password or role
+// change.
+func (h *AuthHandler) ParseToken(tokenString string) (*Claims, error) {
+	claims := &Claims{}
+	token, err := jwt.ParseWithClaims(tokenString, claims, h.keys.Keyfunc, h.keys.ParserOptions()...)
+	if err != nil {
+		return nil, err
+	}
+	if !token.Valid {
+		return nil, errors.New("invalid token")
+	}
+
+	if claims.SessionID == "" {
+		return nil, errors.New("token has no session")
+	}
+
+	now := time.Now()
+	session, err := h.sessions.GetActiveSession(claims.SessionID, now)
+	if err != nil || session.UserID != claims.UserID {
+		return nil, errors.New("session has been revoked")
+	}
+
+	user, err := h.users.GetUserByID(claims.UserID)
+	if err != nil || user.TokenVersion != claims.TokenVersion || user.SuspendedAt != nil {
+		return nil, errors.New("token has been revoked")
+	}
+
+	if err := h.sessions.TouchSession(session.ID, now); err != nil {
+		log.Printf("Error updating session last seen: %v", err)
+	}
+
+	return claims, nil
+}
+
+// AuthMiddleware rejects requests without a valid access token for a live session
+func (h *AuthHandler) AuthMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		authHeader := c.GetHeader("Authorization")
+		if authHeader == "" {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "authorization header required"})
+			c.Abort()
+			return
+		}
+
+		tokenString := strings.TrimPrefix(authHeader, "Bearer ")
+		if tokenString == authHeader {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid authorization format"})
+			c.Abort()
+			return
+		}
+
+		claims, err := h.ParseToken(tokenString)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
+			c.Abort()
+			return
+		}
+
+		c.Set("user_id", claims.UserID)
+		c.Set("user_email", claims.Email)
+		c.Set("user_role", claims.Role)
+		c.Set("session_id", claims.SessionID)
+		c.Next()
+	}
+}
+
+// RequireRole only lets users with at least the given role through. It runs
+// after AuthMiddleware.
+func (h *AuthHandler) RequireRole(role string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !models.RoleAtLeast(c.GetString("user_role"), role) {
+			c.JSON(http.StatusForbidden, gin.H{"error": role + " access required"})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// OptionalAuthMiddleware identifies the caller like AuthMiddleware when a
+// valid token is sent, and otherwise lets the request through anonymously
+func (h *AuthHandler) OptionalAuthMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tokenString := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if tokenString != "" {
+			if claims, err := h.ParseToken(tokenString); err == nil {
+				c.Set("user_id", claims.UserID)
+				c.Set("user_email", claims.Email)
+				c.Set("user_role", claims.Role)
+				c.Set("session_id", claims.SessionID)
+			}
+		}
+		c.Next()
+	}
 }
 
 func (h *AuthHandler) Register(c *gin.Context) {
 	var input struct {
-		Email    string `json:"email" binding:"required,email"`
-		Password string `json:"password" binding:"required,min=6"`
+		Email    string `json:"email" binding:"required"`
+		Password string `json:"password" binding:"required"`
 		Name     string `json:"name" binding:"required"`
 		Year     string `json:"year" binding:"required"`
 	}
@@ -80,137 +268,261 @@ func (h *AuthHandler) Register(c *gin.Context) {
 
 	// Check @ucla.edu email
 	if !strings.HasSuffix(strings.ToLower(input.Email), "@ucla.edu") {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Must use a @ucla.edu email address"})
+		c.JSON(http.StatusBadRequest, gin.H{"error": "must use a @ucla.edu email address"})
 		return
 	}
 
 	// Check if email already exists
-	var exists bool
-	err := h.db.QueryRow("SELECT EXISTS(SELECT 1 FROM users WHERE email = $1)", input.Email).Scan(&exists)
-	if err != nil {
-		log.Printf("Database error checking email existence: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+	_, err := h.users.GetUserByEmail(input.Email)
+	if err == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "email already registered"})
 		return
 	}
-	if exists {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Email already registered"})
+	if err != store.ErrNotFound {
+		log.Printf("Database error checking email existence: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database error"})
 		return
 	}
 
 	// Hash password
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(input.Password), bcrypt.DefaultCost)
 	if err != nil {
-		log.Printf("Failed to hash password: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to hash password"})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to hash password"})
 		return
 	}
 
 	// Generate verification token
 	token, err := generateToken()
 	if err != nil {
-		log.Printf("Failed to generate token: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate verification token"})
 		return
 	}
 
 	// Token expires in 24 hours
 	expiresAt := time.Now().Add(24 * time.Hour)
 
-	// Create user with year field
-	var userID int
-	err = h.db.QueryRow(`
-		INSERT INTO users (email, password, name, year, email_verified, verification_token, verification_token_expires)
-		VALUES ($1, $2, $3, $4, FALSE, $5, $6)
-		RETURNING id
-	`, input.Email, string(hashedPassword), input.Name, input.Year, token, expiresAt).Scan(&userID)
-
-	if err != nil {
+	user := &models.User{
+		ID:                       uuid.New().String(),
+		Email:                    input.Email,
+		Name:                     input.Name,
+		Year:                     input.Year,
+		Password:                 string(hashedPassword),
+		VerificationToken:        &token,
+		VerificationTokenExpires: &expiresAt,
+		Role:                     models.RoleUser,
+		CreatedAt:                time.Now(),
+	}
+	if err := h.users.CreateUser(user); err != nil {
+		if err == store.ErrConflict {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "email already registered"})
+			return
+		}
 		log.Printf("Failed to create user: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create user"})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create user"})
 		return
 	}
 
-	// Send verification email
-	go func() {
-		err := h.emailService.SendVerificationEmail(input.Email, input.Name, token)
-		if err != nil {
-			log.Printf("Failed to send verification email: %v", err)
-		} else {
-			log.Printf("Verification email sent successfully to %s", input.Email)
-		}
-	}()
+	// Send verification email asynchronously
+	if h.mailer != nil {
+		go func() {
+			err := h.mailer.SendVerificationEmail(input.Email, input.Name, token)
+			if err != nil {
+				log.Printf("Failed to send verification email to %s: %v", input.Email, err)
+			} else {
+				log.Printf("Verification email sent successfully to %s", input.Email)
+			}
+		}()
+	} else {
+		log.Printf("ERROR: Email service not initialized. Verification email not sent for %s", input.Email)
+		log.Printf("Please check that SENDGRID_API_KEY and SENDGRID_FROM_EMAIL environment variables are set")
+	}
 
 	c.JSON(http.StatusCreated, gin.H{
-		"message": "Registration successful! Please check your email INBOX and SPAM folder to verify your account.",
-		"email":   input.Email,
+		"message": "Registration successful! Please check your email to verify your account. If email is not in your Inbox, PLEASE CHECK SPAM FOLDER.",
+	})
+}
+
+func (h *AuthHandler) Login(c *gin.Context) {
+	var input struct {
+		Email    string `json:"email" binding:"required"`
+		Password string `json:"password" binding:"required"`
+		Device   string `json:"device"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Get user
+	user, err := h.users.GetUserByEmail(input.Email)
+	if err == store.ErrNotFound {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid email or password"})
+		return
+	}
+	if err != nil {
+		log.Printf("Database error during login: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database error"})
+		return
+	}
+
+	// Check password
+	err = bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(input.Password))
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid email or password"})
+		return
+	}
+
+	// Check if email is verified
+	if !user.EmailVerified {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Please verify your email address before logging in. Check your inbox for the verification email."})
+		return
+	}
+
+	if user.SuspendedAt != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": "this account has been suspended"})
+		return
+	}
+
+	accessToken, refreshToken, err := h.startSession(c, user, input.Device)
+	if err != nil {
+		log.Printf("Failed to start session: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate token"})
+		return
+	}
+
+	c.JSON(http.StatusOK, AuthResponse{
+		Token:        accessToken,
+		RefreshToken: refreshToken,
+		User:         *user,
 	})
 }
 
 func (h *AuthHandler) VerifyEmail(c *gin.Context) {
 	token := c.Query("token")
 	if token == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Verification token required"})
+		c.JSON(http.StatusBadRequest, gin.H{"error": "verification token is required"})
 		return
 	}
 
 	// Find user with this token
-	var user models.User
-	err := h.db.QueryRow(`
-		SELECT id, email, name, verification_token_expires
-		FROM users
-		WHERE verification_token = $1 AND email_verified = FALSE
-	`, token).Scan(&user.ID, &user.Email, &user.Name, &user.VerificationTokenExpires)
-
-	if err == sql.ErrNoRows {
+	user, err := h.users.GetUserByVerificationToken(token)
+	if err == store.ErrNotFound {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid or expired verification token"})
 		return
 	}
 	if err != nil {
-		log.Printf("Database error during verification: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		log.Printf("Database error during email verification: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database error"})
 		return
 	}
 
 	// Check if token expired
-	if user.VerificationTokenExpires.Before(time.Now()) {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Verification token has expired"})
+	if user.VerificationTokenExpires == nil || time.Now().After(*user.VerificationTokenExpires) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Verification token has expired. Please request a new one."})
 		return
 	}
 
-	// Mark email as verified
-	_, err = h.db.Exec(`
-		UPDATE users
-		SET email_verified = TRUE, verification_token = NULL, verification_token_expires = NULL
-		WHERE id = $1
-	`, user.ID)
+	// Mark email as verified and clear token
+	if err := h.users.MarkEmailVerified(user.ID); err != nil {
+		log.Printf("Failed to update user verification status: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to verify email"})
+		return
+	}
+	user.EmailVerified = true
 
+	accessToken, refreshToken, err := h.startSession(c, user, "")
 	if err != nil {
-		log.Printf("Failed to update user verification status: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify email"})
+		log.Printf("Failed to generate JWT token: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate token"})
+		return
+	}
+
+	// Send welcome email asynchronously
+	if h.mailer != nil {
+		go func() {
+			err := h.mailer.SendWelcomeEmail(user.Email, user.Name)
+			if err != nil {
+				log.Printf("Failed to send welcome email to %s: %v", user.Email, err)
+			} else {
+				log.Printf("Welcome email sent successfully to %s", user.Email)
+			}
+		}()
+	} else {
+		log.Printf("Warning: Email service not initialized. Welcome email not sent for %s", user.Email)
+	}
+
+	c.JSON(http.StatusOK, AuthResponse{
+		Token:        accessToken,
+		RefreshToken: refreshToken,
+		User:         *user,
+	})
+}
+
+func (h *AuthHandler) ResendVerification(c *gin.Context) {
+	var input struct {
+		Email string `json:"email" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, err := h.users.GetUserByEmail(input.Email)
+
+	// Don't reveal if email exists or not for security
+	if err == store.ErrNotFound {
+		c.JSON(http.StatusOK, gin.H{"message": "If that email is registered, a verification email has been sent."})
 		return
 	}
+	if err != nil {
+		log.Printf("Database error during resend verification: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database error"})
+		return
+	}
+
+	// If already verified, don't resend
+	if user.EmailVerified {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Email is already verified. You can log in."})
+		return
+	}
+
+	// Generate new token
+	token, err := generateToken()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate verification token"})
+		return
+	}
+
+	expiresAt := time.Now().Add(24 * time.Hour)
 
-	log.Printf("Email verified successfully for user: %s", user.Email)
+	if err := h.users.SetVerificationToken(user.ID, token, expiresAt); err != nil {
+		log.Printf("Failed to update verification token: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update verification token"})
+		return
+	}
 
-	// Send welcome email
+	if h.mailer == nil {
+		log.Printf("ERROR: Email service not initialized. Cannot resend verification email to %s", user.Email)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "email service is not configured. Please contact support."})
+		return
+	}
 	go func() {
-		err := h.emailService.SendWelcomeEmail(user.Email, user.Name)
+		err := h.mailer.SendVerificationEmail(user.Email, user.Name, token)
 		if err != nil {
-			log.Printf("Failed to send welcome email: %v", err)
+			log.Printf("Failed to send verification email to %s: %v", user.Email, err)
 		} else {
-			log.Printf("Welcome email sent successfully to %s", user.Email)
+			log.Printf("Verification email resent successfully to %s", user.Email)
 		}
 	}()
 
-	c.JSON(http.StatusOK, gin.H{
-		"message": "Email verified successfully! You can now log in.",
-	})
+	c.JSON(http.StatusOK, gin.H{"message": "Verification email sent! Please check your inbox."})
 }
 
-func (h *AuthHandler) Login(c *gin.Context) {
+func (h *AuthHandler) ForgotPassword(c *gin.Context) {
 	var input struct {
-		Email    string `json:"email" binding:"required,email"`
-		Password string `json:"password" binding:"required"`
+		Email string `json:"email" binding:"required"`
 	}
 
 	if err := c.ShouldBindJSON(&input); err != nil {
@@ -218,59 +530,97 @@ func (h *AuthHandler) Login(c *gin.Context) {
 		return
 	}
 
-	// Get user
-	var user models.User
-	var hashedPassword string
-	err := h.db.QueryRow(`
-		SELECT id, email, password, name, profile_picture_url, email_verified
-		FROM users
-		WHERE email = $1
-	`, input.Email).Scan(&user.ID, &user.Email, &hashedPassword, &user.Name, &user.ProfilePictureURL, &user.EmailVerified)
+	// Checked before the lookup so the answer doesn't depend on whether the
+	// account exists
+	if h.mailer == nil {
+		log.Printf("ERROR: Email service not initialized. Cannot send password reset emails")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "email service is not configured. Please contact support."})
+		return
+	}
 
-	if err == sql.ErrNoRows {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid email or password"})
+	// Same response whether or not the account exists
+	genericResponse := gin.H{"message": "If that email is registered, a password reset link has been sent."}
+
+	user, err := h.users.GetUserByEmail(input.Email)
+	if err == store.ErrNotFound {
+		c.JSON(http.StatusOK, genericResponse)
 		return
 	}
 	if err != nil {
-		log.Printf("Database error during login: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		log.Printf("Database error during forgot password: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database error"})
 		return
 	}
 
-	// Check if email is verified
-	if !user.EmailVerified {
-		c.JSON(http.StatusForbidden, gin.H{
-			"error": "Please verify your email before logging in. Check your inbox for the verification link.",
-		})
+	resetToken, err := generateToken()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate reset token"})
 		return
 	}
 
-	// Check password
-	err = bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(input.Password))
+	// Issuing a new token replaces any outstanding one
+	expiresAt := time.Now().Add(1 * time.Hour)
+	if err := h.users.SetPasswordResetToken(user.ID, hashToken(resetToken), expiresAt); err != nil {
+		log.Printf("Failed to store password reset token: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create reset token"})
+		return
+	}
+
+	go func() {
+		err := h.mailer.SendPasswordResetEmail(user.Email, user.Name, resetToken)
+		if err != nil {
+			log.Printf("Failed to send password reset email to %s: %v", user.Email, err)
+		} else {
+			log.Printf("Password reset email sent successfully to %s", user.Email)
+		}
+	}()
+
+	c.JSON(http.StatusOK, genericResponse)
+}
+
+func (h *AuthHandler) ResetPassword(c *gin.Context) {
+	var input struct {
+		Token    string `json:"token" binding:"required"`
+		Password string `json:"password" binding:"required,min=6"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(input.Password), bcrypt.DefaultCost)
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid email or password"})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to hash password"})
 		return
 	}
 
-	// Generate JWT token
-	token, err := h.generateJWT(user.ID, user.Email)
+	// Redeeming the token bumps the token version, which revokes old JWTs
+	now := time.Now()
+	userID, err := h.users.ResetPassword(hashToken(input.Token), string(hashedPassword), now)
+	if err == store.ErrNotFound {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid or expired password reset token"})
+		return
+	}
 	if err != nil {
-		log.Printf("Failed to generate JWT: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
+		log.Printf("Failed to reset password: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to reset password"})
 		return
 	}
 
-	log.Printf("User logged in successfully: %s", user.Email)
+	// Sign out everywhere; the old password may have been compromised
+	if err := h.sessions.RevokeUserSessions(userID, now); err != nil {
+		log.Printf("Failed to revoke sessions after password reset: %v", err)
+	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"token": token,
-		"user":  user,
-	})
+	log.Printf("Password reset for user %s", userID)
+
+	c.JSON(http.StatusOK, gin.H{"message": "Password reset successfully. Please log in with your new password."})
 }
 
-func (h *AuthHandler) ResendVerification(c *gin.Context) {
+func (h *AuthHandler) Refresh(c *gin.Context) {
 	var input struct {
-		Email string `json:"email" binding:"required,email"`
+		RefreshToken string `json:"refresh_token" binding:"required"`
 	}
 
 	if err := c.ShouldBindJSON(&input); err != nil {
@@ -278,62 +628,165 @@ func (h *AuthHandler) ResendVerification(c *gin.Context) {
 		return
 	}
 
-	// Get user
-	var user models.User
-	err := h.db.QueryRow(`
-		SELECT id, email, name, email_verified
-		FROM users
-		WHERE email = $1
-	`, input.Email).Scan(&user.ID, &user.Email, &user.Name, &user.EmailVerified)
+	tokenHash := hashToken(input.RefreshToken)
+	now := time.Now()
 
-	if err == sql.ErrNoRows {
-		// Don't reveal if email exists
-		c.JSON(http.StatusOK, gin.H{"message": "If this email is registered, a verification email has been sent"})
+	session, err := h.sessions.GetSessionByRefreshToken(tokenHash)
+	if err == store.ErrNotFound {
+		// A rotated-out token being replayed means it leaked; kill the session
+		if sessionID, err := h.sessions.RevokeSessionByPreviousToken(tokenHash, now); err == nil {
+			log.Printf("Refresh token reuse detected, revoked session %s", sessionID)
+		}
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid refresh token"})
 		return
 	}
 	if err != nil {
-		log.Printf("Database error during resend: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		log.Printf("Database error during refresh: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database error"})
 		return
 	}
 
-	if user.EmailVerified {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Email already verified"})
+	if !session.Active(now) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "session has expired. Please log in again."})
 		return
 	}
 
-	// Generate new token
-	token, err := generateToken()
+	user, err := h.users.GetUserByID(session.UserID)
+	if err != nil || user.SuspendedAt != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid refresh token"})
+		return
+	}
+
+	newRefreshToken, err := generateToken()
 	if err != nil {
-		log.Printf("Failed to generate token: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate token"})
 		return
 	}
 
-	expiresAt := time.Now().Add(24 * time.Hour)
+	err = h.sessions.RotateRefreshToken(session.ID, tokenHash, hashToken(newRefreshToken), c.ClientIP(), c.GetHeader("User-Agent"), now)
+	if err == store.ErrNotFound {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid refresh token"})
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to rotate refresh token: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to refresh session"})
+		return
+	}
+
+	accessToken, err := h.generateJWT(user, session.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate token"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"token":         accessToken,
+		"refresh_token": newRefreshToken,
+	})
+}
+
+func (h *AuthHandler) Logout(c *gin.Context) {
+	err := h.sessions.RevokeSession(c.GetString("session_id"), c.GetString("user_id"), time.Now())
+	if err != nil && err != store.ErrNotFound {
+		log.Printf("Error revoking session: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to log out"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "logged out successfully"})
+}
 
-	// Update token
-	_, err = h.db.Exec(`
-		UPDATE users
-		SET verification_token = $1, verification_token_expires = $2
-		WHERE id = $3
-	`, token, expiresAt, user.ID)
+func (h *AuthHandler) ListSessions(c *gin.Context) {
+	currentSessionID := c.GetString("session_id")
 
+	sessions, err := h.sessions.ListActiveSessions(c.GetString("user_id"), time.Now())
 	if err != nil {
-		log.Printf("Failed to update token: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update token"})
+		log.Printf("Error fetching sessions: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch sessions"})
 		return
 	}
 
-	// Send email
-	go func() {
-		err := h.emailService.SendVerificationEmail(user.Email, user.Name, token)
-		if err != nil {
-			log.Printf("Failed to send verification email: %v", err)
+	for i := range sessions {
+		sessions[i].Current = sessions[i].ID == currentSessionID
+	}
+
+	c.JSON(http.StatusOK, sessions)
+}
+
+func (h *AuthHandler) RevokeSession(c *gin.Context) {
+	err := h.sessions.RevokeSession(c.Param("id"), c.GetString("user_id"), time.Now())
+	if err == store.ErrNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": "session not found"})
+		return
+	}
+	if err != nil {
+		log.Printf("Error revoking session: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to revoke session"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "session revoked successfully"})
+}
+
+// Me returns the current user along with their purchase and sale history
+func (h *AuthHandler) Me(c *gin.Context) {
+	user, err := h.users.GetUserByID(c.GetString("user_id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+		return
+	}
+
+	transactions, err := h.transactions.ListTransactionsForUser(user.ID)
+	if err != nil {
+		log.Printf("Error fetching transactions: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch purchase history"})
+		return
+	}
+	purchases := []models.Transaction{}
+	sales := []models.Transaction{}
+	for _, t := range transactions {
+		if t.BuyerID == user.ID {
+			purchases = append(purchases, t)
 		} else {
-			log.Printf("Resent verification email to %s", user.Email)
+			sales = append(sales, t)
 		}
-	}()
+	}
+
+	c.JSON(http.StatusOK, struct {
+		*models.User
+		Purchases []models.Transaction `json:"purchases"`
+		Sales     []models.Transaction `json:"sales"`
+	}{user, purchases, sales})
+}
+
+func (h *AuthHandler) UpdateYear(c *gin.Context) {
+	var input struct {
+		Year string `json:"year" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Validate year value
+	validYears := map[string]bool{
+		"Freshman":  true,
+		"Sophomore": true,
+		"Junior":    true,
+		"Senior":    true,
+		"Graduate":  true,
+	}
+	if !validYears[input.Year] {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid year value"})
+		return
+	}
+
+	if err := h.users.UpdateYear(c.GetString("user_id"), input.Year); err != nil {
+		log.Printf("Error updating user year: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update year"})
+		return
+	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Verification email sent!"})
+	c.JSON(http.StatusOK, gin.H{"message": "year updated successfully"})
 }
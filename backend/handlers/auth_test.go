@@ -0,0 +1,236 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRegisterRequiresUCLAEmail(t *testing.T) {
+	env := newTestEnv(t)
+
+	w := env.do("POST", "/auth/register", "", gin.H{"email": "joe@usc.edu", "password": "secret1", "name": "Joe", "year": "Junior"})
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("got %d, want 400", w.Code)
+	}
+}
+
+func TestRegisterRejectsDuplicateEmail(t *testing.T) {
+	env := newTestEnv(t)
+	env.signUp(t, "joe@ucla.edu", "Joe Bruin")
+
+	w := env.do("POST", "/auth/register", "", gin.H{"email": "joe@ucla.edu", "password": "secret1", "name": "Joe", "year": "Junior"})
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("got %d, want 400", w.Code)
+	}
+}
+
+func TestLoginRequiresVerifiedEmail(t *testing.T) {
+	env := newTestEnv(t)
+
+	env.do("POST", "/auth/register", "", gin.H{"email": "joe@ucla.edu", "password": "secret1", "name": "Joe", "year": "Junior"})
+
+	w := env.do("POST", "/auth/login", "", gin.H{"email": "joe@ucla.edu", "password": "secret1"})
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("got %d, want 403", w.Code)
+	}
+}
+
+func TestLoginAndMe(t *testing.T) {
+	env := newTestEnv(t)
+	env.signUp(t, "joe@ucla.edu", "Joe Bruin")
+
+	w := env.do("POST", "/auth/login", "", gin.H{"email": "joe@ucla.edu", "password": "wrong"})
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("wrong password: got %d, want 401", w.Code)
+	}
+
+	w = env.do("POST", "/auth/login", "", gin.H{"email": "joe@ucla.edu", "password": "secret1"})
+	if w.Code != http.StatusOK {
+		t.Fatalf("login: got %d %s", w.Code, w.Body.String())
+	}
+	var login AuthResponse
+	decode(t, w, &login)
+	if login.User.Year != "Junior" || login.User.ID == "" {
+		t.Fatalf("unexpected user %+v", login.User)
+	}
+
+	w = env.do("GET", "/auth/me", login.Token, nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("me: got %d %s", w.Code, w.Body.String())
+	}
+
+	w = env.do("GET", "/auth/me", "", nil)
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("me without token: got %d, want 401", w.Code)
+	}
+}
+
+func TestRefreshRotatesAndDetectsReuse(t *testing.T) {
+	env := newTestEnv(t)
+	first := env.signUp(t, "joe@ucla.edu", "Joe Bruin")
+
+	w := env.do("POST", "/auth/refresh", "", gin.H{"refresh_token": first.RefreshToken})
+	if w.Code != http.StatusOK {
+		t.Fatalf("refresh: got %d %s", w.Code, w.Body.String())
+	}
+	var second AuthResponse
+	decode(t, w, &second)
+	if second.RefreshToken == first.RefreshToken {
+		t.Fatal("refresh token was not rotated")
+	}
+
+	// Replaying the rotated-out token revokes the whole session
+	w = env.do("POST", "/auth/refresh", "", gin.H{"refresh_token": first.RefreshToken})
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("replay: got %d, want 401", w.Code)
+	}
+	w = env.do("POST", "/auth/refresh", "", gin.H{"refresh_token": second.RefreshToken})
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("refresh after reuse: got %d, want 401", w.Code)
+	}
+	w = env.do("GET", "/auth/me", second.Token, nil)
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("access token after reuse: got %d, want 401", w.Code)
+	}
+}
+
+func TestLogoutRevokesSession(t *testing.T) {
+	env := newTestEnv(t)
+	session := env.signUp(t, "joe@ucla.edu", "Joe Bruin")
+
+	w := env.do("POST", "/auth/logout", session.Token, nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("logout: got %d", w.Code)
+	}
+
+	if w := env.do("GET", "/auth/me", session.Token, nil); w.Code != http.StatusUnauthorized {
+		t.Fatalf("me after logout: got %d, want 401", w.Code)
+	}
+	if w := env.do("POST", "/auth/refresh", "", gin.H{"refresh_token": session.RefreshToken}); w.Code != http.StatusUnauthorized {
+		t.Fatalf("refresh after logout: got %d, want 401", w.Code)
+	}
+}
+
+func TestListAndRevokeSessions(t *testing.T) {
+	env := newTestEnv(t)
+	laptop := env.signUp(t, "joe@ucla.edu", "Joe Bruin")
+
+	w := env.do("POST", "/auth/login", "", gin.H{"email": "joe@ucla.edu", "password": "secret1", "device": "Phone"})
+	var phone AuthResponse
+	decode(t, w, &phone)
+
+	w = env.do("GET", "/auth/sessions", laptop.Token, nil)
+	var sessions []struct {
+		ID      string `json:"id"`
+		Device  string `json:"device"`
+		Current bool   `json:"current"`
+	}
+	decode(t, w, &sessions)
+	if len(sessions) != 2 {
+		t.Fatalf("got %d sessions, want 2", len(sessions))
+	}
+
+	var phoneSessionID string
+	for _, s := range sessions {
+		if s.Device == "Phone" {
+			phoneSessionID = s.ID
+			if s.Current {
+				t.Fatal("phone session reported as current for the laptop token")
+			}
+		}
+	}
+	if phoneSessionID == "" {
+		t.Fatal("phone session missing from list")
+	}
+
+	if w := env.do("DELETE", "/auth/sessions/"+phoneSessionID, laptop.Token, nil); w.Code != http.StatusOK {
+		t.Fatalf("revoke: got %d", w.Code)
+	}
+	if w := env.do("GET", "/auth/me", phone.Token, nil); w.Code != http.StatusUnauthorized {
+		t.Fatalf("revoked phone token: got %d, want 401", w.Code)
+	}
+	if w := env.do("GET", "/auth/me", laptop.Token, nil); w.Code != http.StatusOK {
+		t.Fatalf("laptop token: got %d, want 200", w.Code)
+	}
+}
+
+func TestPasswordReset(t *testing.T) {
+	env := newTestEnv(t)
+	session := env.signUp(t, "joe@ucla.edu", "Joe Bruin")
+
+	// Unknown emails get the same answer and no email
+	w := env.do("POST", "/auth/forgot-password", "", gin.H{"email": "nobody@ucla.edu"})
+	if w.Code != http.StatusOK {
+		t.Fatalf("forgot unknown: got %d", w.Code)
+	}
+
+	w = env.do("POST", "/auth/forgot-password", "", gin.H{"email": "joe@ucla.edu"})
+	if w.Code != http.StatusOK {
+		t.Fatalf("forgot: got %d", w.Code)
+	}
+	resetToken := receive(t, env.mailer.reset)
+
+	w = env.do("POST", "/auth/reset-password", "", gin.H{"token": resetToken, "password": "newsecret"})
+	if w.Code != http.StatusOK {
+		t.Fatalf("reset: got %d %s", w.Code, w.Body.String())
+	}
+
+	// Single use
+	w = env.do("POST", "/auth/reset-password", "", gin.H{"token": resetToken, "password": "another"})
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("reuse reset token: got %d, want 400", w.Code)
+	}
+
+	// Existing tokens no longer work
+	if w := env.do("GET", "/auth/me", session.Token, nil); w.Code != http.StatusUnauthorized {
+		t.Fatalf("old access token: got %d, want 401", w.Code)
+	}
+	if w := env.do("POST", "/auth/refresh", "", gin.H{"refresh_token": session.RefreshToken}); w.Code != http.StatusUnauthorized {
+		t.Fatalf("old refresh token: got %d, want 401", w.Code)
+	}
+
+	if w := env.do("POST", "/auth/login", "", gin.H{"email": "joe@ucla.edu", "password": "secret1"}); w.Code != http.StatusUnauthorized {
+		t.Fatalf("old password: got %d, want 401", w.Code)
+	}
+	if w := env.do("POST", "/auth/login", "", gin.H{"email": "joe@ucla.edu", "password": "newsecret"}); w.Code != http.StatusOK {
+		t.Fatalf("new password: got %d, want 200", w.Code)
+	}
+}
+
+func TestForgotPasswordWithoutMailerDoesNotRevealAccounts(t *testing.T) {
+	env := newTestEnv(t)
+	env.signUp(t, "joe@ucla.edu", "Joe Bruin")
+
+	noMailer := NewAuthHandler(env.store, env.store, env.store, nil, env.handlers.Auth.keys, time.Minute, time.Hour)
+	router := gin.New()
+	router.POST("/forgot-password", noMailer.ForgotPassword)
+	forgot := func(email string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("POST", "/forgot-password", strings.NewReader(`{"email": "`+email+`"}`)))
+		return w
+	}
+
+	known := forgot("joe@ucla.edu")
+	unknown := forgot("nobody@ucla.edu")
+	if known.Code != unknown.Code || known.Body.String() != unknown.Body.String() {
+		t.Fatalf("known email got %d %s, unknown got %d %s", known.Code, known.Body.String(), unknown.Code, unknown.Body.String())
+	}
+}
+
+func TestResetPasswordRejectsExpiredToken(t *testing.T) {
+	env := newTestEnv(t)
+	env.signUp(t, "joe@ucla.edu", "Joe Bruin")
+
+	user, _ := env.store.GetUserByEmail("joe@ucla.edu")
+	env.store.SetPasswordResetToken(user.ID, hashToken("stale"), time.Now().Add(-time.Minute))
+
+	w := env.do("POST", "/auth/reset-password", "", gin.H{"token": "stale", "password": "newsecret"})
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("got %d, want 400", w.Code)
+	}
+}
@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"bruinmarket-backend/store"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Longest category name accepted, matching posts.category
+const maxCategoryLength = 100
+
+type CategoryHandler struct {
+	categories store.CategoryStore
+}
+
+func NewCategoryHandler(categories store.CategoryStore) *CategoryHandler {
+	return &CategoryHandler{categories: categories}
+}
+
+// GetCategories lists the listing categories in picker order
+func (h *CategoryHandler) GetCategories(c *gin.Context) {
+	categories, err := h.categories.ListCategories()
+	if err != nil {
+		log.Printf("Error fetching categories: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch categories"})
+		return
+	}
+
+	c.JSON(http.StatusOK, categories)
+}
+
+// bindCategoryName reads {"name": ...}, writing a 400 if it is missing or too long
+func bindCategoryName(c *gin.Context) (string, bool) {
+	var input struct {
+		Name string `json:"name"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return "", false
+	}
+	name := strings.TrimSpace(input.Name)
+	if name == "" || len(name) > maxCategoryLength {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "name must be between 1 and 100 characters"})
+		return "", false
+	}
+	return name, true
+}
+
+// CreateCategory adds {"name": ...} to the end of the category list
+func (h *CategoryHandler) CreateCategory(c *gin.Context) {
+	name, ok := bindCategoryName(c)
+	if !ok {
+		return
+	}
+
+	category, err := h.categories.CreateCategory(name)
+	if err == store.ErrConflict {
+		c.JSON(http.StatusConflict, gin.H{"error": "category already exists"})
+		return
+	}
+	if err != nil {
+		log.Printf("Error creating category: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create category"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, category)
+}
+
+// RenameCategory renames the :name category to {"name": ...}, moving its
+// posts and saved searches along with it
+func (h *CategoryHandler) RenameCategory(c *gin.Context) {
+	name, ok := bindCategoryName(c)
+	if !ok {
+		return
+	}
+
+	err := h.categories.RenameCategory(c.Param("name"), name)
+	if err == store.ErrNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": "category not found"})
+		return
+	}
+	if err == store.ErrConflict {
+		c.JSON(http.StatusConflict, gin.H{"error": "category already exists"})
+		return
+	}
+	if err != nil {
+		log.Printf("Error renaming category: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to rename category"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "category renamed", "name": name})
+}
+
+// DeleteCategory takes a category out of the picker. Existing posts keep it.
+func (h *CategoryHandler) DeleteCategory(c *gin.Context) {
+	err := h.categories.DeleteCategory(c.Param("name"))
+	if err == store.ErrNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": "category not found"})
+		return
+	}
+	if err != nil {
+		log.Printf("Error deleting category: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete category"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "category deleted"})
+}
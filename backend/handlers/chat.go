@@ -0,0 +1,201 @@
+package handlers
+
+import (
+	"bruinmarket-backend/chat"
+	"bruinmarket-backend/models"
+	"bruinmarket-backend/store"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+)
+
+// WebSocket upgrader
+var upgrader = websocket.Upgrader{
+	CheckOrigin: func(r *http.Request) bool {
+		return true
+	},
+}
+
+type ChatHandler struct {
+	conversations store.ConversationStore
+	messages      store.MessageStore
+	hub           *chat.Hub
+	auth          *AuthHandler
+}
+
+func NewChatHandler(conversations store.ConversationStore, messages store.MessageStore, hub *chat.Hub, auth *AuthHandler) *ChatHandler {
+	return &ChatHandler{
+		conversations: conversations,
+		messages:      messages,
+		hub:           hub,
+		auth:          auth,
+	}
+}
+
+// WebSocket handler
+func (h *ChatHandler) WebSocket(c *gin.Context) {
+	// Get token from query parameter
+	tokenString := c.Query("token")
+	if tokenString == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "token required"})
+		return
+	}
+
+	// Verify token and that its session hasn't been revoked
+	claims, err := h.auth.ParseToken(tokenString)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
+		return
+	}
+
+	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		log.Printf("WebSocket upgrade error: %v", err)
+		return
+	}
+
+	h.hub.ServeClient(conn, claims.UserID)
+}
+
+func (h *ChatHandler) GetOrCreateConversation(c *gin.Context) {
+	userID := c.GetString("user_id")
+	otherUserID := c.Param("user_id")
+
+	if userID == otherUserID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "cannot create conversation with yourself"})
+		return
+	}
+
+	conversation, err := findOrCreateConversation(h.conversations, userID, otherUserID)
+	if err != nil {
+		log.Printf("Error fetching conversation: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch conversation"})
+		return
+	}
+
+	h.withPresence(conversation)
+	c.JSON(http.StatusOK, conversation)
+}
+
+// findOrCreateConversation returns the conversation between two users,
+// starting one if they haven't talked yet
+func findOrCreateConversation(conversations store.ConversationStore, userA, userB string) (*models.Conversation, error) {
+	// Ensure consistent ordering
+	user1ID, user2ID := userA, userB
+	if userA > userB {
+		user1ID, user2ID = userB, userA
+	}
+
+	conversation, err := conversations.FindConversation(user1ID, user2ID)
+	if err != store.ErrNotFound {
+		return conversation, err
+	}
+
+	err = conversations.CreateConversation(&models.Conversation{
+		ID:        uuid.New().String(),
+		User1ID:   user1ID,
+		User2ID:   user2ID,
+		CreatedAt: time.Now(),
+	})
+	if err != nil && err != store.ErrConflict {
+		return nil, err
+	}
+
+	// Fetch the new conversation (or the one a concurrent request created)
+	return conversations.FindConversation(user1ID, user2ID)
+}
+
+func (h *ChatHandler) GetConversations(c *gin.Context) {
+	conversations, err := h.conversations.ListConversations(c.GetString("user_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch conversations"})
+		return
+	}
+
+	refs := make([]*models.Conversation, len(conversations))
+	for i := range conversations {
+		refs[i] = &conversations[i]
+	}
+	h.withPresence(refs...)
+	c.JSON(http.StatusOK, conversations)
+}
+
+// withPresence fills in both members' presence. Conversations are still
+// worth returning without it, so errors are only logged.
+func (h *ChatHandler) withPresence(conversations ...*models.Conversation) {
+	userIDs := []string{}
+	for _, conv := range conversations {
+		userIDs = append(userIDs, conv.User1ID, conv.User2ID)
+	}
+	if len(userIDs) == 0 {
+		return
+	}
+	presence, err := h.hub.Presence(userIDs...)
+	if err != nil {
+		log.Printf("Error fetching presence: %v", err)
+		return
+	}
+	for _, conv := range conversations {
+		if p, ok := presence[conv.User1ID]; ok {
+			conv.User1Presence = &p
+		}
+		if p, ok := presence[conv.User2ID]; ok {
+			conv.User2Presence = &p
+		}
+	}
+}
+
+// GetUserPresence reports whether a user is online, away or offline, and
+// when they were last seen
+func (h *ChatHandler) GetUserPresence(c *gin.Context) {
+	userID := c.Param("user_id")
+	presence, err := h.hub.Presence(userID)
+	if err != nil {
+		log.Printf("Error fetching presence: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch presence"})
+		return
+	}
+	p, ok := presence[userID]
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, p)
+}
+
+func (h *ChatHandler) GetMessages(c *gin.Context) {
+	userID := c.GetString("user_id")
+	conversationID := c.Param("conversation_id")
+
+	// Verify user is part of conversation
+	conversation, err := h.conversations.GetConversation(conversationID)
+	if err != nil || !conversation.HasMember(userID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "access denied"})
+		return
+	}
+
+	messages, err := h.messages.ListMessages(conversationID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch messages"})
+		return
+	}
+
+	// Withhold messages hidden after reports from everyone but their sender
+	for i := range messages {
+		if messages[i].Hidden && messages[i].SenderID != userID {
+			messages[i].Content = ""
+		}
+	}
+
+	// Mark messages as read
+	if err := h.messages.MarkMessagesRead(conversationID, userID); err != nil {
+		log.Printf("Error marking messages as read: %v", err)
+	}
+
+	c.JSON(http.StatusOK, messages)
+}
@@ -0,0 +1,505 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"bruinmarket-backend/chat"
+	"bruinmarket-backend/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+)
+
+// dialWS opens a chat WebSocket as the token's user against a live server
+// for the test router
+func (env *testEnv) dialWS(t *testing.T, token string) *websocket.Conn {
+	t.Helper()
+	if env.server == nil {
+		env.server = httptest.NewServer(env.router)
+		t.Cleanup(env.server.Close)
+	}
+	return dialWSServer(t, env.server, token)
+}
+
+// dialWSServer opens a chat WebSocket to server as the token's user
+func dialWSServer(t *testing.T, server *httptest.Server, token string) *websocket.Conn {
+	t.Helper()
+	url := "ws" + strings.TrimPrefix(server.URL, "http") + "/api/ws?token=" + token
+	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		t.Fatalf("dial websocket: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+// useChatConfig swaps in a hub running with config for the chat WebSocket
+func (env *testEnv) useChatConfig(config chat.Config) *chat.Hub {
+	hub := chat.NewHub(env.store, env.store, env.store, chat.NewMemoryBroker(), config)
+	go hub.Run()
+	env.handlers.Chat.hub = hub
+	return hub
+}
+
+// waitForConnections waits until the hub has n connections open for userID
+func (env *testEnv) waitForConnections(t *testing.T, userID string, n int) {
+	t.Helper()
+	waitForHubConnections(t, env.handlers.Chat.hub, userID, n)
+}
+
+// waitForHubConnections waits until hub has n connections open for userID
+func waitForHubConnections(t *testing.T, hub *chat.Hub, userID string, n int) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for hub.Connections(userID) != n {
+		if time.Now().After(deadline) {
+			t.Fatalf("user has %d connections, want %d", hub.Connections(userID), n)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+// readFrame reads the next frame from conn into v
+func readFrame(t *testing.T, conn *websocket.Conn, v interface{}) {
+	t.Helper()
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	_, data, err := conn.ReadMessage()
+	if err != nil {
+		t.Fatalf("read frame: %v", err)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		t.Fatalf("decode frame %s: %v", data, err)
+	}
+}
+
+func TestConversationAccess(t *testing.T) {
+	env := newTestEnv(t)
+	joe := env.signUp(t, "joe@ucla.edu", "Joe Bruin")
+	josie := env.signUp(t, "josie@ucla.edu", "Josie Bruin")
+	eve := env.signUp(t, "eve@ucla.edu", "Eve Trojan")
+
+	w := env.do("GET", "/conversations/"+josie.User.ID, joe.Token, nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("create conversation: got %d %s", w.Code, w.Body.String())
+	}
+	var conversation models.Conversation
+	decode(t, w, &conversation)
+
+	// Asking again from the other side returns the same conversation
+	w = env.do("GET", "/conversations/"+joe.User.ID, josie.Token, nil)
+	var again models.Conversation
+	decode(t, w, &again)
+	if again.ID != conversation.ID {
+		t.Fatalf("got conversation %s, want %s", again.ID, conversation.ID)
+	}
+
+	if w := env.do("GET", "/messages/"+conversation.ID, josie.Token, nil); w.Code != http.StatusOK {
+		t.Fatalf("member messages: got %d", w.Code)
+	}
+	if w := env.do("GET", "/messages/"+conversation.ID, eve.Token, nil); w.Code != http.StatusForbidden {
+		t.Fatalf("non-member messages: got %d, want 403", w.Code)
+	}
+	if w := env.do("GET", "/conversations/"+joe.User.ID, joe.Token, nil); w.Code != http.StatusBadRequest {
+		t.Fatalf("conversation with self: got %d, want 400", w.Code)
+	}
+}
+
+func TestWebSocketSenderComesFromConnection(t *testing.T) {
+	env := newTestEnv(t)
+	joe := env.signUp(t, "joe@ucla.edu", "Joe Bruin")
+	josie := env.signUp(t, "josie@ucla.edu", "Josie Bruin")
+	eve := env.signUp(t, "eve@ucla.edu", "Eve Trojan")
+
+	var conversation models.Conversation
+	decode(t, env.do("GET", "/conversations/"+josie.User.ID, joe.Token, nil), &conversation)
+
+	joeConn := env.dialWS(t, joe.Token)
+	josieConn := env.dialWS(t, josie.Token)
+	eveConn := env.dialWS(t, eve.Token)
+	env.waitForConnections(t, josie.User.ID, 1)
+
+	// Eve claims to be Josie writing to Joe in a conversation she isn't in
+	eveConn.WriteJSON(map[string]string{
+		"type":            "message",
+		"conversation_id": conversation.ID,
+		"sender_id":       josie.User.ID,
+		"receiver_id":     joe.User.ID,
+		"content":         "send me your password",
+		"client_id":       "c1",
+	})
+	var rejected chat.ErrorFrame
+	readFrame(t, eveConn, &rejected)
+	if rejected.Type != "error" || rejected.Code != chat.ErrNotMember || rejected.ClientID != "c1" {
+		t.Fatalf("spoofed message: got %+v, want not_member error", rejected)
+	}
+
+	// Joe's own message keeps him as the sender whatever the frame says
+	joeConn.WriteJSON(map[string]string{
+		"type":            "message",
+		"conversation_id": conversation.ID,
+		"sender_id":       josie.User.ID,
+		"receiver_id":     eve.User.ID,
+		"content":         "still selling the desk?",
+	})
+	var received chat.WSMessage
+	readFrame(t, josieConn, &received)
+	if received.SenderID != joe.User.ID || received.ReceiverID != josie.User.ID || received.Content != "still selling the desk?" {
+		t.Fatalf("delivered message: got %+v", received)
+	}
+	var confirmed chat.WSMessage
+	readFrame(t, joeConn, &confirmed)
+	if confirmed.MessageID != received.MessageID {
+		t.Fatalf("confirmation: got message %s, want %s", confirmed.MessageID, received.MessageID)
+	}
+
+	var messages []models.Message
+	decode(t, env.do("GET", "/messages/"+conversation.ID, joe.Token, nil), &messages)
+	if len(messages) != 1 || messages[0].SenderID != joe.User.ID {
+		t.Fatalf("stored messages: got %+v, want just Joe's", messages)
+	}
+
+	// Malformed frames, unknown types and empty messages are rejected
+	joeConn.WriteMessage(websocket.TextMessage, []byte("not json"))
+	var frame chat.ErrorFrame
+	readFrame(t, joeConn, &frame)
+	if frame.Code != chat.ErrInvalidFrame {
+		t.Fatalf("bad JSON: got %+v", frame)
+	}
+	joeConn.WriteJSON(map[string]string{"type": "shout"})
+	readFrame(t, joeConn, &frame)
+	if frame.Code != chat.ErrUnknownType {
+		t.Fatalf("unknown type: got %+v", frame)
+	}
+	joeConn.WriteJSON(map[string]string{"type": "message", "conversation_id": conversation.ID, "content": "  "})
+	readFrame(t, joeConn, &frame)
+	if frame.Code != chat.ErrInvalidMessage {
+		t.Fatalf("empty message: got %+v", frame)
+	}
+}
+
+func TestWebSocketMultipleDevices(t *testing.T) {
+	env := newTestEnv(t)
+	joe := env.signUp(t, "joe@ucla.edu", "Joe Bruin")
+	josie := env.signUp(t, "josie@ucla.edu", "Josie Bruin")
+
+	var conversation models.Conversation
+	decode(t, env.do("GET", "/conversations/"+josie.User.ID, joe.Token, nil), &conversation)
+
+	laptop := env.dialWS(t, josie.Token)
+	phone := env.dialWS(t, josie.Token)
+	joeConn := env.dialWS(t, joe.Token)
+	env.waitForConnections(t, josie.User.ID, 2)
+	env.waitForConnections(t, joe.User.ID, 1)
+
+	send := func(content string) {
+		joeConn.WriteJSON(map[string]string{"type": "message", "conversation_id": conversation.ID, "content": content})
+		var confirmed chat.WSMessage
+		readFrame(t, joeConn, &confirmed)
+	}
+
+	// Both of Josie's devices get the message
+	send("is the desk still available?")
+	for name, conn := range map[string]*websocket.Conn{"laptop": laptop, "phone": phone} {
+		var msg chat.WSMessage
+		readFrame(t, conn, &msg)
+		if msg.Content != "is the desk still available?" {
+			t.Fatalf("%s: got %+v", name, msg)
+		}
+	}
+
+	// Closing the laptop leaves the phone connected
+	laptop.Close()
+	env.waitForConnections(t, josie.User.ID, 1)
+	send("I can pick it up today")
+	var msg chat.WSMessage
+	readFrame(t, phone, &msg)
+	if msg.Content != "I can pick it up today" {
+		t.Fatalf("phone after laptop closed: got %+v", msg)
+	}
+
+	// Josie's reply from her phone is echoed to her other connections too
+	tablet := env.dialWS(t, josie.Token)
+	env.waitForConnections(t, josie.User.ID, 2)
+	phone.WriteJSON(map[string]string{"type": "message", "conversation_id": conversation.ID, "content": "yes, come by at 5"})
+	for name, conn := range map[string]*websocket.Conn{"joe": joeConn, "phone": phone, "tablet": tablet} {
+		var msg chat.WSMessage
+		readFrame(t, conn, &msg)
+		if msg.SenderID != josie.User.ID || msg.Content != "yes, come by at 5" {
+			t.Fatalf("%s: got %+v", name, msg)
+		}
+	}
+
+	phone.Close()
+	tablet.Close()
+	env.waitForConnections(t, josie.User.ID, 0)
+}
+
+func TestWebSocketKeepalive(t *testing.T) {
+	env := newTestEnv(t)
+	env.useChatConfig(chat.Config{
+		PingInterval:   20 * time.Millisecond,
+		PongTimeout:    100 * time.Millisecond,
+		WriteTimeout:   100 * time.Millisecond,
+		MaxMessageSize: 512,
+		SendBuffer:     16,
+	})
+	joe := env.signUp(t, "joe@ucla.edu", "Joe Bruin")
+	josie := env.signUp(t, "josie@ucla.edu", "Josie Bruin")
+	eve := env.signUp(t, "eve@ucla.edu", "Eve Trojan")
+
+	// Reading answers the server's pings, so Joe stays connected well past
+	// the pong timeout
+	joeConn := env.dialWS(t, joe.Token)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for {
+			if _, _, err := joeConn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+	env.waitForConnections(t, joe.User.ID, 1)
+	time.Sleep(300 * time.Millisecond)
+	if n := env.handlers.Chat.hub.Connections(joe.User.ID); n != 1 {
+		t.Fatalf("responsive client: got %d connections, want 1", n)
+	}
+
+	// Josie's client vanishes without closing: it never reads, so never
+	// answers a ping, and is dropped
+	env.dialWS(t, josie.Token)
+	env.waitForConnections(t, josie.User.ID, 1)
+	env.waitForConnections(t, josie.User.ID, 0)
+
+	// Frames over the size limit close the connection
+	eveConn := env.dialWS(t, eve.Token)
+	env.waitForConnections(t, eve.User.ID, 1)
+	eveConn.WriteJSON(map[string]string{"type": "message", "content": strings.Repeat("x", 1024)})
+	eveConn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	_, _, err := eveConn.ReadMessage()
+	if !websocket.IsCloseError(err, websocket.CloseMessageTooBig) {
+		t.Fatalf("oversized frame: got %v, want close 1009", err)
+	}
+	env.waitForConnections(t, eve.User.ID, 0)
+
+	joeConn.Close()
+	<-done
+	env.waitForConnections(t, joe.User.ID, 0)
+}
+
+func TestWebSocketSlowClient(t *testing.T) {
+	env := newTestEnv(t)
+	hub := env.useChatConfig(chat.Config{
+		PingInterval:   time.Second,
+		PongTimeout:    5 * time.Second,
+		WriteTimeout:   100 * time.Millisecond,
+		MaxMessageSize: 512,
+		SendBuffer:     4,
+	})
+	joe := env.signUp(t, "joe@ucla.edu", "Joe Bruin")
+	josie := env.signUp(t, "josie@ucla.edu", "Josie Bruin")
+
+	// Josie's connection stays open but never reads anything
+	env.dialWS(t, josie.Token)
+	joeConn := env.dialWS(t, joe.Token)
+	env.waitForConnections(t, josie.User.ID, 1)
+	env.waitForConnections(t, joe.User.ID, 1)
+
+	// Pushing to her never blocks; once she falls behind she is cut off
+	payload := []byte(`"` + strings.Repeat("x", 64*1024) + `"`)
+	start := time.Now()
+	for i := 0; i < 200; i++ {
+		hub.SendToUser(josie.User.ID, payload)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("pushing to a slow client took %s", elapsed)
+	}
+	env.waitForConnections(t, josie.User.ID, 0)
+
+	// Pushes to a user with nothing connected are a no-op, and other
+	// users are unaffected
+	hub.SendToUser(josie.User.ID, payload)
+	hub.SendToUser(joe.User.ID, []byte(`{"type":"ping_test"}`))
+	var frame map[string]string
+	readFrame(t, joeConn, &frame)
+	if frame["type"] != "ping_test" {
+		t.Fatalf("healthy client: got %v", frame)
+	}
+}
+
+func TestWebSocketAcrossInstances(t *testing.T) {
+	env := newTestEnv(t)
+	joe := env.signUp(t, "joe@ucla.edu", "Joe Bruin")
+	josie := env.signUp(t, "josie@ucla.edu", "Josie Bruin")
+
+	var conversation models.Conversation
+	decode(t, env.do("GET", "/conversations/"+josie.User.ID, joe.Token, nil), &conversation)
+
+	// Two instances over the same store, relaying pushes through one broker
+	broker := chat.NewMemoryBroker()
+	hubA := chat.NewHub(env.store, env.store, env.store, broker, chat.DefaultConfig())
+	hubB := chat.NewHub(env.store, env.store, env.store, broker, chat.DefaultConfig())
+	go hubA.Run()
+	go hubB.Run()
+	env.handlers.Chat.hub = hubA
+	handlersB := *env.handlers
+	handlersB.Chat = NewChatHandler(env.store, env.store, hubB, env.handlers.Auth)
+	routerB := gin.New()
+	RegisterRoutes(routerB, &handlersB)
+	serverB := httptest.NewServer(routerB)
+	defer serverB.Close()
+
+	joeConn := env.dialWS(t, joe.Token)
+	laptop := env.dialWS(t, josie.Token)
+	phone := dialWSServer(t, serverB, josie.Token)
+	waitForHubConnections(t, hubA, joe.User.ID, 1)
+	waitForHubConnections(t, hubA, josie.User.ID, 1)
+	waitForHubConnections(t, hubB, josie.User.ID, 1)
+
+	// Joe's message, saved on instance A, reaches Josie on both instances
+	joeConn.WriteJSON(map[string]string{"type": "message", "conversation_id": conversation.ID, "content": "meet at Ackerman?"})
+	for name, conn := range map[string]*websocket.Conn{"joe": joeConn, "laptop": laptop, "phone": phone} {
+		var msg chat.WSMessage
+		readFrame(t, conn, &msg)
+		if msg.SenderID != joe.User.ID || msg.Content != "meet at Ackerman?" {
+			t.Fatalf("%s: got %+v", name, msg)
+		}
+	}
+
+	// And her reply from instance B gets back to Joe on A
+	phone.WriteJSON(map[string]string{"type": "message", "conversation_id": conversation.ID, "content": "sure"})
+	var reply chat.WSMessage
+	readFrame(t, joeConn, &reply)
+	if reply.SenderID != josie.User.ID || reply.Content != "sure" {
+		t.Fatalf("reply across instances: got %+v", reply)
+	}
+}
+
+func TestTypingAndPresence(t *testing.T) {
+	env := newTestEnv(t)
+	env.useChatConfig(chat.Config{
+		PingInterval:   time.Second,
+		PongTimeout:    5 * time.Second,
+		WriteTimeout:   time.Second,
+		MaxMessageSize: 512,
+		SendBuffer:     16,
+		AwayAfter:      200 * time.Millisecond,
+	})
+	joe := env.signUp(t, "joe@ucla.edu", "Joe Bruin")
+	josie := env.signUp(t, "josie@ucla.edu", "Josie Bruin")
+	eve := env.signUp(t, "eve@ucla.edu", "Eve Trojan")
+
+	var conversation models.Conversation
+	decode(t, env.do("GET", "/conversations/"+josie.User.ID, joe.Token, nil), &conversation)
+
+	presenceOf := func(userID string) models.Presence {
+		t.Helper()
+		w := env.do("GET", "/users/"+userID+"/presence", joe.Token, nil)
+		if w.Code != http.StatusOK {
+			t.Fatalf("presence: got %d %s", w.Code, w.Body.String())
+		}
+		var p models.Presence
+		decode(t, w, &p)
+		return p
+	}
+
+	if p := presenceOf(josie.User.ID); p.Status != models.PresenceOffline || p.LastSeenAt != nil {
+		t.Fatalf("before connecting: got %+v, want offline and never seen", p)
+	}
+	if w := env.do("GET", "/users/nobody/presence", joe.Token, nil); w.Code != http.StatusNotFound {
+		t.Fatalf("unknown user presence: got %d, want 404", w.Code)
+	}
+
+	joeConn := env.dialWS(t, joe.Token)
+	josieConn := env.dialWS(t, josie.Token)
+	eveConn := env.dialWS(t, eve.Token)
+	env.waitForConnections(t, josie.User.ID, 1)
+	env.waitForConnections(t, joe.User.ID, 1)
+	if p := presenceOf(josie.User.ID); p.Status != models.PresenceOnline {
+		t.Fatalf("connected: got %+v, want online", p)
+	}
+
+	// Typing goes to the other member only
+	joeConn.WriteJSON(map[string]string{"type": "typing_start", "conversation_id": conversation.ID})
+	var typing chat.TypingFrame
+	readFrame(t, josieConn, &typing)
+	if typing.Type != "typing_start" || typing.ConversationID != conversation.ID || typing.UserID != joe.User.ID {
+		t.Fatalf("typing_start: got %+v", typing)
+	}
+	eveConn.WriteJSON(map[string]string{"type": "typing_start", "conversation_id": conversation.ID})
+	var rejected chat.ErrorFrame
+	readFrame(t, eveConn, &rejected)
+	if rejected.Code != chat.ErrNotMember {
+		t.Fatalf("outsider typing: got %+v", rejected)
+	}
+
+	// Conversations carry both members' presence
+	var conversations []models.Conversation
+	decode(t, env.do("GET", "/conversations", joe.Token, nil), &conversations)
+	if len(conversations) != 1 || conversations[0].User1Presence == nil || conversations[0].User2Presence == nil {
+		t.Fatalf("conversations: got %+v, want presence for both members", conversations)
+	}
+	if conversations[0].User1Presence.Status != models.PresenceOnline || conversations[0].User2Presence.Status != models.PresenceOnline {
+		t.Fatalf("conversation presence: got %+v and %+v", conversations[0].User1Presence, conversations[0].User2Presence)
+	}
+
+	// Josie has been quiet since connecting, so she shows as away until she
+	// does something
+	time.Sleep(300 * time.Millisecond)
+	if p := presenceOf(josie.User.ID); p.Status != models.PresenceAway {
+		t.Fatalf("idle: got %+v, want away", p)
+	}
+	josieConn.WriteJSON(map[string]string{"type": "typing_stop", "conversation_id": conversation.ID})
+	readFrame(t, joeConn, &typing)
+	if typing.Type != "typing_stop" || typing.UserID != josie.User.ID {
+		t.Fatalf("typing_stop: got %+v", typing)
+	}
+	if p := presenceOf(josie.User.ID); p.Status != models.PresenceOnline {
+		t.Fatalf("after typing: got %+v, want online", p)
+	}
+
+	// Once she disconnects she is offline, with when she was last seen
+	josieConn.Close()
+	deadline := time.Now().Add(2 * time.Second)
+	p := presenceOf(josie.User.ID)
+	for p.Status != models.PresenceOffline && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+		p = presenceOf(josie.User.ID)
+	}
+	if p.Status != models.PresenceOffline || p.LastSeenAt == nil {
+		t.Fatalf("disconnected: got %+v, want offline with last_seen_at", p)
+	}
+}
+
+func TestSuspendedUserIsDisconnected(t *testing.T) {
+	env := newTestEnv(t)
+	admin := env.grantRole(t, env.signUp(t, "admin@ucla.edu", "Ada Admin"), models.RoleAdmin)
+	joe := env.signUp(t, "joe@ucla.edu", "Joe Bruin")
+	eve := env.signUp(t, "eve@ucla.edu", "Eve Trojan")
+
+	eveConn := env.dialWS(t, eve.Token)
+	joeConn := env.dialWS(t, joe.Token)
+	env.waitForConnections(t, eve.User.ID, 1)
+	env.waitForConnections(t, joe.User.ID, 1)
+
+	if w := env.do("POST", "/admin/users/"+eve.User.ID+"/suspend", admin.Token, nil); w.Code != http.StatusOK {
+		t.Fatalf("suspend: got %d %s", w.Code, w.Body.String())
+	}
+
+	// Eve's open socket is closed, so she can't keep chatting
+	eveConn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	_, _, err := eveConn.ReadMessage()
+	if !websocket.IsCloseError(err, websocket.ClosePolicyViolation) {
+		t.Fatalf("suspended user's socket: got %v, want close 1008", err)
+	}
+	env.waitForConnections(t, eve.User.ID, 0)
+	if n := env.handlers.Chat.hub.Connections(joe.User.ID); n != 1 {
+		t.Fatalf("other user: got %d connections, want 1", n)
+	}
+	joeConn.Close()
+}
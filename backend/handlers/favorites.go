@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"bruinmarket-backend/models"
+	"bruinmarket-backend/store"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type FavoriteHandler struct {
+	favorites store.FavoriteStore
+	posts     store.PostStore
+	media     store.MediaStore
+}
+
+func NewFavoriteHandler(favorites store.FavoriteStore, posts store.PostStore, media store.MediaStore) *FavoriteHandler {
+	return &FavoriteHandler{
+		favorites: favorites,
+		posts:     posts,
+		media:     media,
+	}
+}
+
+func (h *FavoriteHandler) GetFavorites(c *gin.Context) {
+	posts, err := h.favorites.ListFavoritePosts(c.GetString("user_id"))
+	if err != nil {
+		log.Printf("Error fetching favorites: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch favorites"})
+		return
+	}
+	loadMedia(h.media, posts)
+
+	c.JSON(http.StatusOK, gin.H{"posts": posts})
+}
+
+func (h *FavoriteHandler) AddFavorite(c *gin.Context) {
+	postID := c.Param("post_id")
+	if post, err := h.posts.GetPost(postID); err == store.ErrNotFound ||
+		(err == nil && (post.State == models.PostDraft || post.State == models.PostRemoved)) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "post not found"})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database error"})
+		return
+	}
+
+	if err := h.favorites.AddFavorite(c.GetString("user_id"), postID, time.Now()); err != nil {
+		log.Printf("Error adding favorite: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to add favorite"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "added to favorites"})
+}
+
+func (h *FavoriteHandler) RemoveFavorite(c *gin.Context) {
+	err := h.favorites.RemoveFavorite(c.GetString("user_id"), c.Param("post_id"))
+	if err == store.ErrNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": "post is not in your favorites"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to remove favorite"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "removed from favorites"})
+}
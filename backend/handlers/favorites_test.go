@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"net/http"
+	"testing"
+
+	"bruinmarket-backend/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestFavoritesNotifyWatchers(t *testing.T) {
+	env := newTestEnv(t)
+	joe := env.signUp(t, "joe@ucla.edu", "Joe Bruin")
+	josie := env.signUp(t, "josie@ucla.edu", "Josie Bruin")
+
+	lamp := env.createPost(t, joe.Token, gin.H{"title": "Desk lamp", "description": "Bright", "price": 20, "category": "Furniture", "type": "selling"})
+
+	if w := env.do("POST", "/favorites/missing", josie.Token, nil); w.Code != http.StatusNotFound {
+		t.Fatalf("favorite missing post: got %d, want 404", w.Code)
+	}
+	// Adding twice is harmless
+	for i := 0; i < 2; i++ {
+		if w := env.do("POST", "/favorites/"+lamp.ID, josie.Token, nil); w.Code != http.StatusCreated {
+			t.Fatalf("favorite: got %d %s", w.Code, w.Body.String())
+		}
+	}
+
+	var post models.Post
+	decode(t, env.do("GET", "/posts/"+lamp.ID, "", nil), &post)
+	if post.FavoriteCount != 1 {
+		t.Fatalf("favorite_count = %d, want 1", post.FavoriteCount)
+	}
+	var favorites struct {
+		Posts []models.Post `json:"posts"`
+	}
+	decode(t, env.do("GET", "/favorites", josie.Token, nil), &favorites)
+	if len(favorites.Posts) != 1 || favorites.Posts[0].ID != lamp.ID {
+		t.Fatalf("favorites: got %+v", favorites.Posts)
+	}
+
+	update := gin.H{"title": "Desk lamp", "description": "Bright", "category": "Furniture", "type": "selling"}
+	// A price rise doesn't notify anyone
+	update["price"] = 25
+	if w := env.do("PUT", "/posts/"+lamp.ID, joe.Token, update); w.Code != http.StatusOK {
+		t.Fatalf("update: got %d", w.Code)
+	}
+	// Nor does a rejected negative price
+	update["price"] = -5
+	if w := env.do("PUT", "/posts/"+lamp.ID, joe.Token, update); w.Code != http.StatusBadRequest {
+		t.Fatalf("negative price: got %d, want 400", w.Code)
+	}
+	update["price"] = 15
+	if w := env.do("PUT", "/posts/"+lamp.ID, joe.Token, update); w.Code != http.StatusOK {
+		t.Fatalf("update: got %d", w.Code)
+	}
+	if w := env.do("PATCH", "/posts/"+lamp.ID+"/sold", joe.Token, gin.H{"sold": true}); w.Code != http.StatusOK {
+		t.Fatalf("mark sold: got %d", w.Code)
+	}
+
+	list := env.waitForNotifications(t, josie.Token, 2)
+	if len(list.Notifications) != 2 {
+		t.Fatalf("got %d notifications, want 2", len(list.Notifications))
+	}
+	sold, drop := list.Notifications[0], list.Notifications[1]
+	if drop.Type != models.NotificationPriceDrop || drop.PostID != lamp.ID {
+		t.Fatalf("unexpected price drop notification %+v", drop)
+	}
+	if sold.Type != models.NotificationPostSold || sold.PostID != lamp.ID {
+		t.Fatalf("unexpected sold notification %+v", sold)
+	}
+
+	if w := env.do("DELETE", "/favorites/"+lamp.ID, josie.Token, nil); w.Code != http.StatusOK {
+		t.Fatalf("remove favorite: got %d", w.Code)
+	}
+	if w := env.do("DELETE", "/favorites/"+lamp.ID, josie.Token, nil); w.Code != http.StatusNotFound {
+		t.Fatalf("remove again: got %d, want 404", w.Code)
+	}
+}
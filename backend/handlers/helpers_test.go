@@ -0,0 +1,213 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"bruinmarket-backend/auth"
+	"bruinmarket-backend/chat"
+	"bruinmarket-backend/models"
+	"bruinmarket-backend/notify"
+	"bruinmarket-backend/store"
+
+	"github.com/gin-gonic/gin"
+)
+
+// fakeMailer records the tokens the handlers would have emailed, the
+// recipients of alert emails and the posts expiry emails were sent for
+type fakeMailer struct {
+	verification chan string
+	reset        chan string
+	alerts       chan string
+	expired      chan string
+}
+
+func newFakeMailer() *fakeMailer {
+	return &fakeMailer{
+		verification: make(chan string, 10),
+		reset:        make(chan string, 10),
+		alerts:       make(chan string, 10),
+		expired:      make(chan string, 10),
+	}
+}
+
+func (m *fakeMailer) SendVerificationEmail(toEmail, toName, token string) error {
+	m.verification <- token
+	return nil
+}
+
+func (m *fakeMailer) SendWelcomeEmail(toEmail, toName string) error {
+	return nil
+}
+
+func (m *fakeMailer) SendPasswordResetEmail(toEmail, toName, token string) error {
+	m.reset <- token
+	return nil
+}
+
+func (m *fakeMailer) SendSavedSearchAlertEmail(toEmail, toName, searchName string, post *models.Post) error {
+	m.alerts <- toEmail
+	return nil
+}
+
+func (m *fakeMailer) SendPostExpiredEmail(toEmail, toName string, post *models.Post) error {
+	m.expired <- post.ID
+	return nil
+}
+
+func receive(t *testing.T, ch chan string) string {
+	t.Helper()
+	select {
+	case token := <-ch:
+		return token
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for email")
+		return ""
+	}
+}
+
+type testEnv struct {
+	router   *gin.Engine
+	handlers *Handlers
+	store    *store.Memory
+	mailer   *fakeMailer
+	expirer  *notify.PostExpirer
+	server   *httptest.Server // Started by dialWS
+}
+
+// newTestEnv serves the full API on top of the in-memory store
+func newTestEnv(t *testing.T) *testEnv {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	key, err := auth.ParseKey(auth.KeyConfig{KID: "test", Alg: "HS256", Secret: "test-secret"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys, err := auth.NewKeyManager([]*auth.Key{key}, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	env := &testEnv{store: store.NewMemory(), mailer: newFakeMailer()}
+	s := env.store
+	authHandler := NewAuthHandler(s, s, s, env.mailer, keys, 15*time.Minute, 24*time.Hour)
+	hub := chat.NewHub(s, s, s, chat.NewMemoryBroker(), chat.DefaultConfig())
+	go hub.Run()
+	notifier := notify.NewNotifier(s, hub)
+	events := &notify.PostEvents{
+		SavedSearchMatcher: notify.NewSavedSearchMatcher(s, s, notifier, env.mailer),
+		Watchlist:          notify.NewWatchlist(s, notifier),
+		Sales:              notify.NewSales(notifier),
+	}
+	go events.SavedSearchMatcher.Run()
+	go events.Watchlist.Run()
+	env.expirer = notify.NewPostExpirer(s, notifier, env.mailer)
+
+	env.handlers = &Handlers{
+		Auth:          authHandler,
+		Posts:         NewPostHandler(s, s, s, s, s, events, DefaultPostLifetimes()),
+		Users:         NewUserHandler(s, s, s, s),
+		Media:         NewMediaHandler(s, t.TempDir()),
+		Chat:          NewChatHandler(s, s, hub, authHandler),
+		Search:        NewSearchHandler(s),
+		SavedSearches: NewSavedSearchHandler(s),
+		Notifications: NewNotificationHandler(s),
+		Favorites:     NewFavoriteHandler(s, s, s),
+		Offers:        NewOfferHandler(s, s, s, s, hub),
+		Reviews:       NewReviewHandler(s, s, s),
+		Transactions:  NewTransactionHandler(s, s, s, s),
+		Reports:       NewReportHandler(s, s, s, s, s, hub, 2),
+		Admin:         NewAdminHandler(s, s, hub),
+		Categories:    NewCategoryHandler(s),
+	}
+	env.router = gin.New()
+	RegisterRoutes(env.router, env.handlers)
+	return env
+}
+
+// do sends a JSON request to /api + path
+func (env *testEnv) do(method, path, token string, body interface{}) *httptest.ResponseRecorder {
+	var buf bytes.Buffer
+	if body != nil {
+		json.NewEncoder(&buf).Encode(body)
+	}
+	req := httptest.NewRequest(method, "/api"+path, &buf)
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	w := httptest.NewRecorder()
+	env.router.ServeHTTP(w, req)
+	return w
+}
+
+func decode(t *testing.T, w *httptest.ResponseRecorder, v interface{}) {
+	t.Helper()
+	if err := json.Unmarshal(w.Body.Bytes(), v); err != nil {
+		t.Fatalf("decode %q: %v", w.Body.String(), err)
+	}
+}
+
+// signUp registers a user with password "secret1", follows the verification
+// link and returns the tokens it hands back
+func (env *testEnv) signUp(t *testing.T, email, name string) AuthResponse {
+	t.Helper()
+	w := env.do("POST", "/auth/register", "", gin.H{"email": email, "password": "secret1", "name": name, "year": "Junior"})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("register: got %d %s", w.Code, w.Body.String())
+	}
+
+	token := receive(t, env.mailer.verification)
+	w = env.do("GET", "/auth/verify-email?token="+token, "", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("verify: got %d %s", w.Code, w.Body.String())
+	}
+
+	var resp AuthResponse
+	decode(t, w, &resp)
+	return resp
+}
+
+// grantRole gives a signed-up user a role and logs them in again, since
+// changing roles revokes their old tokens
+func (env *testEnv) grantRole(t *testing.T, user AuthResponse, role string) AuthResponse {
+	t.Helper()
+	if err := env.store.SetUserRole(user.User.ID, role); err != nil {
+		t.Fatalf("set role: %v", err)
+	}
+	w := env.do("POST", "/auth/login", "", gin.H{"email": user.User.Email, "password": "secret1"})
+	if w.Code != http.StatusOK {
+		t.Fatalf("login: got %d %s", w.Code, w.Body.String())
+	}
+	var resp AuthResponse
+	decode(t, w, &resp)
+	return resp
+}
+
+type notificationList struct {
+	Notifications []models.Notification `json:"notifications"`
+	Unread        int                   `json:"unread"`
+}
+
+// waitForNotifications polls until the user has at least n notifications,
+// since alerts are sent in the background
+func (env *testEnv) waitForNotifications(t *testing.T, token string, n int) notificationList {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		var list notificationList
+		decode(t, env.do("GET", "/notifications", token, nil), &list)
+		if len(list.Notifications) >= n {
+			return list
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("got %d notifications, want %d", len(list.Notifications), n)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
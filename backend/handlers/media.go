@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"bruinmarket-backend/store"
+	"fmt"
+	"io"
+	"log"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type MediaHandler struct {
+	users     store.UserStore
+	uploadDir string
+}
+
+func NewMediaHandler(users store.UserStore, uploadDir string) *MediaHandler {
+	return &MediaHandler{
+		users:     users,
+		uploadDir: uploadDir,
+	}
+}
+
+// saveUpload copies an uploaded file into dir, creating it if needed
+func saveUpload(file multipart.File, dir, filename string) error {
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return fmt.Errorf("failed to create upload directory: %w", err)
+	}
+
+	filePath := filepath.Join(dir, filename)
+	log.Printf("Saving file to: %s", filePath)
+
+	dst, err := os.Create(filePath)
+	if err != nil {
+		return err
+	}
+	defer dst.Close()
+
+	_, err = io.Copy(dst, file)
+	return err
+}
+
+func (h *MediaHandler) UploadMedia(c *gin.Context) {
+	c.Request.ParseMultipartForm(10 << 20)
+
+	file, header, err := c.Request.FormFile("file")
+	if err != nil {
+		log.Printf("No file in request: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "no file uploaded"})
+		return
+	}
+	defer file.Close()
+
+	contentType := header.Header.Get("Content-Type")
+	if !strings.HasPrefix(contentType, "image/") && !strings.HasPrefix(contentType, "video/") {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "only images and videos allowed"})
+		return
+	}
+
+	filename := uuid.New().String() + filepath.Ext(header.Filename)
+
+	if err := saveUpload(file, h.uploadDir, filename); err != nil {
+		log.Printf("Error saving upload: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to save file"})
+		return
+	}
+
+	url := fmt.Sprintf("/uploads/%s", filename)
+	log.Printf("File saved successfully: %s", url)
+
+	c.JSON(http.StatusOK, gin.H{
+		"url":  url,
+		"type": contentType,
+	})
+}
+
+func (h *MediaHandler) UploadProfilePicture(c *gin.Context) {
+	userID := c.GetString("user_id")
+	c.Request.ParseMultipartForm(10 << 20)
+
+	file, header, err := c.Request.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "no file uploaded"})
+		return
+	}
+	defer file.Close()
+
+	contentType := header.Header.Get("Content-Type")
+	if !strings.HasPrefix(contentType, "image/") {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "only images allowed"})
+		return
+	}
+
+	filename := "profile_" + userID + "_" + uuid.New().String() + filepath.Ext(header.Filename)
+
+	if err := saveUpload(file, filepath.Join(h.uploadDir, "profiles"), filename); err != nil {
+		log.Printf("Error saving profile picture: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to save file"})
+		return
+	}
+
+	profileURL := fmt.Sprintf("/uploads/profiles/%s", filename)
+
+	if err := h.users.UpdateProfilePicture(userID, profileURL); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update profile picture"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"url": profileURL,
+	})
+}
@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"bruinmarket-backend/store"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// How many notifications GetNotifications returns
+const notificationsPageSize = 50
+
+type NotificationHandler struct {
+	notifications store.NotificationStore
+}
+
+func NewNotificationHandler(notifications store.NotificationStore) *NotificationHandler {
+	return &NotificationHandler{notifications: notifications}
+}
+
+func (h *NotificationHandler) GetNotifications(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	notifications, err := h.notifications.ListNotifications(userID, notificationsPageSize)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch notifications"})
+		return
+	}
+	unread, err := h.notifications.CountUnreadNotifications(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to count notifications"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"notifications": notifications,
+		"unread":        unread,
+	})
+}
+
+func (h *NotificationHandler) MarkNotificationRead(c *gin.Context) {
+	err := h.notifications.MarkNotificationRead(c.Param("id"), c.GetString("user_id"))
+	if err == store.ErrNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": "notification not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update notification"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "notification marked as read"})
+}
+
+func (h *NotificationHandler) MarkAllNotificationsRead(c *gin.Context) {
+	if err := h.notifications.MarkAllNotificationsRead(c.GetString("user_id")); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update notifications"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "notifications marked as read"})
+}
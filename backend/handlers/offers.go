@@ -0,0 +1,417 @@
+package handlers
+
+import (
+	"bruinmarket-backend/chat"
+	"bruinmarket-backend/models"
+	"bruinmarket-backend/store"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// How long an offer stands unless the request says otherwise, and the most
+// it can ask for
+const (
+	defaultOfferHours = 48
+	maxOfferHours     = 7 * 24
+)
+
+type offerRequest struct {
+	Amount         float64 `json:"amount" binding:"required,gt=0"`
+	Message        string  `json:"message"`
+	ExpiresInHours int     `json:"expires_in_hours"`
+}
+
+type OfferHandler struct {
+	offers        store.OfferStore
+	posts         store.PostStore
+	conversations store.ConversationStore
+	messages      store.MessageStore
+	hub           *chat.Hub
+}
+
+func NewOfferHandler(offers store.OfferStore, posts store.PostStore, conversations store.ConversationStore, messages store.MessageStore, hub *chat.Hub) *OfferHandler {
+	return &OfferHandler{
+		offers:        offers,
+		posts:         posts,
+		conversations: conversations,
+		messages:      messages,
+		hub:           hub,
+	}
+}
+
+// newOffer fills in an offer from the request, or writes a 400 and returns nil
+func newOffer(c *gin.Context) *models.Offer {
+	var req offerRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "amount must be greater than zero"})
+		return nil
+	}
+	hours := req.ExpiresInHours
+	if hours == 0 {
+		hours = defaultOfferHours
+	}
+	if hours < 0 || hours > maxOfferHours {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("expires_in_hours must be between 1 and %d", maxOfferHours)})
+		return nil
+	}
+
+	now := time.Now()
+	return &models.Offer{
+		ID:         uuid.New().String(),
+		ProposedBy: c.GetString("user_id"),
+		Amount:     req.Amount,
+		Message:    req.Message,
+		Status:     models.OfferPending,
+		ExpiresAt:  now.Add(time.Duration(hours) * time.Hour),
+		CreatedAt:  now,
+	}
+}
+
+// postEvent records an offer event as a system message in the buyer and
+// seller's conversation and pushes it to both of them
+func (h *OfferHandler) postEvent(offer *models.Offer, messageType, senderID, content string) {
+	conversation, err := findOrCreateConversation(h.conversations, offer.BuyerID, offer.SellerID)
+	if err != nil {
+		log.Printf("Error fetching conversation for offer %s: %v", offer.ID, err)
+		return
+	}
+
+	receiverID := offer.SellerID
+	if senderID == offer.SellerID {
+		receiverID = offer.BuyerID
+	}
+	message := &models.Message{
+		ID:             uuid.New().String(),
+		ConversationID: conversation.ID,
+		SenderID:       senderID,
+		ReceiverID:     receiverID,
+		Type:           messageType,
+		Content:        content,
+		OfferID:        offer.ID,
+		CreatedAt:      time.Now(),
+	}
+	if err := h.messages.CreateMessage(message); err != nil {
+		log.Printf("Error saving offer message: %v", err)
+		return
+	}
+	if err := h.conversations.UpdateLastMessage(conversation.ID, content, message.CreatedAt); err != nil {
+		log.Printf("Error updating conversation: %v", err)
+	}
+
+	payload, err := json.Marshal(chat.WSMessage{
+		Type:           messageType,
+		ConversationID: conversation.ID,
+		SenderID:       senderID,
+		ReceiverID:     receiverID,
+		Content:        content,
+		MessageID:      message.ID,
+		CreatedAt:      message.CreatedAt,
+		OfferID:        offer.ID,
+		Offer:          offer,
+	})
+	if err != nil {
+		log.Printf("Error encoding offer message: %v", err)
+		return
+	}
+	h.hub.SendToUser(senderID, payload)
+	h.hub.SendToUser(receiverID, payload)
+}
+
+func (h *OfferHandler) CreateOffer(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	post, err := h.posts.GetPost(c.Param("id"))
+	if err == store.ErrNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": "post not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database error"})
+		return
+	}
+	if post.UserID == userID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "you can't make an offer on your own post"})
+		return
+	}
+	if post.Type != "selling" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "offers can only be made on items for sale"})
+		return
+	}
+	if post.State != models.PostActive {
+		c.JSON(http.StatusConflict, gin.H{"error": "post is no longer available"})
+		return
+	}
+
+	offer := newOffer(c)
+	if offer == nil {
+		return
+	}
+
+	existing, err := h.offers.ListOffersForPost(post.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch offers"})
+		return
+	}
+	for _, o := range existing {
+		if o.BuyerID != userID || o.Status != models.OfferPending {
+			continue
+		}
+		if o.ExpiresAt.After(offer.CreatedAt) {
+			c.JSON(http.StatusConflict, gin.H{"error": "you already have a pending offer on this post"})
+			return
+		}
+		// Expired but not yet swept, and it would block the new offer. Only
+		// this one is expired here; the sweep itself runs on one replica.
+		at := offer.CreatedAt
+		err := h.offers.ResolveOffer(o.ID, models.OfferExpired, at)
+		if err == nil {
+			o.Status = models.OfferExpired
+			o.RespondedAt = &at
+			h.offerExpired(&o)
+		} else if err != store.ErrNotFound {
+			log.Printf("Error expiring offer %s: %v", o.ID, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create offer"})
+			return
+		}
+	}
+
+	offer.PostID = post.ID
+	offer.PostTitle = post.Title
+	offer.BuyerID = userID
+	offer.SellerID = post.UserID
+	err = h.offers.CreateOffer(offer)
+	if err == store.ErrConflict {
+		c.JSON(http.StatusConflict, gin.H{"error": "you already have a pending offer on this post"})
+		return
+	}
+	if err != nil {
+		log.Printf("Error creating offer: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create offer"})
+		return
+	}
+	if created, err := h.offers.GetOffer(offer.ID); err == nil {
+		offer = created
+	}
+
+	h.postEvent(offer, models.MessageOfferCreated, userID, fmt.Sprintf("Offered $%.2f for %s", offer.Amount, offer.PostTitle))
+	c.JSON(http.StatusCreated, offer)
+}
+
+// GetPostOffers lists every offer on the post for its seller, and only the
+// caller's own offers for anyone else
+func (h *OfferHandler) GetPostOffers(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	ownerID, err := h.posts.GetPostOwner(c.Param("id"))
+	if err == store.ErrNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": "post not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database error"})
+		return
+	}
+
+	offers, err := h.offers.ListOffersForPost(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch offers"})
+		return
+	}
+	if ownerID != userID {
+		mine := []models.Offer{}
+		for _, o := range offers {
+			if o.BuyerID == userID {
+				mine = append(mine, o)
+			}
+		}
+		offers = mine
+	}
+
+	c.JSON(http.StatusOK, offers)
+}
+
+func (h *OfferHandler) GetMyOffers(c *gin.Context) {
+	offers, err := h.offers.ListOffersForUser(c.GetString("user_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch offers"})
+		return
+	}
+
+	c.JSON(http.StatusOK, offers)
+}
+
+// pendingOffer loads an offer the current user can act on and writes the
+// error response if they can't. respond is true for accept/decline/counter,
+// which belong to the side that didn't propose it, and false for withdraw,
+// which belongs to the side that did.
+func (h *OfferHandler) pendingOffer(c *gin.Context, respond bool) (*models.Offer, bool) {
+	userID := c.GetString("user_id")
+
+	offer, err := h.offers.GetOffer(c.Param("id"))
+	if err == store.ErrNotFound || (err == nil && offer.BuyerID != userID && offer.SellerID != userID) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "offer not found"})
+		return nil, false
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database error"})
+		return nil, false
+	}
+
+	if respond && offer.Recipient() != userID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "waiting for the other side to respond"})
+		return nil, false
+	}
+	if !respond && offer.ProposedBy != userID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "you can only withdraw your own offers"})
+		return nil, false
+	}
+	if offer.Status != models.OfferPending {
+		c.JSON(http.StatusConflict, gin.H{"error": "offer is already " + offer.Status})
+		return nil, false
+	}
+	if !offer.ExpiresAt.After(time.Now()) {
+		c.JSON(http.StatusConflict, gin.H{"error": "offer has expired"})
+		return nil, false
+	}
+	return offer, true
+}
+
+// resolve moves the offer to status, records the event and responds with
+// the updated offer
+func (h *OfferHandler) resolve(c *gin.Context, offer *models.Offer, status, messageType, content string) {
+	h.resolved(c, offer, h.offers.ResolveOffer(offer.ID, status, time.Now()), messageType, content)
+}
+
+// resolved responds to an attempt to move the offer out of pending: with the
+// error if err is set, otherwise by recording the event and returning the
+// updated offer
+func (h *OfferHandler) resolved(c *gin.Context, offer *models.Offer, err error, messageType, content string) {
+	if err == store.ErrNotFound {
+		c.JSON(http.StatusConflict, gin.H{"error": "offer is no longer pending"})
+		return
+	}
+	if err != nil {
+		log.Printf("Error updating offer: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update offer"})
+		return
+	}
+
+	if updated, err := h.offers.GetOffer(offer.ID); err == nil {
+		offer = updated
+	}
+	h.postEvent(offer, messageType, c.GetString("user_id"), content)
+	c.JSON(http.StatusOK, offer)
+}
+
+// AcceptOffer accepts the offer and, unless the body says {"reserve": false},
+// marks the post reserved so nobody else can make offers on it
+func (h *OfferHandler) AcceptOffer(c *gin.Context) {
+	offer, ok := h.pendingOffer(c, true)
+	if !ok {
+		return
+	}
+
+	var requestBody struct {
+		Reserve *bool `json:"reserve"`
+	}
+	c.ShouldBindJSON(&requestBody)
+	reserve := requestBody.Reserve == nil || *requestBody.Reserve
+
+	post, err := h.posts.GetPost(offer.PostID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database error"})
+		return
+	}
+	if post.State != models.PostActive {
+		c.JSON(http.StatusConflict, gin.H{"error": "post is no longer available"})
+		return
+	}
+
+	// Accepting and reserving happen together so a failed reserve can't leave
+	// an accepted offer on a post that is still open to everyone else
+	err = h.offers.AcceptOffer(offer.ID, reserve, time.Now())
+	if err == store.ErrConflict {
+		c.JSON(http.StatusConflict, gin.H{"error": "post is no longer available"})
+		return
+	}
+	content := fmt.Sprintf("Accepted $%.2f for %s", offer.Amount, offer.PostTitle)
+	h.resolved(c, offer, err, models.MessageOfferAccepted, content)
+}
+
+func (h *OfferHandler) DeclineOffer(c *gin.Context) {
+	offer, ok := h.pendingOffer(c, true)
+	if !ok {
+		return
+	}
+
+	content := fmt.Sprintf("Declined $%.2f for %s", offer.Amount, offer.PostTitle)
+	h.resolve(c, offer, models.OfferDeclined, models.MessageOfferDeclined, content)
+}
+
+func (h *OfferHandler) WithdrawOffer(c *gin.Context) {
+	offer, ok := h.pendingOffer(c, false)
+	if !ok {
+		return
+	}
+
+	content := fmt.Sprintf("Withdrew the offer of $%.2f for %s", offer.Amount, offer.PostTitle)
+	h.resolve(c, offer, models.OfferWithdrawn, models.MessageOfferWithdrawn, content)
+}
+
+// CounterOffer answers a pending offer with a new amount, which the other
+// side can in turn accept, decline or counter
+func (h *OfferHandler) CounterOffer(c *gin.Context) {
+	parent, ok := h.pendingOffer(c, true)
+	if !ok {
+		return
+	}
+
+	counter := newOffer(c)
+	if counter == nil {
+		return
+	}
+	counter.PostID = parent.PostID
+	counter.BuyerID = parent.BuyerID
+	counter.SellerID = parent.SellerID
+	counter.ParentID = parent.ID
+
+	err := h.offers.CounterOffer(parent.ID, counter)
+	if err == store.ErrNotFound {
+		c.JSON(http.StatusConflict, gin.H{"error": "offer is no longer pending"})
+		return
+	}
+	if err != nil {
+		log.Printf("Error creating counter-offer: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create counter-offer"})
+		return
+	}
+	if created, err := h.offers.GetOffer(counter.ID); err == nil {
+		counter = created
+	}
+
+	h.postEvent(counter, models.MessageOfferCountered, counter.ProposedBy, fmt.Sprintf("Countered with $%.2f for %s", counter.Amount, counter.PostTitle))
+	c.JSON(http.StatusCreated, counter)
+}
+
+// ExpireOffers marks offers that expired before now and tells both sides
+func (h *OfferHandler) ExpireOffers(now time.Time) {
+	expired, err := h.offers.ExpireOffers(now)
+	if err != nil {
+		log.Printf("Error expiring offers: %v", err)
+		return
+	}
+	for i := range expired {
+		h.offerExpired(&expired[i])
+	}
+}
+
+// offerExpired tells both sides the offer expired
+func (h *OfferHandler) offerExpired(offer *models.Offer) {
+	h.postEvent(offer, models.MessageOfferExpired, offer.ProposedBy, fmt.Sprintf("The offer of $%.2f for %s expired", offer.Amount, offer.PostTitle))
+}
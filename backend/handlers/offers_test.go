@@ -0,0 +1,208 @@
+package handlers
+
+import (
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"bruinmarket-backend/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (env *testEnv) offer(t *testing.T, method, path, token string, body gin.H, want int) models.Offer {
+	t.Helper()
+	w := env.do(method, path, token, body)
+	if w.Code != want {
+		t.Fatalf("%s %s: got %d %s, want %d", method, path, w.Code, w.Body.String(), want)
+	}
+	var offer models.Offer
+	if want < 300 {
+		decode(t, w, &offer)
+	}
+	return offer
+}
+
+func TestOfferNegotiation(t *testing.T) {
+	env := newTestEnv(t)
+	joe := env.signUp(t, "joe@ucla.edu", "Joe Bruin")
+	josie := env.signUp(t, "josie@ucla.edu", "Josie Bruin")
+	eve := env.signUp(t, "eve@ucla.edu", "Eve Trojan")
+
+	bike := env.createPost(t, joe.Token, gin.H{"title": "Road bike", "description": "Fast", "price": 100, "category": "Other", "type": "selling"})
+	offersPath := "/posts/" + bike.ID + "/offers"
+
+	env.offer(t, "POST", offersPath, joe.Token, gin.H{"amount": 50}, http.StatusBadRequest)
+	env.offer(t, "POST", offersPath, josie.Token, gin.H{"amount": 0}, http.StatusBadRequest)
+	first := env.offer(t, "POST", offersPath, josie.Token, gin.H{"amount": 70, "message": "Cash today?"}, http.StatusCreated)
+	if first.Status != models.OfferPending || first.SellerID != joe.User.ID || first.PostTitle != "Road bike" {
+		t.Fatalf("unexpected offer %+v", first)
+	}
+	env.offer(t, "POST", offersPath, josie.Token, gin.H{"amount": 75}, http.StatusConflict)
+
+	// Only the seller can respond to the buyer's offer
+	env.offer(t, "POST", "/offers/"+first.ID+"/accept", josie.Token, nil, http.StatusForbidden)
+	env.offer(t, "POST", "/offers/"+first.ID+"/accept", eve.Token, nil, http.StatusNotFound)
+
+	counter := env.offer(t, "POST", "/offers/"+first.ID+"/counter", joe.Token, gin.H{"amount": 85}, http.StatusCreated)
+	if counter.ParentID != first.ID || counter.ProposedBy != joe.User.ID || counter.BuyerID != josie.User.ID {
+		t.Fatalf("unexpected counter %+v", counter)
+	}
+	env.offer(t, "POST", "/offers/"+first.ID+"/accept", joe.Token, nil, http.StatusConflict)
+	env.offer(t, "POST", "/offers/"+counter.ID+"/accept", joe.Token, nil, http.StatusForbidden)
+
+	accepted := env.offer(t, "POST", "/offers/"+counter.ID+"/accept", josie.Token, nil, http.StatusOK)
+	if accepted.Status != models.OfferAccepted || accepted.RespondedAt == nil {
+		t.Fatalf("unexpected accepted offer %+v", accepted)
+	}
+
+	var post models.Post
+	decode(t, env.do("GET", "/posts/"+bike.ID, "", nil), &post)
+	if !post.Reserved {
+		t.Fatal("accepting the offer didn't reserve the post")
+	}
+	env.offer(t, "POST", offersPath, eve.Token, gin.H{"amount": 90}, http.StatusConflict)
+
+	// The seller sees the whole thread, others only their own offers
+	var offers []models.Offer
+	decode(t, env.do("GET", offersPath, joe.Token, nil), &offers)
+	if len(offers) != 2 {
+		t.Fatalf("seller sees %d offers, want 2", len(offers))
+	}
+	decode(t, env.do("GET", offersPath, eve.Token, nil), &offers)
+	if len(offers) != 0 {
+		t.Fatalf("eve sees %d offers, want 0", len(offers))
+	}
+
+	// Every step shows up in the chat as a typed system message
+	var conversation models.Conversation
+	decode(t, env.do("GET", "/conversations/"+joe.User.ID, josie.Token, nil), &conversation)
+	var messages []models.Message
+	decode(t, env.do("GET", "/messages/"+conversation.ID, josie.Token, nil), &messages)
+	want := []string{models.MessageOfferCreated, models.MessageOfferCountered, models.MessageOfferAccepted}
+	if len(messages) != len(want) {
+		t.Fatalf("got %d messages, want %d", len(messages), len(want))
+	}
+	for i, msg := range messages {
+		if msg.Type != want[i] || msg.OfferID == "" {
+			t.Fatalf("message %d: got %s for offer %q, want %s", i, msg.Type, msg.OfferID, want[i])
+		}
+	}
+}
+
+func TestOffersExpire(t *testing.T) {
+	env := newTestEnv(t)
+	joe := env.signUp(t, "joe@ucla.edu", "Joe Bruin")
+	josie := env.signUp(t, "josie@ucla.edu", "Josie Bruin")
+
+	lamp := env.createPost(t, joe.Token, gin.H{"title": "Desk lamp", "description": "Bright", "price": 20, "category": "Furniture", "type": "selling"})
+	offer := env.offer(t, "POST", "/posts/"+lamp.ID+"/offers", josie.Token, gin.H{"amount": 15, "expires_in_hours": 1}, http.StatusCreated)
+
+	env.handlers.Offers.ExpireOffers(time.Now().Add(2 * time.Hour))
+
+	env.offer(t, "POST", "/offers/"+offer.ID+"/accept", joe.Token, nil, http.StatusConflict)
+	var offers []models.Offer
+	decode(t, env.do("GET", "/offers", josie.Token, nil), &offers)
+	if len(offers) != 1 || offers[0].Status != models.OfferExpired {
+		t.Fatalf("got %+v, want one expired offer", offers)
+	}
+
+	// The buyer can try again once the old offer has lapsed
+	env.offer(t, "POST", "/posts/"+lamp.ID+"/offers", josie.Token, gin.H{"amount": 18}, http.StatusCreated)
+}
+
+func TestConcurrentOffers(t *testing.T) {
+	env := newTestEnv(t)
+	joe := env.signUp(t, "joe@ucla.edu", "Joe Bruin")
+	josie := env.signUp(t, "josie@ucla.edu", "Josie Bruin")
+	eve := env.signUp(t, "eve@ucla.edu", "Eve Trojan")
+
+	desk := env.createPost(t, joe.Token, gin.H{"title": "Standing desk", "description": "Sturdy", "price": 120, "category": "Furniture", "type": "selling"})
+	offersPath := "/posts/" + desk.ID + "/offers"
+
+	// Racing requests from one buyer leave exactly one pending offer
+	codes := make([]int, 5)
+	var wg sync.WaitGroup
+	for i := range codes {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			codes[i] = env.do("POST", offersPath, josie.Token, gin.H{"amount": 80 + i}).Code
+		}(i)
+	}
+	wg.Wait()
+	created := 0
+	for _, code := range codes {
+		switch code {
+		case http.StatusCreated:
+			created++
+		case http.StatusConflict:
+		default:
+			t.Fatalf("unexpected status %d", code)
+		}
+	}
+	if created != 1 {
+		t.Fatalf("%d offers created, want 1", created)
+	}
+
+	// Accepting with reserve takes the post, so only one of two accepts wins
+	// and the other offer stays pending
+	var offers []models.Offer
+	decode(t, env.do("GET", offersPath, joe.Token, nil), &offers)
+	eveOffer := env.offer(t, "POST", offersPath, eve.Token, gin.H{"amount": 90}, http.StatusCreated)
+	ids := []string{offers[0].ID, eveOffer.ID}
+	codes = make([]int, len(ids))
+	for i, id := range ids {
+		wg.Add(1)
+		go func(i int, id string) {
+			defer wg.Done()
+			codes[i] = env.do("POST", "/offers/"+id+"/accept", joe.Token, nil).Code
+		}(i, id)
+	}
+	wg.Wait()
+	if codes[0]+codes[1] != http.StatusOK+http.StatusConflict {
+		t.Fatalf("accept statuses %v, want one 200 and one 409", codes)
+	}
+	decode(t, env.do("GET", offersPath, joe.Token, nil), &offers)
+	statuses := map[string]int{}
+	for _, o := range offers {
+		statuses[o.Status]++
+	}
+	if statuses[models.OfferAccepted] != 1 || statuses[models.OfferPending] != 1 {
+		t.Fatalf("offer statuses %v, want one accepted and one pending", statuses)
+	}
+}
+
+func TestLapsedOfferMakesWayForANewOne(t *testing.T) {
+	env := newTestEnv(t)
+	joe := env.signUp(t, "joe@ucla.edu", "Joe Bruin")
+	josie := env.signUp(t, "josie@ucla.edu", "Josie Bruin")
+	eve := env.signUp(t, "eve@ucla.edu", "Eve Trojan")
+
+	lamp := env.createPost(t, joe.Token, gin.H{"title": "Desk lamp", "description": "Bright", "price": 20, "category": "Furniture", "type": "selling"})
+
+	// Two offers past their expiry that the sweep hasn't reached yet
+	lapsed := func(buyerID string) *models.Offer {
+		offer := &models.Offer{
+			ID: buyerID + "-lapsed", PostID: lamp.ID, BuyerID: buyerID, SellerID: joe.User.ID, ProposedBy: buyerID,
+			Amount: 10, Status: models.OfferPending, ExpiresAt: time.Now().Add(-time.Minute), CreatedAt: time.Now().Add(-time.Hour),
+		}
+		if err := env.store.CreateOffer(offer); err != nil {
+			t.Fatal(err)
+		}
+		return offer
+	}
+	mine := lapsed(josie.User.ID)
+	theirs := lapsed(eve.User.ID)
+
+	env.offer(t, "POST", "/posts/"+lamp.ID+"/offers", josie.Token, gin.H{"amount": 18}, http.StatusCreated)
+
+	// Only the buyer's own lapsed offer is expired to make way
+	if o, _ := env.store.GetOffer(mine.ID); o.Status != models.OfferExpired {
+		t.Fatalf("josie's lapsed offer is %s, want expired", o.Status)
+	}
+	if o, _ := env.store.GetOffer(theirs.ID); o.Status != models.OfferPending {
+		t.Fatalf("eve's lapsed offer is %s, want it left for the sweep", o.Status)
+	}
+}
@@ -0,0 +1,877 @@
+package handlers
+
+import (
+	"bruinmarket-backend/models"
+	"bruinmarket-backend/store"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// Page sizes for post lists
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+// Searches with fewer full-text hits than this are topped up with
+// typo-tolerant title matches
+const fuzzySearchBelow = 3
+
+// How often an owner may bump a listing back to the top of "newest"
+const bumpInterval = 24 * time.Hour
+
+// How far ahead a draft can be scheduled to go live
+const maxScheduleAhead = 90 * 24 * time.Hour
+
+// PostLifetimes says how long a listing stays up after it is created or
+// renewed before the expiry job marks it expired
+type PostLifetimes struct {
+	Default    time.Duration
+	ByCategory map[string]time.Duration
+}
+
+// DefaultPostLifetimes runs listings for 30 days, less for things that are
+// only useful for a short while
+func DefaultPostLifetimes() PostLifetimes {
+	return PostLifetimes{
+		Default: 30 * 24 * time.Hour,
+		ByCategory: map[string]time.Duration{
+			"Tickets":   14 * 24 * time.Hour,
+			"Swipes":    7 * 24 * time.Hour,
+			"Rideshare": 7 * 24 * time.Hour,
+		},
+	}
+}
+
+// For returns the lifetime of a listing in category
+func (l PostLifetimes) For(category string) time.Duration {
+	if d, ok := l.ByCategory[category]; ok {
+		return d
+	}
+	return l.Default
+}
+
+// PostEvents is told about listing changes so alerts can go out.
+// notify.PostEvents implements it.
+type PostEvents interface {
+	PostCreated(post *models.Post)
+	PriceDropped(post *models.Post, oldPrice float64)
+	PostSold(post *models.Post)
+	SaleRecorded(sale *models.Transaction)
+}
+
+type PostHandler struct {
+	posts         store.PostStore
+	users         store.UserStore
+	media         store.MediaStore
+	conversations store.ConversationStore
+	categories    store.CategoryStore
+	events        PostEvents
+	lifetimes     PostLifetimes
+}
+
+// NewPostHandler builds the post endpoints; events may be nil
+func NewPostHandler(posts store.PostStore, users store.UserStore, media store.MediaStore, conversations store.ConversationStore, categories store.CategoryStore, events PostEvents, lifetimes PostLifetimes) *PostHandler {
+	return &PostHandler{
+		posts:         posts,
+		users:         users,
+		media:         media,
+		conversations: conversations,
+		categories:    categories,
+		events:        events,
+		lifetimes:     lifetimes,
+	}
+}
+
+// loadMedia fills in Media for every post with a single query
+func loadMedia(mediaStore store.MediaStore, posts []models.Post) {
+	if len(posts) == 0 {
+		return
+	}
+
+	ids := make([]string, len(posts))
+	for i := range posts {
+		ids[i] = posts[i].ID
+	}
+
+	byPost, err := mediaStore.ListMediaForPosts(ids)
+	if err != nil {
+		log.Printf("Error fetching media for %d posts: %v", len(posts), err)
+		return
+	}
+	for i := range posts {
+		media := byPost[posts[i].ID]
+		if media == nil {
+			media = []models.Media{}
+		}
+		posts[i].Media = media
+	}
+}
+
+// parsePaging applies the sort, limit and cursor query parameters to filter
+// and writes a 400 if any of them is invalid
+func parsePaging(c *gin.Context, filter *store.PostFilter) bool {
+	sort, ok := store.ParsePostSort(c.Query("sort"))
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "sort must be one of newest, price_asc, price_desc, most_viewed, ending_soon, relevance"})
+		return false
+	}
+	// Searches rank by relevance unless asked otherwise
+	if c.Query("sort") == "" && filter.Search != "" {
+		sort = store.SortRelevance
+	}
+	if sort == store.SortRelevance && filter.Search == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "sort=relevance requires a search"})
+		return false
+	}
+	filter.Sort = sort
+
+	filter.Limit = defaultPageSize
+	if raw := c.Query("limit"); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit < 1 || limit > maxPageSize {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("limit must be between 1 and %d", maxPageSize)})
+			return false
+		}
+		filter.Limit = limit
+	}
+
+	if raw := c.Query("cursor"); raw != "" {
+		cursor, err := store.DecodePostCursor(raw)
+		if err != nil || cursor.Sort != sort {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid cursor"})
+			return false
+		}
+		filter.After = cursor
+	}
+	return true
+}
+
+// Listing states anyone can browse, and the ones lists show by default.
+// Owners can also list their drafts and removed posts.
+var (
+	publicStates       = []string{models.PostActive, models.PostReserved, models.PostSold, models.PostExpired}
+	defaultListStates  = []string{models.PostActive, models.PostReserved, models.PostSold}
+	ownerStates        = []string{models.PostDraft, models.PostActive, models.PostReserved, models.PostSold, models.PostExpired, models.PostRemoved}
+	defaultOwnerStates = []string{models.PostDraft, models.PostActive, models.PostReserved, models.PostSold, models.PostExpired}
+)
+
+// parseStates reads a comma-separated ?state= into filter.States, allowing
+// only the given states. Without ?state= the filter gets defaults.
+func parseStates(c *gin.Context, filter *store.PostFilter, allowed, defaults []string) bool {
+	raw := c.Query("state")
+	if raw == "" || raw == "all" {
+		filter.States = defaults
+		return true
+	}
+	for _, state := range strings.Split(raw, ",") {
+		state = strings.TrimSpace(state)
+		ok := false
+		for _, a := range allowed {
+			ok = ok || a == state
+		}
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "state must be one of " + strings.Join(allowed, ", ")})
+			return false
+		}
+		filter.States = append(filter.States, state)
+	}
+	return true
+}
+
+// postPageJSON is the envelope every post list is returned in
+func postPageJSON(page *store.PostPage) gin.H {
+	var next *string
+	if page.Next != nil {
+		encoded := page.Next.Encode()
+		next = &encoded
+	}
+	return gin.H{
+		"posts":       page.Posts,
+		"next_cursor": next,
+		"total":       page.Total,
+	}
+}
+
+// checkOwner loads the post's owner and writes the error response if the
+// post is missing or not owned by the current user
+func (h *PostHandler) checkOwner(c *gin.Context, postID, forbiddenMessage string) bool {
+	ownerID, err := h.posts.GetPostOwner(postID)
+	if err == store.ErrNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": "post not found"})
+		return false
+	}
+	if err != nil {
+		log.Printf("Error fetching post owner: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database error"})
+		return false
+	}
+
+	if ownerID != c.GetString("user_id") {
+		c.JSON(http.StatusForbidden, gin.H{"error": forbiddenMessage})
+		return false
+	}
+	return true
+}
+
+// ownPost is checkOwner for handlers that also need the post as it was
+// before the change
+func (h *PostHandler) ownPost(c *gin.Context, postID, forbiddenMessage string) (*models.Post, bool) {
+	post, err := h.posts.GetPost(postID)
+	if err == store.ErrNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": "post not found"})
+		return nil, false
+	}
+	if err != nil {
+		log.Printf("Error fetching post: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database error"})
+		return nil, false
+	}
+
+	if post.UserID != c.GetString("user_id") {
+		c.JSON(http.StatusForbidden, gin.H{"error": forbiddenMessage})
+		return nil, false
+	}
+	return post, true
+}
+
+func (h *PostHandler) CreatePost(c *gin.Context) {
+	var post models.Post
+	if err := c.ShouldBindJSON(&post); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if post.Price < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "price cannot be negative"})
+		return
+	}
+	if !h.validCategory(c, post.Category) {
+		return
+	}
+
+	post.ID = uuid.New().String()
+	post.CreatedAt = time.Now()
+	post.BumpedAt = post.CreatedAt
+	post.ExpiresAt = post.CreatedAt.Add(h.lifetimes.For(post.Category))
+	post.ViewCount = 0
+	post.UserID = c.GetString("user_id")
+
+	// Posts go live straight away unless saved as a draft or scheduled,
+	// which makes them a draft until their publish time
+	switch {
+	case post.State != "" && post.State != models.PostActive && post.State != models.PostDraft:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "state must be 'active' or 'draft'"})
+		return
+	case post.PublishAt != nil:
+		if !validPublishAt(c, *post.PublishAt) {
+			return
+		}
+		post.SetState(models.PostDraft)
+	case post.State == models.PostDraft:
+		post.SetState(models.PostDraft)
+	default:
+		post.SetState(models.PostActive)
+	}
+
+	if post.Type != "selling" && post.Type != "buying" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "type must be 'selling' or 'buying'"})
+		return
+	}
+
+	user, err := h.users.GetUserByID(post.UserID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get user info"})
+		return
+	}
+	post.UserEmail = user.Email
+	post.UserName = user.Name
+	post.UserProfilePictureURL = user.ProfilePictureURL
+
+	if err := h.posts.CreatePost(&post); err != nil {
+		log.Printf("createPost insert error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create post"})
+		return
+	}
+
+	if err := h.media.ReplacePostMedia(post.ID, post.Media); err != nil {
+		log.Printf("Failed to insert media: %v", err)
+	}
+
+	if h.events != nil && post.State == models.PostActive {
+		h.events.PostCreated(&post)
+	}
+
+	c.JSON(http.StatusCreated, post)
+}
+
+func (h *PostHandler) GetPosts(c *gin.Context) {
+	filter := store.PostFilter{
+		Search:        c.Query("search"),
+		Condition:     c.Query("condition"),
+		ExcludeHidden: true,
+	}
+
+	if category := c.Query("category"); category != "all" {
+		filter.Category = category
+	}
+	if postType := c.Query("type"); postType != "all" {
+		filter.Type = postType
+	}
+	if val, err := strconv.ParseFloat(c.Query("min_price"), 64); err == nil {
+		filter.MinPrice = &val
+	}
+	if val, err := strconv.ParseFloat(c.Query("max_price"), 64); err == nil {
+		filter.MaxPrice = &val
+	}
+	if !parseStates(c, &filter, publicStates, defaultListStates) || !parsePaging(c, &filter) {
+		return
+	}
+
+	page, err := h.posts.ListPosts(filter)
+	if err != nil {
+		log.Printf("Error fetching posts: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch posts"})
+		return
+	}
+
+	fuzzy := false
+	if filter.Search != "" && filter.After == nil && page.Total < fuzzySearchBelow && !usesSearchOperators(filter.Search) {
+		fuzzy = h.addSimilarPosts(page, filter)
+	}
+	loadMedia(h.media, page.Posts)
+
+	response := postPageJSON(page)
+	response["fuzzy"] = fuzzy
+	c.JSON(http.StatusOK, response)
+}
+
+// usesSearchOperators reports whether the search has "phrases" or -exclusions.
+// Those are deliberate, so they don't get a typo-tolerant fallback.
+func usesSearchOperators(search string) bool {
+	if strings.Contains(search, `"`) {
+		return true
+	}
+	for _, word := range strings.Fields(search) {
+		if strings.HasPrefix(word, "-") {
+			return true
+		}
+	}
+	return false
+}
+
+// addSimilarPosts appends typo-tolerant matches to a sparse search result.
+// The combined result is a single page. Reports whether anything was added.
+func (h *PostHandler) addSimilarPosts(page *store.PostPage, filter store.PostFilter) bool {
+	similar, err := h.posts.SimilarPosts(filter)
+	if err != nil {
+		log.Printf("Error fetching similar posts: %v", err)
+		return false
+	}
+
+	seen := map[string]bool{}
+	for _, post := range page.Posts {
+		seen[post.ID] = true
+	}
+	added := false
+	for _, post := range similar {
+		if len(page.Posts) >= filter.Limit {
+			break
+		}
+		if !seen[post.ID] {
+			page.Posts = append(page.Posts, post)
+			added = true
+		}
+	}
+	if added {
+		page.Total = len(page.Posts)
+		page.Next = nil
+	}
+	return added
+}
+
+func (h *PostHandler) GetMyPosts(c *gin.Context) {
+	filter := store.PostFilter{UserID: c.GetString("user_id")}
+	if !parseStates(c, &filter, ownerStates, defaultOwnerStates) || !parsePaging(c, &filter) {
+		return
+	}
+
+	page, err := h.posts.ListPosts(filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch posts"})
+		return
+	}
+	loadMedia(h.media, page.Posts)
+
+	c.JSON(http.StatusOK, postPageJSON(page))
+}
+
+// GetPost returns a post and counts the view. Drafts and posts hidden after
+// reports are only visible to their owner, and removed posts to no one.
+func (h *PostHandler) GetPost(c *gin.Context) {
+	postID := c.Param("id")
+	post, err := h.posts.GetPost(postID)
+	if err == store.ErrNotFound || (err == nil && post.State == models.PostRemoved) ||
+		(err == nil && (post.State == models.PostDraft || post.Hidden) && post.UserID != c.GetString("user_id")) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "post not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch post"})
+		return
+	}
+
+	if post.State != models.PostDraft {
+		if err := h.posts.IncrementViewCount(postID); err != nil {
+			log.Printf("Error counting post view: %v", err)
+		} else {
+			post.ViewCount++
+		}
+	}
+
+	posts := []models.Post{*post}
+	loadMedia(h.media, posts)
+
+	c.JSON(http.StatusOK, posts[0])
+}
+
+func (h *PostHandler) DeletePost(c *gin.Context) {
+	postID := c.Param("id")
+	if !h.checkOwner(c, postID, "you can only delete your own posts") {
+		return
+	}
+
+	if err := h.posts.DeletePost(postID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete post"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "post deleted successfully"})
+}
+
+// saleInput optionally says who a post being marked sold went to and for
+// how much
+type saleInput struct {
+	BuyerID    string   `json:"buyer_id"`
+	FinalPrice *float64 `json:"final_price"`
+}
+
+// changeState moves post to state to on behalf of the current user and
+// writes the error response if that isn't allowed. Moving to the state the
+// post is already in is a no-op. A sale with a buyer is recorded for the
+// buyer to confirm.
+func (h *PostHandler) changeState(c *gin.Context, post *models.Post, to string, sale saleInput) bool {
+	if sale.BuyerID != "" && to != models.PostSold {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "buyer_id can only be given when marking a post sold"})
+		return false
+	}
+	if post.State == to && sale.BuyerID == "" {
+		return true
+	}
+	if !models.CanTransition(post.State, to) {
+		c.JSON(http.StatusConflict, gin.H{"error": fmt.Sprintf("a %s post can't be marked %s", post.State, to)})
+		return false
+	}
+	var transaction *models.Transaction
+	if sale.BuyerID != "" {
+		var ok bool
+		if transaction, ok = h.newSale(c, post, sale); !ok {
+			return false
+		}
+	}
+
+	now := time.Now()
+	var err error
+	switch {
+	case post.State == models.PostDraft && to == models.PostActive:
+		err = h.publish(post, c.GetString("user_id"), now)
+	case post.State == models.PostExpired && to == models.PostActive:
+		// Reactivating starts a fresh lifetime, the same as renewing
+		post.ExpiresAt = now.Add(h.lifetimes.For(post.Category))
+		err = h.posts.RenewPost(post.ID, c.GetString("user_id"), post.ExpiresAt, now)
+	case to == models.PostSold:
+		err = h.posts.SellPost(post.ID, post.State, c.GetString("user_id"), transaction, now)
+	default:
+		err = h.posts.SetPostState(post.ID, post.State, to, c.GetString("user_id"), now)
+	}
+	if err == store.ErrNotFound {
+		c.JSON(http.StatusConflict, gin.H{"error": "post was changed by someone else, please reload"})
+		return false
+	}
+	if err != nil {
+		log.Printf("Error updating post state: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update post state"})
+		return false
+	}
+
+	post.SetState(to)
+	if to == models.PostSold && h.events != nil {
+		h.events.PostSold(post)
+		if transaction != nil {
+			h.events.SaleRecorded(transaction)
+		}
+	}
+	return true
+}
+
+// newSale builds the pending transaction for selling post to sale.BuyerID,
+// writing a 400 unless the buyer is someone other than the seller who the
+// seller has a conversation with. The final price defaults to the asking
+// price.
+func (h *PostHandler) newSale(c *gin.Context, post *models.Post, sale saleInput) (*models.Transaction, bool) {
+	if sale.BuyerID == post.UserID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "you can't sell a post to yourself"})
+		return nil, false
+	}
+	finalPrice := post.Price
+	if sale.FinalPrice != nil {
+		finalPrice = *sale.FinalPrice
+	}
+	if finalPrice < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "final_price cannot be negative"})
+		return nil, false
+	}
+
+	buyer, err := h.users.GetUserByID(sale.BuyerID)
+	if err == nil {
+		_, err = h.conversations.FindConversation(post.UserID, sale.BuyerID)
+	}
+	if err == store.ErrNotFound {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "the buyer must be someone you have messaged"})
+		return nil, false
+	}
+	if err != nil {
+		log.Printf("Error checking buyer: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database error"})
+		return nil, false
+	}
+
+	return &models.Transaction{
+		ID:         uuid.New().String(),
+		PostID:     post.ID,
+		PostTitle:  post.Title,
+		SellerID:   post.UserID,
+		SellerName: post.UserName,
+		BuyerID:    buyer.ID,
+		BuyerName:  buyer.Name,
+		FinalPrice: finalPrice,
+		Status:     models.TransactionPending,
+		CreatedAt:  time.Now(),
+	}, true
+}
+
+// UpdatePostState moves the post to {"state": ...} if the transition is
+// allowed. A post being sold can name its buyer with "buyer_id" and
+// "final_price".
+func (h *PostHandler) UpdatePostState(c *gin.Context) {
+	post, ok := h.ownPost(c, c.Param("id"), "you can only update your own posts")
+	if !ok {
+		return
+	}
+
+	var requestBody struct {
+		State string `json:"state" binding:"required"`
+		saleInput
+	}
+	if err := c.ShouldBindJSON(&requestBody); err != nil || !models.ValidPostState(requestBody.State) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "state must be one of draft, active, reserved, sold, expired, removed"})
+		return
+	}
+
+	if !h.changeState(c, post, requestBody.State, requestBody.saleInput) {
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "post state updated successfully", "state": post.State})
+}
+
+// GetPostHistory lists the post's state changes for its owner
+func (h *PostHandler) GetPostHistory(c *gin.Context) {
+	postID := c.Param("id")
+	if !h.checkOwner(c, postID, "you can only view the history of your own posts") {
+		return
+	}
+
+	history, err := h.posts.ListPostStateHistory(postID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch post history"})
+		return
+	}
+
+	c.JSON(http.StatusOK, history)
+}
+
+// MarkPostAsSold is the older way to move a post to or from sold; prefer
+// UpdatePostState
+func (h *PostHandler) MarkPostAsSold(c *gin.Context) {
+	post, ok := h.ownPost(c, c.Param("id"), "you can only update your own posts")
+	if !ok {
+		return
+	}
+
+	// Parse request body to get sold status
+	var requestBody struct {
+		Sold bool `json:"sold"`
+		saleInput
+	}
+	if err := c.ShouldBindJSON(&requestBody); err != nil {
+		// If no body provided, default to true (mark as sold)
+		requestBody.Sold = true
+	}
+
+	action := "marked as sold"
+	to := models.PostSold
+	if !requestBody.Sold {
+		action = "unmarked as sold"
+		// Only undoes a sale; a post that isn't sold stays as it is
+		to = post.State
+		if post.State == models.PostSold {
+			to = models.PostActive
+		}
+	}
+	if !h.changeState(c, post, to, requestBody.saleInput) {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": fmt.Sprintf("post %s successfully", action)})
+}
+
+// MarkPostAsReserved holds the post for a buyer, or releases it again with
+// {"reserved": false}. Accepting an offer reserves the post automatically.
+func (h *PostHandler) MarkPostAsReserved(c *gin.Context) {
+	post, ok := h.ownPost(c, c.Param("id"), "you can only update your own posts")
+	if !ok {
+		return
+	}
+
+	var requestBody struct {
+		Reserved bool `json:"reserved"`
+	}
+	if err := c.ShouldBindJSON(&requestBody); err != nil {
+		requestBody.Reserved = true
+	}
+
+	action := "marked as reserved"
+	to := models.PostReserved
+	if !requestBody.Reserved {
+		action = "unmarked as reserved"
+		to = post.State
+		if post.State == models.PostReserved {
+			to = models.PostActive
+		}
+	}
+	if !h.changeState(c, post, to, saleInput{}) {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": fmt.Sprintf("post %s successfully", action)})
+}
+
+// validPublishAt writes a 400 unless t is in the future and within
+// maxScheduleAhead
+func validPublishAt(c *gin.Context, t time.Time) bool {
+	now := time.Now()
+	if !t.After(now) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "publish_at must be in the future"})
+		return false
+	}
+	if t.After(now.Add(maxScheduleAhead)) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("publish_at can be at most %d days ahead", int(maxScheduleAhead.Hours()/24))})
+		return false
+	}
+	return true
+}
+
+// publish makes a draft go live as of at, giving it a fresh lifetime and
+// sending out new listing alerts the same as creating it would have
+func (h *PostHandler) publish(post *models.Post, changedBy string, at time.Time) error {
+	expiresAt := at.Add(h.lifetimes.For(post.Category))
+	if err := h.posts.PublishPost(post.ID, changedBy, expiresAt, at); err != nil {
+		return err
+	}
+	post.SetState(models.PostActive)
+	post.BumpedAt = at
+	post.ExpiresAt = expiresAt
+	post.PublishAt = nil
+	if h.events != nil {
+		h.events.PostCreated(post)
+	}
+	return nil
+}
+
+// SchedulePost sets when a draft goes live with {"publish_at": ...}, or
+// unschedules it with {"publish_at": null}
+func (h *PostHandler) SchedulePost(c *gin.Context) {
+	post, ok := h.ownPost(c, c.Param("id"), "you can only schedule your own posts")
+	if !ok {
+		return
+	}
+
+	var requestBody struct {
+		PublishAt *time.Time `json:"publish_at"`
+	}
+	if err := c.ShouldBindJSON(&requestBody); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if requestBody.PublishAt != nil && !validPublishAt(c, *requestBody.PublishAt) {
+		return
+	}
+
+	err := h.posts.SchedulePost(post.ID, requestBody.PublishAt)
+	if err == store.ErrNotFound {
+		c.JSON(http.StatusConflict, gin.H{"error": "only drafts can be scheduled"})
+		return
+	}
+	if err != nil {
+		log.Printf("Error scheduling post: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to schedule post"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "post schedule updated successfully", "publish_at": requestBody.PublishAt})
+}
+
+// PublishScheduled publishes every draft whose publish time has come by now
+func (h *PostHandler) PublishScheduled(now time.Time) {
+	due, err := h.posts.ListScheduledPosts(now)
+	if err != nil {
+		log.Printf("Error listing scheduled posts: %v", err)
+		return
+	}
+	for i := range due {
+		err := h.publish(&due[i], "", now)
+		if err != nil && err != store.ErrNotFound {
+			log.Printf("Error publishing scheduled post %s: %v", due[i].ID, err)
+		}
+	}
+}
+
+// RenewPost restarts an active or expired listing's lifetime from now,
+// putting an expired one back up. The expiry email links here.
+func (h *PostHandler) RenewPost(c *gin.Context) {
+	post, ok := h.ownPost(c, c.Param("id"), "you can only renew your own posts")
+	if !ok {
+		return
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(h.lifetimes.For(post.Category))
+	err := h.posts.RenewPost(post.ID, c.GetString("user_id"), expiresAt, now)
+	if err == store.ErrNotFound {
+		c.JSON(http.StatusConflict, gin.H{"error": fmt.Sprintf("a %s post can't be renewed", post.State)})
+		return
+	}
+	if err != nil {
+		log.Printf("Error renewing post: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to renew post"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "post renewed successfully", "state": models.PostActive, "expires_at": expiresAt})
+}
+
+// BumpPost moves an active listing back to the top of the newest sort, at
+// most once per bumpInterval
+func (h *PostHandler) BumpPost(c *gin.Context) {
+	post, ok := h.ownPost(c, c.Param("id"), "you can only bump your own posts")
+	if !ok {
+		return
+	}
+	if post.State != models.PostActive {
+		c.JSON(http.StatusConflict, gin.H{"error": "only active posts can be bumped"})
+		return
+	}
+
+	now := time.Now()
+	if next := post.BumpedAt.Add(bumpInterval); now.Before(next) {
+		retryAfter := int(next.Sub(now).Seconds()) + 1
+		c.Header("Retry-After", strconv.Itoa(retryAfter))
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": "posts can only be bumped once a day", "next_bump_at": next})
+		return
+	}
+
+	err := h.posts.BumpPost(post.ID, now, now.Add(-bumpInterval))
+	if err == store.ErrNotFound {
+		// Bumped or changed by a concurrent request
+		c.JSON(http.StatusConflict, gin.H{"error": "post was changed by someone else, please reload"})
+		return
+	}
+	if err != nil {
+		log.Printf("Error bumping post: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to bump post"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "post bumped successfully", "bumped_at": now})
+}
+
+// validCategory checks category is one of the categories admins manage,
+// writing the error response if it isn't
+func (h *PostHandler) validCategory(c *gin.Context, category string) bool {
+	categories, err := h.categories.ListCategories()
+	if err != nil {
+		log.Printf("Error fetching categories: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch categories"})
+		return false
+	}
+	for _, known := range categories {
+		if known.Name == category {
+			return true
+		}
+	}
+	c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("unknown category %q", category)})
+	return false
+}
+
+func (h *PostHandler) UpdatePost(c *gin.Context) {
+	postID := c.Param("id")
+	before, ok := h.ownPost(c, postID, "you can only update your own posts")
+	if !ok {
+		return
+	}
+
+	var post models.Post
+	if err := c.ShouldBindJSON(&post); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if post.Price < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "price cannot be negative"})
+		return
+	}
+	post.ID = postID
+	post.UserID = before.UserID
+	post.SetState(before.State)
+
+	// Posts left in a deleted category can still be edited as long as they
+	// stay there
+	if post.Category != before.Category && !h.validCategory(c, post.Category) {
+		return
+	}
+
+	if err := h.posts.UpdatePost(&post); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update post"})
+		return
+	}
+
+	if err := h.media.ReplacePostMedia(postID, post.Media); err != nil {
+		log.Printf("updatePost media error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update media"})
+		return
+	}
+
+	// Nobody is waiting on a price cut for something already sold or gone
+	stillListed := before.State == models.PostActive || before.State == models.PostReserved
+	if post.Price < before.Price && stillListed && h.events != nil {
+		h.events.PriceDropped(&post, before.Price)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "post updated successfully"})
+}
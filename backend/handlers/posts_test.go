@@ -0,0 +1,369 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"bruinmarket-backend/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (env *testEnv) createPost(t *testing.T, token string, body gin.H) models.Post {
+	t.Helper()
+	w := env.do("POST", "/posts", token, body)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("create post: got %d %s", w.Code, w.Body.String())
+	}
+	var post models.Post
+	decode(t, w, &post)
+	return post
+}
+
+type postPage struct {
+	Posts      []models.Post `json:"posts"`
+	NextCursor *string       `json:"next_cursor"`
+	Total      int           `json:"total"`
+}
+
+func (env *testEnv) listPosts(t *testing.T, path, token string) postPage {
+	t.Helper()
+	w := env.do("GET", path, token, nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("list %s: got %d %s", path, w.Code, w.Body.String())
+	}
+	var page postPage
+	decode(t, w, &page)
+	return page
+}
+
+func TestCreateAndFilterPosts(t *testing.T) {
+	env := newTestEnv(t)
+	joe := env.signUp(t, "joe@ucla.edu", "Joe Bruin")
+
+	env.createPost(t, joe.Token, gin.H{"title": "Desk lamp", "description": "Bright", "price": 15, "category": "Furniture", "type": "selling",
+		"media": []gin.H{{"url": "/uploads/lamp.jpg", "type": "image", "order": 0}}})
+	env.createPost(t, joe.Token, gin.H{"title": "Calculus textbook", "description": "Barely used", "price": 60, "category": "Class Supplies", "type": "selling"})
+
+	page := env.listPosts(t, "/posts?category=all&type=all", "")
+	if len(page.Posts) != 2 || page.Total != 2 || page.NextCursor != nil {
+		t.Fatalf("got %d posts of %d, want 2 on one page", len(page.Posts), page.Total)
+	}
+
+	posts := env.listPosts(t, "/posts?search=lamp&max_price=20", "").Posts
+	if len(posts) != 1 || posts[0].Title != "Desk lamp" {
+		t.Fatalf("search: got %+v", posts)
+	}
+	if len(posts[0].Media) != 1 || posts[0].UserName != "Joe Bruin" {
+		t.Fatalf("post missing media or owner: %+v", posts[0])
+	}
+}
+
+func TestOnlyOwnerCanChangePost(t *testing.T) {
+	env := newTestEnv(t)
+	joe := env.signUp(t, "joe@ucla.edu", "Joe Bruin")
+	josie := env.signUp(t, "josie@ucla.edu", "Josie Bruin")
+
+	post := env.createPost(t, joe.Token, gin.H{"title": "Bike", "description": "Red", "price": 120, "category": "Other", "type": "selling"})
+
+	if w := env.do("DELETE", "/posts/"+post.ID, josie.Token, nil); w.Code != http.StatusForbidden {
+		t.Fatalf("delete by other user: got %d, want 403", w.Code)
+	}
+	if w := env.do("PATCH", "/posts/"+post.ID+"/sold", joe.Token, gin.H{"sold": true}); w.Code != http.StatusOK {
+		t.Fatalf("mark sold: got %d", w.Code)
+	}
+
+	w := env.do("GET", "/posts/"+post.ID, "", nil)
+	var got models.Post
+	decode(t, w, &got)
+	if !got.Sold {
+		t.Fatal("post not marked sold")
+	}
+
+	if w := env.do("DELETE", "/posts/"+post.ID, joe.Token, nil); w.Code != http.StatusOK {
+		t.Fatalf("delete: got %d", w.Code)
+	}
+	if w := env.do("GET", "/posts/"+post.ID, "", nil); w.Code != http.StatusNotFound {
+		t.Fatalf("deleted post: got %d, want 404", w.Code)
+	}
+}
+
+func TestPostPaginationAndSort(t *testing.T) {
+	env := newTestEnv(t)
+	joe := env.signUp(t, "joe@ucla.edu", "Joe Bruin")
+
+	prices := []float64{30, 10, 50, 20, 40}
+	for i, price := range prices {
+		env.createPost(t, joe.Token, gin.H{"title": fmt.Sprintf("Item %d", i), "description": "x", "price": price, "category": "Other", "type": "selling"})
+	}
+
+	var got []float64
+	path := "/posts?sort=price_asc&limit=2"
+	for pages := 0; ; pages++ {
+		if pages > len(prices) {
+			t.Fatal("pagination did not terminate")
+		}
+		page := env.listPosts(t, path, "")
+		if page.Total != len(prices) {
+			t.Fatalf("total = %d, want %d", page.Total, len(prices))
+		}
+		for _, p := range page.Posts {
+			got = append(got, p.Price)
+		}
+		if page.NextCursor == nil {
+			break
+		}
+		path = "/posts?sort=price_asc&limit=2&cursor=" + *page.NextCursor
+	}
+
+	want := []float64{10, 20, 30, 40, 50}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Fatalf("got prices %v, want %v", got, want)
+	}
+
+	// A cursor is only valid for the sort that produced it
+	first := env.listPosts(t, "/posts?sort=price_asc&limit=2", "")
+	if w := env.do("GET", "/posts?sort=newest&cursor="+*first.NextCursor, "", nil); w.Code != http.StatusBadRequest {
+		t.Fatalf("mismatched cursor: got %d, want 400", w.Code)
+	}
+	if w := env.do("GET", "/posts?sort=cheapest", "", nil); w.Code != http.StatusBadRequest {
+		t.Fatalf("unknown sort: got %d, want 400", w.Code)
+	}
+}
+
+func TestMostViewedSort(t *testing.T) {
+	env := newTestEnv(t)
+	joe := env.signUp(t, "joe@ucla.edu", "Joe Bruin")
+
+	quiet := env.createPost(t, joe.Token, gin.H{"title": "Quiet", "description": "x", "price": 1, "category": "Other", "type": "selling"})
+	popular := env.createPost(t, joe.Token, gin.H{"title": "Popular", "description": "x", "price": 1, "category": "Other", "type": "selling"})
+	for i := 0; i < 3; i++ {
+		env.do("GET", "/posts/"+popular.ID, "", nil)
+	}
+	env.do("GET", "/posts/"+quiet.ID, "", nil)
+
+	posts := env.listPosts(t, "/auth/my-posts?sort=most_viewed", joe.Token).Posts
+	if len(posts) != 2 || posts[0].ID != popular.ID || posts[0].ViewCount != 3 {
+		t.Fatalf("unexpected order %+v", posts)
+	}
+}
+
+func TestSearchRanksAndHighlights(t *testing.T) {
+	env := newTestEnv(t)
+	joe := env.signUp(t, "joe@ucla.edu", "Joe Bruin")
+
+	env.createPost(t, joe.Token, gin.H{"title": "Desk", "description": "Comes with a mini fridge shelf", "price": 40, "category": "Furniture", "type": "selling"})
+	env.createPost(t, joe.Token, gin.H{"title": "Mini fridge", "description": "Works great", "price": 60, "category": "Electronics", "type": "selling"})
+	env.createPost(t, joe.Token, gin.H{"title": "Mini fridge", "description": "Broken compressor", "price": 5, "category": "Electronics", "type": "selling"})
+
+	page := env.listPosts(t, `/posts?search=`+url.QueryEscape(`"mini fridge" -broken`), "")
+	if len(page.Posts) != 2 || page.Total != 2 {
+		t.Fatalf("got %d posts, want 2: %+v", len(page.Posts), page.Posts)
+	}
+	if page.Posts[0].Title != "Mini fridge" {
+		t.Fatalf("title match should rank first, got %q", page.Posts[0].Title)
+	}
+	if page.Posts[0].TitleHighlight != "<mark>Mini fridge</mark>" {
+		t.Fatalf("title highlight = %q", page.Posts[0].TitleHighlight)
+	}
+	if page.Posts[1].DescriptionSnippet != "Comes with a <mark>mini fridge</mark> shelf" {
+		t.Fatalf("snippet = %q", page.Posts[1].DescriptionSnippet)
+	}
+
+	if w := env.do("GET", "/posts?sort=relevance", "", nil); w.Code != http.StatusBadRequest {
+		t.Fatalf("relevance without search: got %d, want 400", w.Code)
+	}
+}
+
+func TestPostStateMachine(t *testing.T) {
+	env := newTestEnv(t)
+	joe := env.signUp(t, "joe@ucla.edu", "Joe Bruin")
+	josie := env.signUp(t, "josie@ucla.edu", "Josie Bruin")
+
+	lamp := env.createPost(t, joe.Token, gin.H{"title": "Desk lamp", "description": "Bright", "price": 15, "category": "Furniture", "type": "selling"})
+	env.createPost(t, joe.Token, gin.H{"title": "Desk chair", "description": "Comfy", "price": 40, "category": "Furniture", "type": "selling"})
+	if lamp.State != models.PostActive || lamp.Sold {
+		t.Fatalf("new post: state %q sold %v", lamp.State, lamp.Sold)
+	}
+
+	setState := func(token, state string, want int) {
+		t.Helper()
+		if w := env.do("PATCH", "/posts/"+lamp.ID+"/state", token, gin.H{"state": state}); w.Code != want {
+			t.Fatalf("state %s: got %d %s, want %d", state, w.Code, w.Body.String(), want)
+		}
+	}
+
+	setState(josie.Token, models.PostSold, http.StatusForbidden)
+	setState(joe.Token, "gone", http.StatusBadRequest)
+	setState(joe.Token, models.PostSold, http.StatusOK)
+
+	var post models.Post
+	decode(t, env.do("GET", "/posts/"+lamp.ID, "", nil), &post)
+	if post.State != models.PostSold || !post.Sold {
+		t.Fatalf("after selling: state %q sold %v", post.State, post.Sold)
+	}
+	if posts := env.listPosts(t, "/posts?state=sold", "").Posts; len(posts) != 1 || posts[0].ID != lamp.ID {
+		t.Fatalf("state=sold: got %+v", posts)
+	}
+
+	setState(joe.Token, models.PostReserved, http.StatusConflict)
+
+	// The old endpoint still works, going through the same transitions
+	if w := env.do("PATCH", "/posts/"+lamp.ID+"/sold", joe.Token, gin.H{"sold": false}); w.Code != http.StatusOK {
+		t.Fatalf("unmark sold: got %d", w.Code)
+	}
+	setState(joe.Token, models.PostRemoved, http.StatusOK)
+	setState(joe.Token, models.PostActive, http.StatusConflict)
+
+	if w := env.do("GET", "/posts/"+lamp.ID, "", nil); w.Code != http.StatusNotFound {
+		t.Fatalf("removed post: got %d, want 404", w.Code)
+	}
+	if page := env.listPosts(t, "/posts", ""); page.Total != 1 {
+		t.Fatalf("public list has %d posts, want 1", page.Total)
+	}
+	if w := env.do("GET", "/posts?state=removed", "", nil); w.Code != http.StatusBadRequest {
+		t.Fatalf("public state=removed: got %d, want 400", w.Code)
+	}
+	if page := env.listPosts(t, "/auth/my-posts?state=removed", joe.Token); page.Total != 1 || page.Posts[0].State != models.PostRemoved {
+		t.Fatalf("my removed posts: got %+v", page.Posts)
+	}
+
+	if w := env.do("GET", "/posts/"+lamp.ID+"/history", josie.Token, nil); w.Code != http.StatusForbidden {
+		t.Fatalf("history for non-owner: got %d, want 403", w.Code)
+	}
+	var history []models.PostStateChange
+	decode(t, env.do("GET", "/posts/"+lamp.ID+"/history", joe.Token, nil), &history)
+	want := [][2]string{{models.PostActive, models.PostSold}, {models.PostSold, models.PostActive}, {models.PostActive, models.PostRemoved}}
+	if len(history) != len(want) {
+		t.Fatalf("got %d history entries, want %d", len(history), len(want))
+	}
+	for i, change := range history {
+		if change.FromState != want[i][0] || change.ToState != want[i][1] || change.ChangedBy != joe.User.ID {
+			t.Fatalf("history %d: got %+v, want %v", i, change, want[i])
+		}
+	}
+}
+
+func TestPostExpiryRenewAndBump(t *testing.T) {
+	env := newTestEnv(t)
+	joe := env.signUp(t, "joe@ucla.edu", "Joe Bruin")
+	josie := env.signUp(t, "josie@ucla.edu", "Josie Bruin")
+
+	tickets := env.createPost(t, joe.Token, gin.H{"title": "Concert tickets", "description": "Two seats", "price": 80, "category": "Tickets", "type": "selling"})
+	lamp := env.createPost(t, joe.Token, gin.H{"title": "Desk lamp", "description": "Bright", "price": 15, "category": "Furniture", "type": "selling"})
+	if got := tickets.ExpiresAt.Sub(tickets.CreatedAt); got != 14*24*time.Hour {
+		t.Fatalf("tickets lifetime = %v, want 14 days", got)
+	}
+	if got := lamp.ExpiresAt.Sub(lamp.CreatedAt); got != 30*24*time.Hour {
+		t.Fatalf("lamp lifetime = %v, want 30 days", got)
+	}
+
+	env.expirer.ExpirePosts(time.Now().Add(15 * 24 * time.Hour))
+
+	if id := receive(t, env.mailer.expired); id != tickets.ID {
+		t.Fatalf("expiry email for %s, want %s", id, tickets.ID)
+	}
+	notifications := env.waitForNotifications(t, joe.Token, 1)
+	if n := notifications.Notifications[0]; n.Type != models.NotificationPostExpired || n.PostID != tickets.ID {
+		t.Fatalf("got notification %+v", n)
+	}
+	if posts := env.listPosts(t, "/posts", "").Posts; len(posts) != 1 || posts[0].ID != lamp.ID {
+		t.Fatalf("expired post still listed: %+v", posts)
+	}
+
+	if w := env.do("POST", "/posts/"+tickets.ID+"/renew", josie.Token, nil); w.Code != http.StatusForbidden {
+		t.Fatalf("renew by other user: got %d, want 403", w.Code)
+	}
+	if w := env.do("POST", "/posts/"+tickets.ID+"/renew", joe.Token, nil); w.Code != http.StatusOK {
+		t.Fatalf("renew: got %d %s", w.Code, w.Body.String())
+	}
+	var post models.Post
+	decode(t, env.do("GET", "/posts/"+tickets.ID, "", nil), &post)
+	if post.State != models.PostActive || !post.ExpiresAt.After(time.Now().Add(13*24*time.Hour)) {
+		t.Fatalf("after renew: state %q expires %v", post.State, post.ExpiresAt)
+	}
+
+	// New posts can't be bumped until a day has passed
+	if w := env.do("POST", "/posts/"+tickets.ID+"/bump", joe.Token, nil); w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") == "" {
+		t.Fatalf("early bump: got %d, want 429 with Retry-After", w.Code)
+	}
+	if err := env.store.BumpPost(tickets.ID, time.Now().Add(-48*time.Hour), time.Now().Add(time.Second)); err != nil {
+		t.Fatal(err)
+	}
+	if posts := env.listPosts(t, "/posts", "").Posts; posts[0].ID != lamp.ID {
+		t.Fatalf("newest post is %s, want the lamp", posts[0].Title)
+	}
+	if w := env.do("POST", "/posts/"+tickets.ID+"/bump", joe.Token, nil); w.Code != http.StatusOK {
+		t.Fatalf("bump: got %d %s", w.Code, w.Body.String())
+	}
+	if posts := env.listPosts(t, "/posts", "").Posts; posts[0].ID != tickets.ID {
+		t.Fatalf("newest post is %s after bump, want the tickets", posts[0].Title)
+	}
+	if w := env.do("POST", "/posts/"+tickets.ID+"/bump", joe.Token, nil); w.Code != http.StatusTooManyRequests {
+		t.Fatalf("second bump: got %d, want 429", w.Code)
+	}
+}
+
+func TestDraftAndScheduledPosts(t *testing.T) {
+	env := newTestEnv(t)
+	joe := env.signUp(t, "joe@ucla.edu", "Joe Bruin")
+	josie := env.signUp(t, "josie@ucla.edu", "Josie Bruin")
+
+	draft := env.createPost(t, joe.Token, gin.H{"title": "Mini fridge", "description": "Cold", "price": 50, "category": "Electronics", "type": "selling", "state": "draft"})
+	publishAt := time.Now().Add(time.Hour)
+	scheduled := env.createPost(t, joe.Token, gin.H{"title": "Futon", "description": "Comfy", "price": 70, "category": "Furniture", "type": "selling", "publish_at": publishAt})
+	if draft.State != models.PostDraft || scheduled.State != models.PostDraft || scheduled.PublishAt == nil {
+		t.Fatalf("got states %q and %q, publish_at %v", draft.State, scheduled.State, scheduled.PublishAt)
+	}
+	if w := env.do("POST", "/posts", joe.Token, gin.H{"title": "Desk", "description": "Oak", "price": 40, "category": "Furniture", "type": "selling", "publish_at": time.Now().Add(-time.Hour)}); w.Code != http.StatusBadRequest {
+		t.Fatalf("publish_at in the past: got %d, want 400", w.Code)
+	}
+
+	if page := env.listPosts(t, "/posts", ""); page.Total != 0 {
+		t.Fatalf("public list has %d posts, want drafts hidden", page.Total)
+	}
+	for _, token := range []string{"", josie.Token} {
+		if w := env.do("GET", "/posts/"+draft.ID, token, nil); w.Code != http.StatusNotFound {
+			t.Fatalf("draft seen by others: got %d, want 404", w.Code)
+		}
+	}
+	if w := env.do("GET", "/posts/"+draft.ID, joe.Token, nil); w.Code != http.StatusOK {
+		t.Fatalf("draft seen by owner: got %d, want 200", w.Code)
+	}
+	if w := env.do("POST", "/favorites/"+draft.ID, josie.Token, nil); w.Code != http.StatusNotFound {
+		t.Fatalf("favorite a draft: got %d, want 404", w.Code)
+	}
+
+	// Only drafts can be scheduled
+	lamp := env.createPost(t, joe.Token, gin.H{"title": "Desk lamp", "description": "Bright", "price": 15, "category": "Furniture", "type": "selling"})
+	if w := env.do("PUT", "/posts/"+lamp.ID+"/schedule", joe.Token, gin.H{"publish_at": publishAt}); w.Code != http.StatusConflict {
+		t.Fatalf("schedule an active post: got %d, want 409", w.Code)
+	}
+
+	env.handlers.Posts.PublishScheduled(time.Now().Add(2 * time.Hour))
+
+	var post models.Post
+	decode(t, env.do("GET", "/posts/"+scheduled.ID, "", nil), &post)
+	if post.State != models.PostActive || post.PublishAt != nil || !post.BumpedAt.After(lamp.BumpedAt) {
+		t.Fatalf("scheduled post after its publish time: %+v", post)
+	}
+	decode(t, env.do("GET", "/posts/"+draft.ID, joe.Token, nil), &post)
+	if post.State != models.PostDraft {
+		t.Fatalf("unscheduled draft was published: state %q", post.State)
+	}
+
+	if w := env.do("PATCH", "/posts/"+draft.ID+"/state", joe.Token, gin.H{"state": models.PostActive}); w.Code != http.StatusOK {
+		t.Fatalf("publish draft: got %d %s", w.Code, w.Body.String())
+	}
+	if page := env.listPosts(t, "/posts", ""); page.Total != 3 {
+		t.Fatalf("after publishing: got %d posts, want 3", page.Total)
+	}
+	var history []models.PostStateChange
+	decode(t, env.do("GET", "/posts/"+draft.ID+"/history", joe.Token, nil), &history)
+	if len(history) != 1 || history[0].FromState != models.PostDraft || history[0].ToState != models.PostActive {
+		t.Fatalf("history: got %+v", history)
+	}
+}
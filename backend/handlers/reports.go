@@ -0,0 +1,345 @@
+package handlers
+
+import (
+	"bruinmarket-backend/chat"
+	"bruinmarket-backend/models"
+	"bruinmarket-backend/store"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// Longest report note accepted
+const maxReportNoteLength = 1000
+
+// DefaultReportHideThreshold is how many distinct users must report
+// something before it is hidden pending review
+const DefaultReportHideThreshold = 3
+
+type ReportHandler struct {
+	reports       store.ReportStore
+	users         store.UserStore
+	posts         store.PostStore
+	messages      store.MessageStore
+	sessions      store.SessionStore
+	hub           *chat.Hub
+	hideThreshold int
+}
+
+func NewReportHandler(reports store.ReportStore, users store.UserStore, posts store.PostStore, messages store.MessageStore, sessions store.SessionStore, hub *chat.Hub, hideThreshold int) *ReportHandler {
+	return &ReportHandler{
+		reports:       reports,
+		users:         users,
+		posts:         posts,
+		messages:      messages,
+		sessions:      sessions,
+		hub:           hub,
+		hideThreshold: hideThreshold,
+	}
+}
+
+// reportTargetNames names each target type in error messages
+var reportTargetNames = map[string]string{
+	models.ReportTargetPost:    "post",
+	models.ReportTargetUser:    "user",
+	models.ReportTargetMessage: "message",
+}
+
+// targetOwner finds the user responsible for a report target: the post's
+// owner, the user themselves, or the message's sender. Users can only
+// report messages they received.
+func (h *ReportHandler) targetOwner(targetType, targetID, reporterID string) (string, error) {
+	switch targetType {
+	case models.ReportTargetPost:
+		post, err := h.posts.GetPost(targetID)
+		if err != nil {
+			return "", err
+		}
+		if post.State == models.PostRemoved || (post.State == models.PostDraft && post.UserID != reporterID) {
+			return "", store.ErrNotFound
+		}
+		return post.UserID, nil
+	case models.ReportTargetUser:
+		user, err := h.users.GetUserByID(targetID)
+		if err != nil {
+			return "", err
+		}
+		return user.ID, nil
+	default:
+		message, err := h.messages.GetMessage(targetID)
+		if err != nil {
+			return "", err
+		}
+		if message.ReceiverID != reporterID && message.SenderID != reporterID {
+			return "", store.ErrNotFound
+		}
+		return message.SenderID, nil
+	}
+}
+
+// CreateReport flags a post, user or message with
+// {"target_type": ..., "target_id": ..., "reason": ..., "note": ...}. Once
+// enough different users have reported it, the target is hidden until a
+// moderator reviews it.
+func (h *ReportHandler) CreateReport(c *gin.Context) {
+	var input struct {
+		TargetType string `json:"target_type" binding:"required"`
+		TargetID   string `json:"target_id" binding:"required"`
+		Reason     string `json:"reason" binding:"required"`
+		Note       string `json:"note"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if _, ok := reportTargetNames[input.TargetType]; !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "target_type must be post, user or message"})
+		return
+	}
+	if !models.ValidReportReason(input.Reason) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid reason"})
+		return
+	}
+	input.Note = strings.TrimSpace(input.Note)
+	if len(input.Note) > maxReportNoteLength {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("note can be at most %d characters", maxReportNoteLength)})
+		return
+	}
+	if input.Reason == models.ReportOther && input.Note == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "a note is required when the reason is other"})
+		return
+	}
+
+	userID := c.GetString("user_id")
+	name := reportTargetNames[input.TargetType]
+	ownerID, err := h.targetOwner(input.TargetType, input.TargetID, userID)
+	if err == store.ErrNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": name + " not found"})
+		return
+	}
+	if err != nil {
+		log.Printf("Error fetching report target: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database error"})
+		return
+	}
+	if ownerID == userID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "you cannot report yourself or your own " + name})
+		return
+	}
+
+	report := models.Report{
+		ID:           uuid.New().String(),
+		ReporterID:   userID,
+		TargetType:   input.TargetType,
+		TargetID:     input.TargetID,
+		TargetUserID: ownerID,
+		Reason:       input.Reason,
+		Note:         input.Note,
+		Status:       models.ReportOpen,
+		CreatedAt:    time.Now(),
+	}
+	err = h.reports.CreateReport(&report)
+	if err == store.ErrConflict {
+		c.JSON(http.StatusConflict, gin.H{"error": "you have already reported this " + name})
+		return
+	}
+	if err != nil {
+		log.Printf("Error creating report: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to save report"})
+		return
+	}
+
+	reporters, err := h.reports.CountReporters(report.TargetType, report.TargetID)
+	if err != nil {
+		log.Printf("Error counting reporters: %v", err)
+	} else if reporters >= h.hideThreshold {
+		if err := h.reports.SetTargetHidden(report.TargetType, report.TargetID, true); err != nil {
+			log.Printf("Error hiding reported %s %s: %v", name, report.TargetID, err)
+		}
+	}
+
+	c.JSON(http.StatusCreated, report)
+}
+
+// GetReports lists reports for moderators, oldest first. ?status= picks open
+// (the default), dismissed or actioned reports.
+func (h *ReportHandler) GetReports(c *gin.Context) {
+	status := c.DefaultQuery("status", models.ReportOpen)
+	if status != models.ReportOpen && status != models.ReportDismissed && status != models.ReportActioned {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "status must be open, dismissed or actioned"})
+		return
+	}
+
+	reports, err := h.reports.ListReports(status)
+	if err != nil {
+		log.Printf("Error fetching reports: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch reports"})
+		return
+	}
+
+	c.JSON(http.StatusOK, reports)
+}
+
+// GetReport shows a moderator the report along with the reported content
+// and the other open reports against it
+func (h *ReportHandler) GetReport(c *gin.Context) {
+	report, ok := h.loadReport(c)
+	if !ok {
+		return
+	}
+
+	var target interface{}
+	var err error
+	switch report.TargetType {
+	case models.ReportTargetPost:
+		target, err = h.posts.GetPost(report.TargetID)
+	case models.ReportTargetUser:
+		target, err = h.users.GetUserByID(report.TargetID)
+	default:
+		target, err = h.messages.GetMessage(report.TargetID)
+	}
+	if err == store.ErrNotFound {
+		target = nil
+	} else if err != nil {
+		log.Printf("Error fetching report target: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database error"})
+		return
+	}
+
+	open, err := h.reports.ListReports(models.ReportOpen)
+	if err != nil {
+		log.Printf("Error fetching reports: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch reports"})
+		return
+	}
+	related := []models.Report{}
+	for _, r := range open {
+		if r.TargetType == report.TargetType && r.TargetID == report.TargetID {
+			related = append(related, r)
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"report": report, "target": target, "open_reports": related})
+}
+
+// loadReport fetches the :id report, writing the error response if it is
+// missing
+func (h *ReportHandler) loadReport(c *gin.Context) (*models.Report, bool) {
+	report, err := h.reports.GetReport(c.Param("id"))
+	if err == store.ErrNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": "report not found"})
+		return nil, false
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database error"})
+		return nil, false
+	}
+	return report, true
+}
+
+// loadOpenReport is loadReport for the moderation actions, which need the
+// report to still be open
+func (h *ReportHandler) loadOpenReport(c *gin.Context) (*models.Report, bool) {
+	report, ok := h.loadReport(c)
+	if ok && report.Status != models.ReportOpen {
+		c.JSON(http.StatusConflict, gin.H{"error": "report has already been " + report.Status})
+		return nil, false
+	}
+	return report, ok
+}
+
+// resolve closes every open report on the report's target
+func (h *ReportHandler) resolve(c *gin.Context, report *models.Report, status string) {
+	err := h.reports.ResolveReports(report.TargetType, report.TargetID, status, c.GetString("user_id"), time.Now())
+	if err == store.ErrNotFound {
+		c.JSON(http.StatusConflict, gin.H{"error": "report has already been resolved"})
+		return
+	}
+	if err != nil {
+		log.Printf("Error resolving reports: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to resolve reports"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "reports " + status})
+}
+
+// DismissReport closes the reports on the target without action and shows it
+// again if it had been hidden
+func (h *ReportHandler) DismissReport(c *gin.Context) {
+	report, ok := h.loadOpenReport(c)
+	if !ok {
+		return
+	}
+
+	err := h.reports.SetTargetHidden(report.TargetType, report.TargetID, false)
+	if err != nil && err != store.ErrNotFound {
+		log.Printf("Error unhiding report target: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to restore content"})
+		return
+	}
+
+	h.resolve(c, report, models.ReportDismissed)
+}
+
+// RemoveReportedContent takes down a reported post or message. Reported
+// users are dealt with by suspending them.
+func (h *ReportHandler) RemoveReportedContent(c *gin.Context) {
+	report, ok := h.loadOpenReport(c)
+	if !ok {
+		return
+	}
+
+	var err error
+	switch report.TargetType {
+	case models.ReportTargetUser:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "users cannot be removed; suspend the account instead"})
+		return
+	case models.ReportTargetPost:
+		var post *models.Post
+		post, err = h.posts.GetPost(report.TargetID)
+		if err == nil && post.State != models.PostRemoved {
+			err = h.posts.SetPostState(post.ID, post.State, models.PostRemoved, c.GetString("user_id"), time.Now())
+		}
+	default:
+		// Removed messages stay hidden for good
+		err = h.reports.SetTargetHidden(report.TargetType, report.TargetID, true)
+	}
+	if err != nil && err != store.ErrNotFound {
+		log.Printf("Error removing reported %s: %v", report.TargetType, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to remove content"})
+		return
+	}
+
+	h.resolve(c, report, models.ReportActioned)
+}
+
+// SuspendReportedUser suspends the user responsible for the reported
+// content and signs them out everywhere. Only admins can suspend staff.
+func (h *ReportHandler) SuspendReportedUser(c *gin.Context) {
+	report, ok := h.loadOpenReport(c)
+	if !ok {
+		return
+	}
+
+	user, err := h.users.GetUserByID(report.TargetUserID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database error"})
+		return
+	}
+	if models.RoleAtLeast(user.Role, models.RoleModerator) && !models.RoleAtLeast(c.GetString("user_role"), models.RoleAdmin) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "only admins can suspend staff accounts"})
+		return
+	}
+
+	if !suspendUser(c, h.users, h.sessions, h.hub, user.ID) {
+		return
+	}
+
+	h.resolve(c, report, models.ReportActioned)
+}
@@ -0,0 +1,135 @@
+package handlers
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"bruinmarket-backend/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestReportsAndModeration(t *testing.T) {
+	env := newTestEnv(t)
+	joe := env.signUp(t, "joe@ucla.edu", "Joe Bruin")
+	josie := env.signUp(t, "josie@ucla.edu", "Josie Bruin")
+	eve := env.signUp(t, "eve@ucla.edu", "Eve Trojan")
+	mod := env.grantRole(t, env.signUp(t, "mod@ucla.edu", "Mod Bruin"), models.RoleModerator)
+
+	lamp := env.createPost(t, joe.Token, gin.H{"title": "Desk lamp", "description": "Wire the money first", "price": 15, "category": "Furniture", "type": "selling"})
+	report := func(token string, body gin.H, want int) models.Report {
+		t.Helper()
+		w := env.do("POST", "/reports", token, body)
+		if w.Code != want {
+			t.Fatalf("report %v: got %d %s, want %d", body, w.Code, w.Body.String(), want)
+		}
+		var r models.Report
+		decode(t, w, &r)
+		return r
+	}
+	visiblePosts := func() int {
+		t.Helper()
+		var page struct {
+			Total int `json:"total"`
+		}
+		decode(t, env.do("GET", "/posts", "", nil), &page)
+		return page.Total
+	}
+
+	first := report(josie.Token, gin.H{"target_type": "post", "target_id": lamp.ID, "reason": models.ReportScam}, http.StatusCreated)
+	report(josie.Token, gin.H{"target_type": "post", "target_id": lamp.ID, "reason": models.ReportSpam}, http.StatusConflict)
+	report(joe.Token, gin.H{"target_type": "post", "target_id": lamp.ID, "reason": models.ReportSpam}, http.StatusBadRequest)
+	report(eve.Token, gin.H{"target_type": "post", "target_id": lamp.ID, "reason": "boring"}, http.StatusBadRequest)
+	report(eve.Token, gin.H{"target_type": "post", "target_id": lamp.ID, "reason": models.ReportOther}, http.StatusBadRequest)
+	report(eve.Token, gin.H{"target_type": "post", "target_id": "missing", "reason": models.ReportScam}, http.StatusNotFound)
+	if visiblePosts() != 1 {
+		t.Fatal("post hidden after one report")
+	}
+
+	// A second reporter reaches the test threshold and hides the post
+	report(eve.Token, gin.H{"target_type": "post", "target_id": lamp.ID, "reason": models.ReportOther, "note": "Asks for a wire transfer"}, http.StatusCreated)
+	if visiblePosts() != 0 {
+		t.Fatal("hidden post still listed")
+	}
+	if w := env.do("GET", "/posts/"+lamp.ID, josie.Token, nil); w.Code != http.StatusNotFound {
+		t.Fatalf("hidden post for others: got %d, want 404", w.Code)
+	}
+	var own models.Post
+	decode(t, env.do("GET", "/posts/"+lamp.ID, joe.Token, nil), &own)
+	if !own.Hidden {
+		t.Fatal("owner can't see the post is hidden")
+	}
+
+	if w := env.do("GET", "/admin/reports", josie.Token, nil); w.Code != http.StatusForbidden {
+		t.Fatalf("queue for non-moderator: got %d, want 403", w.Code)
+	}
+	var queue []models.Report
+	decode(t, env.do("GET", "/admin/reports", mod.Token, nil), &queue)
+	if len(queue) != 2 || queue[0].ID != first.ID || queue[0].ReporterName != "Josie Bruin" || queue[1].Note != "Asks for a wire transfer" {
+		t.Fatalf("queue: got %+v", queue)
+	}
+	var review struct {
+		Target      models.Post     `json:"target"`
+		OpenReports []models.Report `json:"open_reports"`
+	}
+	decode(t, env.do("GET", "/admin/reports/"+first.ID, mod.Token, nil), &review)
+	if review.Target.ID != lamp.ID || len(review.OpenReports) != 2 {
+		t.Fatalf("review: got %+v", review)
+	}
+
+	// Dismissing closes both reports and shows the post again
+	if w := env.do("POST", "/admin/reports/"+first.ID+"/dismiss", mod.Token, nil); w.Code != http.StatusOK {
+		t.Fatalf("dismiss: got %d %s", w.Code, w.Body.String())
+	}
+	if w := env.do("POST", "/admin/reports/"+first.ID+"/dismiss", mod.Token, nil); w.Code != http.StatusConflict {
+		t.Fatalf("dismiss twice: got %d, want 409", w.Code)
+	}
+	if visiblePosts() != 1 {
+		t.Fatal("dismissed post not listed again")
+	}
+	decode(t, env.do("GET", "/admin/reports", mod.Token, nil), &queue)
+	if len(queue) != 0 {
+		t.Fatalf("queue after dismissing: got %+v", queue)
+	}
+
+	// Removing a message withholds it from the receiver
+	var conversation models.Conversation
+	decode(t, env.do("GET", "/conversations/"+joe.User.ID, josie.Token, nil), &conversation)
+	env.store.CreateMessage(&models.Message{ID: "abuse", ConversationID: conversation.ID, SenderID: joe.User.ID, ReceiverID: josie.User.ID,
+		Type: models.MessageText, Content: "You'll regret this", CreatedAt: time.Now()})
+	report(eve.Token, gin.H{"target_type": "message", "target_id": "abuse", "reason": models.ReportHarassment}, http.StatusNotFound)
+	abuse := report(josie.Token, gin.H{"target_type": "message", "target_id": "abuse", "reason": models.ReportHarassment}, http.StatusCreated)
+	if abuse.TargetUserID != joe.User.ID {
+		t.Fatalf("message report blames %s, want the sender", abuse.TargetUserID)
+	}
+	if w := env.do("POST", "/admin/reports/"+abuse.ID+"/remove", mod.Token, nil); w.Code != http.StatusOK {
+		t.Fatalf("remove message: got %d %s", w.Code, w.Body.String())
+	}
+	var messages []models.Message
+	decode(t, env.do("GET", "/messages/"+conversation.ID, josie.Token, nil), &messages)
+	if len(messages) != 1 || !messages[0].Hidden || messages[0].Content != "" {
+		t.Fatalf("receiver's messages: got %+v", messages)
+	}
+
+	// Suspending signs the user out and hides their profile and posts
+	account := report(josie.Token, gin.H{"target_type": "user", "target_id": joe.User.ID, "reason": models.ReportScam}, http.StatusCreated)
+	if w := env.do("POST", "/admin/reports/"+account.ID+"/remove", mod.Token, nil); w.Code != http.StatusBadRequest {
+		t.Fatalf("remove user: got %d, want 400", w.Code)
+	}
+	if w := env.do("POST", "/admin/reports/"+account.ID+"/suspend", mod.Token, nil); w.Code != http.StatusOK {
+		t.Fatalf("suspend: got %d %s", w.Code, w.Body.String())
+	}
+	if w := env.do("GET", "/auth/me", joe.Token, nil); w.Code != http.StatusUnauthorized {
+		t.Fatalf("suspended user's token: got %d, want 401", w.Code)
+	}
+	if w := env.do("POST", "/auth/login", "", gin.H{"email": "joe@ucla.edu", "password": "secret1"}); w.Code != http.StatusForbidden {
+		t.Fatalf("suspended login: got %d, want 403", w.Code)
+	}
+	if w := env.do("GET", "/users/"+joe.User.ID, josie.Token, nil); w.Code != http.StatusNotFound {
+		t.Fatalf("suspended profile: got %d, want 404", w.Code)
+	}
+	if visiblePosts() != 0 {
+		t.Fatal("suspended user's post still listed")
+	}
+}
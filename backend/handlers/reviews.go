@@ -0,0 +1,121 @@
+package handlers
+
+import (
+	"bruinmarket-backend/models"
+	"bruinmarket-backend/store"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// Longest review comment accepted
+const maxReviewLength = 1000
+
+type ReviewHandler struct {
+	reviews      store.ReviewStore
+	posts        store.PostStore
+	transactions store.TransactionStore
+}
+
+func NewReviewHandler(reviews store.ReviewStore, posts store.PostStore, transactions store.TransactionStore) *ReviewHandler {
+	return &ReviewHandler{
+		reviews:      reviews,
+		posts:        posts,
+		transactions: transactions,
+	}
+}
+
+// CreateReview lets the seller or buyer in a confirmed sale of the post rate
+// the other side once with {"rating": 1-5, "comment": ...}
+func (h *ReviewHandler) CreateReview(c *gin.Context) {
+	var input struct {
+		Rating  int    `json:"rating" binding:"required"`
+		Comment string `json:"comment"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil || input.Rating < 1 || input.Rating > 5 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "rating must be between 1 and 5"})
+		return
+	}
+	input.Comment = strings.TrimSpace(input.Comment)
+	if len(input.Comment) > maxReviewLength {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("comment can be at most %d characters", maxReviewLength)})
+		return
+	}
+
+	post, err := h.posts.GetPost(c.Param("id"))
+	if err == store.ErrNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": "post not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database error"})
+		return
+	}
+
+	userID := c.GetString("user_id")
+	if post.State != models.PostSold {
+		c.JSON(http.StatusConflict, gin.H{"error": "only sold posts can be reviewed"})
+		return
+	}
+	sale, err := h.transactions.FindSale(post.ID, userID)
+	if err == store.ErrNotFound {
+		c.JSON(http.StatusForbidden, gin.H{"error": "only the buyer and seller in a confirmed sale can review it"})
+		return
+	}
+	if err != nil {
+		log.Printf("Error fetching sale: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database error"})
+		return
+	}
+	revieweeID := sale.BuyerID
+	if userID == sale.BuyerID {
+		revieweeID = sale.SellerID
+	}
+
+	review := models.Review{
+		ID:         uuid.New().String(),
+		PostID:     post.ID,
+		PostTitle:  post.Title,
+		ReviewerID: userID,
+		RevieweeID: revieweeID,
+		Rating:     input.Rating,
+		Comment:    input.Comment,
+		CreatedAt:  time.Now(),
+	}
+	err = h.reviews.CreateReview(&review)
+	if err == store.ErrConflict {
+		c.JSON(http.StatusConflict, gin.H{"error": "you have already reviewed this sale"})
+		return
+	}
+	if err != nil {
+		log.Printf("Error creating review: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to save review"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, review)
+}
+
+// GetUserReviews lists the reviews a user has received with their reputation
+func (h *ReviewHandler) GetUserReviews(c *gin.Context) {
+	userID := c.Param("user_id")
+	reviews, err := h.reviews.ListReviewsForUser(userID)
+	if err != nil {
+		log.Printf("Error fetching reviews: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch reviews"})
+		return
+	}
+	reputation, err := h.reviews.GetReputation(userID)
+	if err != nil {
+		log.Printf("Error fetching reputation: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch reviews"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"reviews": reviews, "reputation": reputation})
+}
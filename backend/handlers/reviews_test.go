@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"net/http"
+	"testing"
+
+	"bruinmarket-backend/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestReviewsAfterSale(t *testing.T) {
+	env := newTestEnv(t)
+	joe := env.signUp(t, "joe@ucla.edu", "Joe Bruin")
+	josie := env.signUp(t, "josie@ucla.edu", "Josie Bruin")
+	eve := env.signUp(t, "eve@ucla.edu", "Eve Trojan")
+
+	lamp := env.createPost(t, joe.Token, gin.H{"title": "Desk lamp", "description": "Bright", "price": 15, "category": "Furniture", "type": "selling"})
+	sell := gin.H{"state": models.PostSold, "buyer_id": josie.User.ID}
+
+	// The buyer has to be someone the seller has talked to
+	if w := env.do("PATCH", "/posts/"+lamp.ID+"/state", joe.Token, sell); w.Code != http.StatusBadRequest {
+		t.Fatalf("sell to a stranger: got %d, want 400", w.Code)
+	}
+	if w := env.do("GET", "/conversations/"+joe.User.ID, josie.Token, nil); w.Code != http.StatusOK {
+		t.Fatalf("start conversation: got %d", w.Code)
+	}
+	if w := env.do("POST", "/posts/"+lamp.ID+"/reviews", josie.Token, gin.H{"rating": 5}); w.Code != http.StatusConflict {
+		t.Fatalf("review before the sale: got %d, want 409", w.Code)
+	}
+	if w := env.do("PATCH", "/posts/"+lamp.ID+"/state", joe.Token, sell); w.Code != http.StatusOK {
+		t.Fatalf("sell: got %d %s", w.Code, w.Body.String())
+	}
+
+	review := func(token string, body gin.H, want int) {
+		t.Helper()
+		if w := env.do("POST", "/posts/"+lamp.ID+"/reviews", token, body); w.Code != want {
+			t.Fatalf("review %v: got %d %s, want %d", body, w.Code, w.Body.String(), want)
+		}
+	}
+
+	// Reviews wait for the buyer to confirm the sale
+	review(josie.Token, gin.H{"rating": 5}, http.StatusForbidden)
+	sale := env.me(t, josie.Token).Purchases[0]
+	if w := env.do("POST", "/transactions/"+sale.ID+"/confirm", josie.Token, nil); w.Code != http.StatusOK {
+		t.Fatalf("confirm sale: got %d %s", w.Code, w.Body.String())
+	}
+	review(eve.Token, gin.H{"rating": 1}, http.StatusForbidden)
+	review(josie.Token, gin.H{"rating": 6}, http.StatusBadRequest)
+	review(josie.Token, gin.H{"rating": 5, "comment": "Easy pickup"}, http.StatusCreated)
+	review(josie.Token, gin.H{"rating": 4}, http.StatusConflict)
+	review(joe.Token, gin.H{"rating": 4}, http.StatusCreated)
+
+	var post models.Post
+	decode(t, env.do("GET", "/posts/"+lamp.ID, "", nil), &post)
+	if post.SellerReputation != 5 || post.SellerReviewCount != 1 {
+		t.Fatalf("post seller reputation %v from %d reviews, want 5 from 1", post.SellerReputation, post.SellerReviewCount)
+	}
+
+	var profile struct {
+		Reputation models.Reputation `json:"reputation"`
+	}
+	decode(t, env.do("GET", "/users/"+josie.User.ID, eve.Token, nil), &profile)
+	if profile.Reputation.Score != 4 || profile.Reputation.ReviewCount != 1 {
+		t.Fatalf("buyer profile reputation: got %+v", profile.Reputation)
+	}
+
+	var reviews struct {
+		Reviews []models.Review `json:"reviews"`
+	}
+	decode(t, env.do("GET", "/users/"+joe.User.ID+"/reviews", eve.Token, nil), &reviews)
+	if len(reviews.Reviews) != 1 || reviews.Reviews[0].ReviewerName != "Josie Bruin" || reviews.Reviews[0].Comment != "Easy pickup" {
+		t.Fatalf("seller reviews: got %+v", reviews.Reviews)
+	}
+}
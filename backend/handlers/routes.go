@@ -0,0 +1,133 @@
+package handlers
+
+import (
+	"bruinmarket-backend/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Handlers bundles everything RegisterRoutes mounts
+type Handlers struct {
+	Auth          *AuthHandler
+	Posts         *PostHandler
+	Users         *UserHandler
+	Media         *MediaHandler
+	Chat          *ChatHandler
+	Search        *SearchHandler
+	SavedSearches *SavedSearchHandler
+	Notifications *NotificationHandler
+	Favorites     *FavoriteHandler
+	Offers        *OfferHandler
+	Reviews       *ReviewHandler
+	Transactions  *TransactionHandler
+	Reports       *ReportHandler
+	Admin         *AdminHandler
+	Categories    *CategoryHandler
+}
+
+// RegisterRoutes mounts the API under /api
+func RegisterRoutes(r *gin.Engine, h *Handlers) {
+	api := r.Group("/api")
+	{
+		// Public routes
+		api.POST("/auth/register", h.Auth.Register)
+		api.POST("/auth/login", h.Auth.Login)
+		api.GET("/auth/verify-email", h.Auth.VerifyEmail)
+		api.POST("/auth/resend-verification", h.Auth.ResendVerification)
+		api.POST("/auth/forgot-password", h.Auth.ForgotPassword)
+		api.POST("/auth/reset-password", h.Auth.ResetPassword)
+		api.POST("/auth/refresh", h.Auth.Refresh)
+		api.GET("/posts", h.Posts.GetPosts)
+		api.GET("/posts/:id", h.Auth.OptionalAuthMiddleware(), h.Posts.GetPost)
+		api.GET("/search/suggest", h.Search.Suggest)
+		api.GET("/categories", h.Categories.GetCategories)
+
+		// WebSocket route - handles auth internally
+		api.GET("/ws", h.Chat.WebSocket)
+
+		// Protected routes
+		protected := api.Group("/")
+		protected.Use(h.Auth.AuthMiddleware())
+		{
+			protected.GET("/auth/me", h.Auth.Me)
+			protected.POST("/auth/logout", h.Auth.Logout)
+			protected.GET("/auth/sessions", h.Auth.ListSessions)
+			protected.DELETE("/auth/sessions/:id", h.Auth.RevokeSession)
+			protected.GET("/auth/my-posts", h.Posts.GetMyPosts)
+			protected.GET("/users/:user_id", h.Users.GetUserProfile)
+			protected.GET("/users/:user_id/reviews", h.Reviews.GetUserReviews)
+			protected.GET("/users/:user_id/presence", h.Chat.GetUserPresence)
+			protected.POST("/posts", h.Posts.CreatePost)
+			protected.DELETE("/posts/:id", h.Posts.DeletePost)
+			protected.PUT("/posts/:id", h.Posts.UpdatePost)
+			protected.PATCH("/posts/:id/sold", h.Posts.MarkPostAsSold)
+			protected.PATCH("/posts/:id/reserved", h.Posts.MarkPostAsReserved)
+			protected.PATCH("/posts/:id/state", h.Posts.UpdatePostState)
+			protected.GET("/posts/:id/history", h.Posts.GetPostHistory)
+			protected.POST("/posts/:id/renew", h.Posts.RenewPost)
+			protected.POST("/posts/:id/bump", h.Posts.BumpPost)
+			protected.PUT("/posts/:id/schedule", h.Posts.SchedulePost)
+			protected.POST("/posts/:id/reviews", h.Reviews.CreateReview)
+			protected.GET("/posts/:id/buyers", h.Transactions.GetBuyerCandidates)
+			protected.POST("/upload", h.Media.UploadMedia)
+			protected.POST("/upload-profile-picture", h.Media.UploadProfilePicture)
+			protected.PATCH("/auth/year", h.Auth.UpdateYear)
+
+			protected.GET("/saved-searches", h.SavedSearches.GetSavedSearches)
+			protected.POST("/saved-searches", h.SavedSearches.CreateSavedSearch)
+			protected.DELETE("/saved-searches/:id", h.SavedSearches.DeleteSavedSearch)
+			protected.GET("/notifications", h.Notifications.GetNotifications)
+			protected.PATCH("/notifications/:id/read", h.Notifications.MarkNotificationRead)
+			protected.POST("/notifications/read-all", h.Notifications.MarkAllNotificationsRead)
+			protected.GET("/favorites", h.Favorites.GetFavorites)
+			protected.POST("/favorites/:post_id", h.Favorites.AddFavorite)
+			protected.DELETE("/favorites/:post_id", h.Favorites.RemoveFavorite)
+
+			// Offer routes
+			protected.POST("/posts/:id/offers", h.Offers.CreateOffer)
+			protected.GET("/posts/:id/offers", h.Offers.GetPostOffers)
+			protected.GET("/offers", h.Offers.GetMyOffers)
+			protected.POST("/offers/:id/accept", h.Offers.AcceptOffer)
+			protected.POST("/offers/:id/decline", h.Offers.DeclineOffer)
+			protected.POST("/offers/:id/counter", h.Offers.CounterOffer)
+			protected.POST("/offers/:id/withdraw", h.Offers.WithdrawOffer)
+
+			// Sale routes
+			protected.GET("/transactions", h.Transactions.GetTransactions)
+			protected.POST("/transactions/:id/confirm", h.Transactions.ConfirmTransaction)
+			protected.POST("/transactions/:id/decline", h.Transactions.DeclineTransaction)
+
+			// Report routes
+			protected.POST("/reports", h.Reports.CreateReport)
+
+			// Chat routes
+			protected.GET("/conversations", h.Chat.GetConversations)
+			protected.GET("/conversations/:user_id", h.Chat.GetOrCreateConversation)
+			protected.GET("/messages/:conversation_id", h.Chat.GetMessages)
+		}
+
+		// Staff routes. Moderators work the report queue; admins also
+		// manage users and categories.
+		admin := api.Group("/admin")
+		admin.Use(h.Auth.AuthMiddleware(), h.Auth.RequireRole(models.RoleModerator))
+		{
+			admin.GET("/reports", h.Reports.GetReports)
+			admin.GET("/reports/:id", h.Reports.GetReport)
+			admin.POST("/reports/:id/dismiss", h.Reports.DismissReport)
+			admin.POST("/reports/:id/remove", h.Reports.RemoveReportedContent)
+			admin.POST("/reports/:id/suspend", h.Reports.SuspendReportedUser)
+
+			adminOnly := admin.Group("/")
+			adminOnly.Use(h.Auth.RequireRole(models.RoleAdmin))
+			{
+				adminOnly.GET("/users", h.Admin.GetUsers)
+				adminOnly.PATCH("/users/:user_id/role", h.Admin.SetUserRole)
+				adminOnly.POST("/users/:user_id/suspend", h.Admin.SuspendUser)
+				adminOnly.POST("/users/:user_id/unsuspend", h.Admin.UnsuspendUser)
+				adminOnly.POST("/categories", h.Categories.CreateCategory)
+				adminOnly.PUT("/categories/:name", h.Categories.RenameCategory)
+				adminOnly.DELETE("/categories/:name", h.Categories.DeleteCategory)
+			}
+		}
+	}
+}
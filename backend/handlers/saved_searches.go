@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"bruinmarket-backend/models"
+	"bruinmarket-backend/store"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// Cap on saved searches per user, since each one is checked for every new post
+const maxSavedSearches = 20
+
+type SavedSearchHandler struct {
+	searches store.SavedSearchStore
+}
+
+func NewSavedSearchHandler(searches store.SavedSearchStore) *SavedSearchHandler {
+	return &SavedSearchHandler{searches: searches}
+}
+
+func (h *SavedSearchHandler) CreateSavedSearch(c *gin.Context) {
+	var search models.SavedSearch
+	if err := c.ShouldBindJSON(&search); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	search.Name = strings.TrimSpace(search.Name)
+	search.Search = strings.TrimSpace(search.Search)
+	if search.Name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "name is required"})
+		return
+	}
+	// "all" is what the listing filters send for no filter
+	if search.Category == "all" {
+		search.Category = ""
+	}
+	if search.Type == "all" {
+		search.Type = ""
+	}
+	if search.Type != "" && search.Type != "selling" && search.Type != "buying" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "type must be 'selling' or 'buying'"})
+		return
+	}
+	if (search.MinPrice != nil && *search.MinPrice < 0) || (search.MaxPrice != nil && *search.MaxPrice < 0) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "price cannot be negative"})
+		return
+	}
+	if search.MinPrice != nil && search.MaxPrice != nil && *search.MinPrice > *search.MaxPrice {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "min_price cannot be greater than max_price"})
+		return
+	}
+
+	search.ID = uuid.New().String()
+	search.UserID = c.GetString("user_id")
+	search.CreatedAt = time.Now()
+
+	existing, err := h.searches.ListSavedSearches(search.UserID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch saved searches"})
+		return
+	}
+	if len(existing) >= maxSavedSearches {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "you can save at most 20 searches"})
+		return
+	}
+
+	if err := h.searches.CreateSavedSearch(&search); err != nil {
+		log.Printf("Error creating saved search: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to save search"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, search)
+}
+
+func (h *SavedSearchHandler) GetSavedSearches(c *gin.Context) {
+	searches, err := h.searches.ListSavedSearches(c.GetString("user_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch saved searches"})
+		return
+	}
+
+	c.JSON(http.StatusOK, searches)
+}
+
+func (h *SavedSearchHandler) DeleteSavedSearch(c *gin.Context) {
+	err := h.searches.DeleteSavedSearch(c.Param("id"), c.GetString("user_id"))
+	if err == store.ErrNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": "saved search not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete saved search"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "saved search deleted"})
+}
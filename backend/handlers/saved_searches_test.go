@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"net/http"
+	"testing"
+
+	"bruinmarket-backend/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestSavedSearchAlerts(t *testing.T) {
+	env := newTestEnv(t)
+	joe := env.signUp(t, "joe@ucla.edu", "Joe Bruin")
+	josie := env.signUp(t, "josie@ucla.edu", "Josie Bruin")
+
+	w := env.do("POST", "/saved-searches", josie.Token, gin.H{"name": "Cheap bikes", "search": "bike", "max_price": 100, "email_alerts": true})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("save search: got %d %s", w.Code, w.Body.String())
+	}
+	var saved models.SavedSearch
+	decode(t, w, &saved)
+
+	w = env.do("POST", "/saved-searches", josie.Token, gin.H{"name": "Bad range", "min_price": 50, "max_price": 10})
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("min above max: got %d, want 400", w.Code)
+	}
+
+	// Too expensive, and Josie's own post, shouldn't alert her
+	env.createPost(t, joe.Token, gin.H{"title": "Road bike", "description": "Fast", "price": 400, "category": "Other", "type": "selling"})
+	env.createPost(t, josie.Token, gin.H{"title": "Kids bike", "description": "Small", "price": 30, "category": "Other", "type": "selling"})
+	match := env.createPost(t, joe.Token, gin.H{"title": "Beach cruiser bike", "description": "Rusty but rides", "price": 80, "category": "Other", "type": "selling"})
+
+	// Posts are matched in order, so once this email arrives the earlier ones are done
+	if to := receive(t, env.mailer.alerts); to != "josie@ucla.edu" {
+		t.Fatalf("alert emailed to %s", to)
+	}
+
+	w = env.do("GET", "/notifications", josie.Token, nil)
+	var list notificationList
+	decode(t, w, &list)
+	if len(list.Notifications) != 1 || list.Unread != 1 {
+		t.Fatalf("got %d notifications (%d unread), want 1", len(list.Notifications), list.Unread)
+	}
+	n := list.Notifications[0]
+	if n.PostID != match.ID || n.Type != models.NotificationSavedSearchMatch || n.Read {
+		t.Fatalf("unexpected notification %+v", n)
+	}
+
+	if w := env.do("PATCH", "/notifications/"+n.ID+"/read", joe.Token, nil); w.Code != http.StatusNotFound {
+		t.Fatalf("mark someone else's notification: got %d, want 404", w.Code)
+	}
+	if w := env.do("PATCH", "/notifications/"+n.ID+"/read", josie.Token, nil); w.Code != http.StatusOK {
+		t.Fatalf("mark read: got %d", w.Code)
+	}
+	decode(t, env.do("GET", "/notifications", josie.Token, nil), &list)
+	if list.Unread != 0 || !list.Notifications[0].Read {
+		t.Fatalf("notification still unread: %+v", list)
+	}
+
+	if w := env.do("DELETE", "/saved-searches/"+saved.ID, joe.Token, nil); w.Code != http.StatusNotFound {
+		t.Fatalf("delete someone else's search: got %d, want 404", w.Code)
+	}
+	if w := env.do("DELETE", "/saved-searches/"+saved.ID, josie.Token, nil); w.Code != http.StatusOK {
+		t.Fatalf("delete: got %d", w.Code)
+	}
+	var searches []models.SavedSearch
+	decode(t, env.do("GET", "/saved-searches", josie.Token, nil), &searches)
+	if len(searches) != 0 {
+		t.Fatalf("got %d saved searches after delete", len(searches))
+	}
+}
@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"bruinmarket-backend/models"
+	"bruinmarket-backend/store"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Suggestion limits and caching
+const (
+	maxSuggestions     = 8
+	minSuggestPrefix   = 2
+	suggestCacheTTL    = time.Minute
+	suggestCacheMaxLen = 1000
+)
+
+type suggestCacheEntry struct {
+	suggestions []models.SearchSuggestion
+	expires     time.Time
+}
+
+// suggestCache remembers suggestions per prefix for a short while, since
+// every keystroke in the search box asks for them
+type suggestCache struct {
+	mu      sync.Mutex
+	entries map[string]suggestCacheEntry
+}
+
+func (c *suggestCache) get(prefix string, now time.Time) ([]models.SearchSuggestion, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[prefix]
+	if !ok || now.After(entry.expires) {
+		return nil, false
+	}
+	return entry.suggestions, true
+}
+
+func (c *suggestCache) put(prefix string, suggestions []models.SearchSuggestion, now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(c.entries) >= suggestCacheMaxLen {
+		for key, entry := range c.entries {
+			if now.After(entry.expires) {
+				delete(c.entries, key)
+			}
+		}
+		// Still full of live entries: start over rather than track recency
+		if len(c.entries) >= suggestCacheMaxLen {
+			c.entries = map[string]suggestCacheEntry{}
+		}
+	}
+	c.entries[prefix] = suggestCacheEntry{suggestions: suggestions, expires: now.Add(suggestCacheTTL)}
+}
+
+type SearchHandler struct {
+	posts store.PostStore
+	cache *suggestCache
+}
+
+func NewSearchHandler(posts store.PostStore) *SearchHandler {
+	return &SearchHandler{
+		posts: posts,
+		cache: &suggestCache{entries: map[string]suggestCacheEntry{}},
+	}
+}
+
+// Suggest completes a partial search from listing titles and categories
+func (h *SearchHandler) Suggest(c *gin.Context) {
+	prefix := strings.ToLower(strings.Join(strings.Fields(c.Query("q")), " "))
+	if len([]rune(prefix)) < minSuggestPrefix {
+		c.JSON(http.StatusOK, gin.H{"suggestions": []models.SearchSuggestion{}})
+		return
+	}
+
+	now := time.Now()
+	if suggestions, ok := h.cache.get(prefix, now); ok {
+		c.JSON(http.StatusOK, gin.H{"suggestions": suggestions})
+		return
+	}
+
+	suggestions, err := h.posts.SuggestSearch(prefix, maxSuggestions)
+	if err != nil {
+		log.Printf("Error fetching search suggestions: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch suggestions"})
+		return
+	}
+	h.cache.put(prefix, suggestions, now)
+
+	c.JSON(http.StatusOK, gin.H{"suggestions": suggestions})
+}
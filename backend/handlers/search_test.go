@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+
+	"bruinmarket-backend/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestSearchFallsBackToSimilarTitles(t *testing.T) {
+	env := newTestEnv(t)
+	joe := env.signUp(t, "joe@ucla.edu", "Joe Bruin")
+
+	env.createPost(t, joe.Token, gin.H{"title": "Calculus textbook", "description": "Stewart 8th edition", "price": 40, "category": "Class Supplies", "type": "selling"})
+	env.createPost(t, joe.Token, gin.H{"title": "IKEA dresser", "description": "White, 3 drawers", "price": 50, "category": "Furniture", "type": "selling"})
+
+	w := env.do("GET", "/posts?search="+url.QueryEscape("calculus texbook"), "", nil)
+	var page struct {
+		postPage
+		Fuzzy bool `json:"fuzzy"`
+	}
+	decode(t, w, &page)
+	if !page.Fuzzy || len(page.Posts) != 1 || page.Posts[0].Title != "Calculus textbook" {
+		t.Fatalf("got fuzzy=%v %+v", page.Fuzzy, page.Posts)
+	}
+
+	w = env.do("GET", "/posts?search=dresser", "", nil)
+	decode(t, w, &page)
+	if page.Fuzzy || len(page.Posts) != 1 {
+		t.Fatalf("exact match should not be fuzzy: fuzzy=%v %+v", page.Fuzzy, page.Posts)
+	}
+}
+
+func TestSuggest(t *testing.T) {
+	env := newTestEnv(t)
+	joe := env.signUp(t, "joe@ucla.edu", "Joe Bruin")
+
+	env.createPost(t, joe.Token, gin.H{"title": "Desk lamp", "description": "x", "price": 15, "category": "Decorations", "type": "selling"})
+	env.createPost(t, joe.Token, gin.H{"title": "Standing desk", "description": "x", "price": 90, "category": "Furniture", "type": "selling"})
+	sold := env.createPost(t, joe.Token, gin.H{"title": "Desk chair", "description": "x", "price": 30, "category": "Furniture", "type": "selling"})
+	env.do("PATCH", "/posts/"+sold.ID+"/sold", joe.Token, gin.H{"sold": true})
+
+	suggest := func(q string) []models.SearchSuggestion {
+		t.Helper()
+		w := env.do("GET", "/search/suggest?q="+url.QueryEscape(q), "", nil)
+		if w.Code != http.StatusOK {
+			t.Fatalf("suggest %q: got %d", q, w.Code)
+		}
+		var resp struct {
+			Suggestions []models.SearchSuggestion `json:"suggestions"`
+		}
+		decode(t, w, &resp)
+		return resp.Suggestions
+	}
+
+	got := suggest("des")
+	if len(got) != 2 || got[0].Text != "Desk lamp" || got[1].Text != "Standing desk" {
+		t.Fatalf("suggest des: %+v", got)
+	}
+
+	if got := suggest("furn"); len(got) != 1 || got[0].Kind != "category" {
+		t.Fatalf("suggest furn: %+v", got)
+	}
+
+	if got := suggest("d"); len(got) != 0 {
+		t.Fatalf("single character should not suggest: %+v", got)
+	}
+
+	// Results are cached per prefix
+	env.createPost(t, joe.Token, gin.H{"title": "Desk organizer", "description": "x", "price": 5, "category": "Other", "type": "selling"})
+	if got := suggest("DES "); len(got) != 2 {
+		t.Fatalf("expected cached suggestions, got %+v", got)
+	}
+}
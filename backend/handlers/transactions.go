@@ -0,0 +1,150 @@
+package handlers
+
+import (
+	"bruinmarket-backend/models"
+	"bruinmarket-backend/store"
+	"log"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type TransactionHandler struct {
+	transactions  store.TransactionStore
+	posts         store.PostStore
+	conversations store.ConversationStore
+	offers        store.OfferStore
+}
+
+func NewTransactionHandler(transactions store.TransactionStore, posts store.PostStore, conversations store.ConversationStore, offers store.OfferStore) *TransactionHandler {
+	return &TransactionHandler{
+		transactions:  transactions,
+		posts:         posts,
+		conversations: conversations,
+		offers:        offers,
+	}
+}
+
+// buyerCandidate is someone a seller could record a sale to
+type buyerCandidate struct {
+	UserID            string    `json:"user_id"`
+	Name              string    `json:"name"`
+	ProfilePictureURL string    `json:"profile_picture_url"`
+	MadeOffer         bool      `json:"made_offer"` // Offered on this post
+	LastMessageTime   time.Time `json:"last_message_time"`
+}
+
+// GetBuyerCandidates lists the users the seller has conversations with, the
+// ones who made an offer on the post first, for picking who it sold to
+func (h *TransactionHandler) GetBuyerCandidates(c *gin.Context) {
+	userID := c.GetString("user_id")
+	ownerID, err := h.posts.GetPostOwner(c.Param("id"))
+	if err == store.ErrNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": "post not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database error"})
+		return
+	}
+	if ownerID != userID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "you can only pick the buyer for your own posts"})
+		return
+	}
+
+	conversations, err := h.conversations.ListConversations(userID)
+	if err != nil {
+		log.Printf("Error fetching conversations: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch conversations"})
+		return
+	}
+	offers, err := h.offers.ListOffersForPost(c.Param("id"))
+	if err != nil {
+		log.Printf("Error fetching offers: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch offers"})
+		return
+	}
+	offered := map[string]bool{}
+	for _, offer := range offers {
+		offered[offer.BuyerID] = true
+	}
+
+	candidates := make([]buyerCandidate, 0, len(conversations))
+	for _, conv := range conversations {
+		candidate := buyerCandidate{
+			UserID:            conv.User1ID,
+			Name:              conv.User1Name,
+			ProfilePictureURL: conv.User1PictureURL,
+			LastMessageTime:   conv.LastMessageTime,
+		}
+		if conv.User1ID == userID {
+			candidate.UserID = conv.User2ID
+			candidate.Name = conv.User2Name
+			candidate.ProfilePictureURL = conv.User2PictureURL
+		}
+		candidate.MadeOffer = offered[candidate.UserID]
+		candidates = append(candidates, candidate)
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].MadeOffer && !candidates[j].MadeOffer
+	})
+
+	c.JSON(http.StatusOK, candidates)
+}
+
+// GetTransactions lists the current user's sales and purchases
+func (h *TransactionHandler) GetTransactions(c *gin.Context) {
+	transactions, err := h.transactions.ListTransactionsForUser(c.GetString("user_id"))
+	if err != nil {
+		log.Printf("Error fetching transactions: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch transactions"})
+		return
+	}
+	c.JSON(http.StatusOK, transactions)
+}
+
+// ConfirmTransaction is the buyer confirming they bought the post
+func (h *TransactionHandler) ConfirmTransaction(c *gin.Context) {
+	h.respond(c, models.TransactionConfirmed)
+}
+
+// DeclineTransaction is the buyer saying they didn't buy the post. The post
+// stays sold; the sale just isn't on anyone's record.
+func (h *TransactionHandler) DeclineTransaction(c *gin.Context) {
+	h.respond(c, models.TransactionDeclined)
+}
+
+func (h *TransactionHandler) respond(c *gin.Context, status string) {
+	userID := c.GetString("user_id")
+	transaction, err := h.transactions.GetTransaction(c.Param("id"))
+	if err == store.ErrNotFound || (err == nil && transaction.BuyerID != userID && transaction.SellerID != userID) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "transaction not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database error"})
+		return
+	}
+	if transaction.BuyerID != userID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "only the buyer can confirm or decline a sale"})
+		return
+	}
+
+	now := time.Now()
+	err = h.transactions.RespondToTransaction(transaction.ID, status, now)
+	if err == store.ErrNotFound {
+		c.JSON(http.StatusConflict, gin.H{"error": "sale is no longer waiting for you"})
+		return
+	}
+	if err != nil {
+		log.Printf("Error updating transaction: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update transaction"})
+		return
+	}
+
+	transaction.Status = status
+	transaction.RespondedAt = &now
+	c.JSON(http.StatusOK, transaction)
+}
@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"net/http"
+	"testing"
+
+	"bruinmarket-backend/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+type meResponse struct {
+	models.User
+	Purchases []models.Transaction `json:"purchases"`
+	Sales     []models.Transaction `json:"sales"`
+}
+
+func (env *testEnv) me(t *testing.T, token string) meResponse {
+	t.Helper()
+	w := env.do("GET", "/auth/me", token, nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("me: got %d %s", w.Code, w.Body.String())
+	}
+	var me meResponse
+	decode(t, w, &me)
+	return me
+}
+
+func TestSaleRequiresBuyerConfirmation(t *testing.T) {
+	env := newTestEnv(t)
+	joe := env.signUp(t, "joe@ucla.edu", "Joe Bruin")
+	josie := env.signUp(t, "josie@ucla.edu", "Josie Bruin")
+	eve := env.signUp(t, "eve@ucla.edu", "Eve Trojan")
+
+	lamp := env.createPost(t, joe.Token, gin.H{"title": "Desk lamp", "description": "Bright", "price": 15, "category": "Furniture", "type": "selling"})
+	env.do("GET", "/conversations/"+joe.User.ID, josie.Token, nil)
+	env.offer(t, "POST", "/posts/"+lamp.ID+"/offers", eve.Token, gin.H{"amount": 10}, http.StatusCreated)
+
+	// Anyone who offered on the post comes first, then other conversations
+	var candidates []buyerCandidate
+	decode(t, env.do("GET", "/posts/"+lamp.ID+"/buyers", joe.Token, nil), &candidates)
+	if len(candidates) != 2 || candidates[0].UserID != eve.User.ID || !candidates[0].MadeOffer || candidates[1].UserID != josie.User.ID {
+		t.Fatalf("buyer candidates: got %+v", candidates)
+	}
+	if w := env.do("GET", "/posts/"+lamp.ID+"/buyers", josie.Token, nil); w.Code != http.StatusForbidden {
+		t.Fatalf("candidates for someone else's post: got %d, want 403", w.Code)
+	}
+
+	if w := env.do("PATCH", "/posts/"+lamp.ID+"/sold", joe.Token, gin.H{"sold": true, "buyer_id": josie.User.ID, "final_price": 12}); w.Code != http.StatusOK {
+		t.Fatalf("sell: got %d %s", w.Code, w.Body.String())
+	}
+	notifications := env.waitForNotifications(t, josie.Token, 1)
+	if n := notifications.Notifications[0]; n.Type != models.NotificationSaleConfirmation || n.PostID != lamp.ID {
+		t.Fatalf("buyer notification: got %+v", n)
+	}
+
+	purchases := env.me(t, josie.Token).Purchases
+	if len(purchases) != 1 || purchases[0].FinalPrice != 12 || purchases[0].Status != models.TransactionPending || purchases[0].SellerName != "Joe Bruin" {
+		t.Fatalf("purchases: got %+v", purchases)
+	}
+	if sales := env.me(t, joe.Token).Sales; len(sales) != 1 || sales[0].ID != purchases[0].ID {
+		t.Fatalf("sales: got %+v", sales)
+	}
+
+	confirm := "/transactions/" + purchases[0].ID + "/confirm"
+	if w := env.do("POST", confirm, joe.Token, nil); w.Code != http.StatusForbidden {
+		t.Fatalf("seller confirming: got %d, want 403", w.Code)
+	}
+	if w := env.do("POST", confirm, eve.Token, nil); w.Code != http.StatusNotFound {
+		t.Fatalf("stranger confirming: got %d, want 404", w.Code)
+	}
+	if w := env.do("POST", confirm, josie.Token, nil); w.Code != http.StatusOK {
+		t.Fatalf("confirm: got %d %s", w.Code, w.Body.String())
+	}
+	if w := env.do("POST", confirm, josie.Token, nil); w.Code != http.StatusConflict {
+		t.Fatalf("confirm twice: got %d, want 409", w.Code)
+	}
+
+	// Unmarking a sale the buyer hasn't confirmed yet cancels it
+	chair := env.createPost(t, joe.Token, gin.H{"title": "Desk chair", "description": "Comfy", "price": 40, "category": "Furniture", "type": "selling"})
+	env.do("PATCH", "/posts/"+chair.ID+"/state", joe.Token, gin.H{"state": models.PostSold, "buyer_id": josie.User.ID})
+	if w := env.do("PATCH", "/posts/"+chair.ID+"/sold", joe.Token, gin.H{"sold": false}); w.Code != http.StatusOK {
+		t.Fatalf("unmark sold: got %d", w.Code)
+	}
+	purchases = env.me(t, josie.Token).Purchases
+	if len(purchases) != 2 || purchases[0].PostID != chair.ID || purchases[0].Status != models.TransactionCancelled || purchases[1].Status != models.TransactionConfirmed {
+		t.Fatalf("purchases after unmarking: got %+v", purchases)
+	}
+}
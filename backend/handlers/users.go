@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"bruinmarket-backend/store"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type UserHandler struct {
+	users   store.UserStore
+	posts   store.PostStore
+	media   store.MediaStore
+	reviews store.ReviewStore
+}
+
+func NewUserHandler(users store.UserStore, posts store.PostStore, media store.MediaStore, reviews store.ReviewStore) *UserHandler {
+	return &UserHandler{
+		users:   users,
+		posts:   posts,
+		media:   media,
+		reviews: reviews,
+	}
+}
+
+func (h *UserHandler) GetUserProfile(c *gin.Context) {
+	userID := c.Param("user_id")
+
+	// Get user info
+	user, err := h.users.GetUserByID(userID)
+	if err == store.ErrNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch user"})
+		return
+	}
+	// Hidden and suspended users' profiles are only visible to themselves
+	if (user.Hidden || user.SuspendedAt != nil) && userID != c.GetString("user_id") {
+		c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+		return
+	}
+
+	// Get user's posts
+	filter := store.PostFilter{UserID: userID, ExcludeHidden: userID != c.GetString("user_id")}
+	if !parseStates(c, &filter, publicStates, defaultListStates) || !parsePaging(c, &filter) {
+		return
+	}
+	page, err := h.posts.ListPosts(filter)
+	if err != nil {
+		log.Printf("Error fetching profile posts: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch posts"})
+		return
+	}
+	loadMedia(h.media, page.Posts)
+
+	reputation, err := h.reviews.GetReputation(userID)
+	if err != nil {
+		log.Printf("Error fetching reputation: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch reputation"})
+		return
+	}
+
+	response := postPageJSON(page)
+	response["user"] = user
+	response["reputation"] = reputation
+	c.JSON(http.StatusOK, response)
+}
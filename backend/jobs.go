@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"time"
+)
+
+// Arbitrary key for pg_try_advisory_lock so only one replica runs the
+// periodic jobs. Migrations lock a different key.
+const jobsLockKey int64 = 4276317419
+
+// periodicJobs runs jobs on whichever replica holds the jobs advisory lock.
+// The lock lives on a connection of its own, so if that replica goes away
+// Postgres releases it and another replica takes over on its next tick.
+type periodicJobs struct {
+	db   *sql.DB
+	conn *sql.Conn
+	jobs []func(now time.Time)
+}
+
+// Run calls every job each interval while this replica holds the lock,
+// until the process exits
+func (p *periodicJobs) Run(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for now := range ticker.C {
+		if !p.lead() {
+			continue
+		}
+		for _, job := range p.jobs {
+			job(now)
+		}
+	}
+}
+
+// lead reports whether this replica holds the jobs lock, trying to take it
+// if not
+func (p *periodicJobs) lead() bool {
+	ctx := context.Background()
+	if p.conn != nil {
+		err := p.conn.PingContext(ctx)
+		if err == nil {
+			return true
+		}
+		log.Printf("Lost the background jobs lock: %v", err)
+		p.conn.Close()
+		p.conn = nil
+	}
+
+	conn, err := p.db.Conn(ctx)
+	if err != nil {
+		log.Printf("Error connecting for the background jobs lock: %v", err)
+		return false
+	}
+	var locked bool
+	if err := conn.QueryRowContext(ctx, `SELECT pg_try_advisory_lock($1)`, jobsLockKey).Scan(&locked); err != nil {
+		log.Printf("Error taking the background jobs lock: %v", err)
+	}
+	if !locked {
+		conn.Close()
+		return false
+	}
+	log.Printf("Running background jobs on this instance")
+	p.conn = conn
+	return true
+}
@@ -0,0 +1,81 @@
+package migrations
+
+import (
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestEmbeddedMigrationsLoad(t *testing.T) {
+	m, err := New(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.migrations[0].Version != 1 || m.migrations[0].Name != "baseline" {
+		t.Fatalf("first migration is %04d_%s, want 0001_baseline", m.migrations[0].Version, m.migrations[0].Name)
+	}
+	for i, mig := range m.migrations {
+		if mig.Version != i+1 {
+			t.Fatalf("migration versions have a gap at %d", mig.Version)
+		}
+	}
+}
+
+func TestLoadSortsAndPairsFiles(t *testing.T) {
+	fsys := fstest.MapFS{
+		"0010_b.up.sql":   {Data: []byte("B UP")},
+		"0010_b.down.sql": {Data: []byte("B DOWN")},
+		"0002_a.up.sql":   {Data: []byte("A UP")},
+		"0002_a.down.sql": {Data: []byte("A DOWN")},
+		"README.md":       {Data: []byte("ignored")},
+	}
+
+	migrations, err := Load(fsys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(migrations) != 2 || migrations[0].Version != 2 || migrations[1].Version != 10 {
+		t.Fatalf("unexpected order %+v", migrations)
+	}
+	if migrations[1].Up != "B UP" || migrations[1].Down != "B DOWN" {
+		t.Fatalf("files paired wrong: %+v", migrations[1])
+	}
+}
+
+func TestLoadRejectsBadFiles(t *testing.T) {
+	cases := map[string]fstest.MapFS{
+		"missing down": {
+			"0001_a.up.sql": {Data: []byte("x")},
+		},
+		"name mismatch": {
+			"0001_a.up.sql":   {Data: []byte("x")},
+			"0001_b.down.sql": {Data: []byte("x")},
+		},
+		"bad name": {
+			"create_users.sql": {Data: []byte("x")},
+		},
+	}
+
+	for name, fsys := range cases {
+		if _, err := Load(fsys); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestPendingLeavesNewerMigrationsAlone(t *testing.T) {
+	known := []Migration{{Version: 1, Name: "a"}, {Version: 2, Name: "b"}, {Version: 3, Name: "c"}}
+	// A newer build has already applied 1 and 4, which this build doesn't know
+	applied := map[int]time.Time{1: time.Now(), 4: time.Now()}
+
+	got := pending(known, applied, 3)
+	if len(got) != 2 || got[0].Version != 2 || got[1].Version != 3 {
+		t.Fatalf("pending: got %+v, want versions 2 and 3", got)
+	}
+	if got := pending(known, applied, 2); len(got) != 1 || got[0].Version != 2 {
+		t.Fatalf("pending up to 2: got %+v", got)
+	}
+	if got := pending(known, map[int]time.Time{1: {}, 2: {}, 3: {}, 4: {}}, 3); len(got) != 0 {
+		t.Fatalf("everything applied: got %+v", got)
+	}
+}
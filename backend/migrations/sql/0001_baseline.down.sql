@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS messages;
+DROP TABLE IF EXISTS conversations;
+DROP TABLE IF EXISTS media;
+DROP TABLE IF EXISTS posts;
+DROP TABLE IF EXISTS users;
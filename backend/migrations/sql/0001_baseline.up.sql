@@ -0,0 +1,69 @@
+-- Schema as it stood before versioned migrations. Everything is idempotent so
+-- databases created by the old initDB adopt this version without changes.
+
+CREATE TABLE IF NOT EXISTS users (
+	id VARCHAR(255) PRIMARY KEY,
+	email VARCHAR(255) UNIQUE NOT NULL,
+	name VARCHAR(255) NOT NULL,
+	password VARCHAR(255) NOT NULL,
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS posts (
+	id VARCHAR(255) PRIMARY KEY,
+	user_id VARCHAR(255) REFERENCES users(id) ON DELETE CASCADE,
+	title VARCHAR(255) NOT NULL,
+	description TEXT NOT NULL,
+	price DECIMAL(10,2) NOT NULL,
+	category VARCHAR(100) NOT NULL,
+	type VARCHAR(50) NOT NULL,
+	location VARCHAR(255),
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS media (
+	id VARCHAR(255) PRIMARY KEY,
+	post_id VARCHAR(255) REFERENCES posts(id) ON DELETE CASCADE,
+	url TEXT NOT NULL,
+	type VARCHAR(50) NOT NULL,
+	order_index INTEGER NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS conversations (
+	id VARCHAR(255) PRIMARY KEY,
+	user1_id VARCHAR(255) REFERENCES users(id) ON DELETE CASCADE,
+	user2_id VARCHAR(255) REFERENCES users(id) ON DELETE CASCADE,
+	last_message TEXT,
+	last_message_time TIMESTAMP,
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	UNIQUE(user1_id, user2_id)
+);
+
+CREATE TABLE IF NOT EXISTS messages (
+	id VARCHAR(255) PRIMARY KEY,
+	conversation_id VARCHAR(255) REFERENCES conversations(id) ON DELETE CASCADE,
+	sender_id VARCHAR(255) REFERENCES users(id) ON DELETE CASCADE,
+	receiver_id VARCHAR(255) REFERENCES users(id) ON DELETE CASCADE,
+	content TEXT NOT NULL,
+	read BOOLEAN DEFAULT FALSE,
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+ALTER TABLE posts ADD COLUMN IF NOT EXISTS condition VARCHAR(50);
+ALTER TABLE posts ADD COLUMN IF NOT EXISTS sold BOOLEAN DEFAULT FALSE;
+ALTER TABLE posts ADD COLUMN IF NOT EXISTS location VARCHAR(255);
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS profile_picture_url VARCHAR(500);
+ALTER TABLE users ADD COLUMN IF NOT EXISTS year VARCHAR(50);
+ALTER TABLE users ADD COLUMN IF NOT EXISTS email_verified BOOLEAN DEFAULT FALSE;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS verification_token VARCHAR(255);
+ALTER TABLE users ADD COLUMN IF NOT EXISTS verification_token_expires TIMESTAMP;
+
+CREATE INDEX IF NOT EXISTS idx_posts_user_id ON posts(user_id);
+CREATE INDEX IF NOT EXISTS idx_posts_category ON posts(category);
+CREATE INDEX IF NOT EXISTS idx_posts_type ON posts(type);
+CREATE INDEX IF NOT EXISTS idx_posts_created_at ON posts(created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_conversations_users ON conversations(user1_id, user2_id);
+CREATE INDEX IF NOT EXISTS idx_messages_conversation ON messages(conversation_id);
+CREATE INDEX IF NOT EXISTS idx_messages_created_at ON messages(created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_verification_token ON users(verification_token);
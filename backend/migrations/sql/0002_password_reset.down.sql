@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_password_reset_token_hash;
+
+ALTER TABLE users DROP COLUMN IF EXISTS token_version;
+ALTER TABLE users DROP COLUMN IF EXISTS password_reset_expires;
+ALTER TABLE users DROP COLUMN IF EXISTS password_reset_token_hash;
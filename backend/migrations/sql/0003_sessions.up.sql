@@ -0,0 +1,17 @@
+-- Login sessions backing refresh tokens
+CREATE TABLE IF NOT EXISTS sessions (
+	id VARCHAR(255) PRIMARY KEY,
+	user_id VARCHAR(255) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	refresh_token_hash VARCHAR(64) UNIQUE NOT NULL,
+	previous_refresh_token_hash VARCHAR(64),
+	device VARCHAR(255),
+	ip_address VARCHAR(64),
+	user_agent TEXT,
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	last_seen_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	expires_at TIMESTAMP NOT NULL,
+	revoked_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_sessions_user_id ON sessions(user_id);
+CREATE INDEX IF NOT EXISTS idx_sessions_previous_refresh_token_hash ON sessions(previous_refresh_token_hash);
@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS idx_posts_expires_at_id;
+DROP INDEX IF EXISTS idx_posts_view_count_id;
+DROP INDEX IF EXISTS idx_posts_price_id;
+DROP INDEX IF EXISTS idx_posts_created_at_id;
+
+ALTER TABLE posts DROP COLUMN IF EXISTS expires_at;
+ALTER TABLE posts DROP COLUMN IF EXISTS view_count;
@@ -0,0 +1,13 @@
+ALTER TABLE posts ADD COLUMN IF NOT EXISTS view_count INTEGER NOT NULL DEFAULT 0;
+
+-- Listings run for 30 days from creation. Existing ones get 30 days from
+-- now, so the expiry job doesn't retire every older listing on its first run.
+ALTER TABLE posts ADD COLUMN IF NOT EXISTS expires_at TIMESTAMP;
+UPDATE posts SET expires_at = CURRENT_TIMESTAMP + INTERVAL '30 days' WHERE expires_at IS NULL;
+ALTER TABLE posts ALTER COLUMN expires_at SET NOT NULL;
+
+-- Keyset pagination orders by the sort column with id as the tie-breaker
+CREATE INDEX IF NOT EXISTS idx_posts_created_at_id ON posts(created_at DESC, id DESC);
+CREATE INDEX IF NOT EXISTS idx_posts_price_id ON posts(price, id);
+CREATE INDEX IF NOT EXISTS idx_posts_view_count_id ON posts(view_count DESC, id DESC);
+CREATE INDEX IF NOT EXISTS idx_posts_expires_at_id ON posts(expires_at, id);
@@ -0,0 +1 @@
+DROP INDEX IF EXISTS idx_media_post_id;
@@ -0,0 +1,2 @@
+-- Media is always loaded by post, in display order
+CREATE INDEX IF NOT EXISTS idx_media_post_id ON media(post_id, order_index);
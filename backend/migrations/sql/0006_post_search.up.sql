@@ -0,0 +1,10 @@
+-- Full-text search over listings. Title ranks above description; category and
+-- condition match but weigh least.
+ALTER TABLE posts ADD COLUMN IF NOT EXISTS search_vector tsvector GENERATED ALWAYS AS (
+	setweight(to_tsvector('english', COALESCE(title, '')), 'A') ||
+	setweight(to_tsvector('english', COALESCE(description, '')), 'B') ||
+	setweight(to_tsvector('english', COALESCE(category, '')), 'C') ||
+	setweight(to_tsvector('english', COALESCE(condition, '')), 'C')
+) STORED;
+
+CREATE INDEX IF NOT EXISTS idx_posts_search_vector ON posts USING GIN (search_vector);
@@ -0,0 +1,3 @@
+-- The pg_trgm extension is left installed; other database objects may use it
+DROP INDEX IF EXISTS idx_posts_category_trgm;
+DROP INDEX IF EXISTS idx_posts_title_trgm;
@@ -0,0 +1,5 @@
+-- Typo-tolerant matching and autocomplete over titles and categories
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+CREATE INDEX IF NOT EXISTS idx_posts_title_trgm ON posts USING GIN (title gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_posts_category_trgm ON posts USING GIN (category gin_trgm_ops);
@@ -0,0 +1,28 @@
+CREATE TABLE IF NOT EXISTS saved_searches (
+	id VARCHAR(255) PRIMARY KEY,
+	user_id VARCHAR(255) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	name VARCHAR(255) NOT NULL,
+	category VARCHAR(100),
+	type VARCHAR(50),
+	search TEXT,
+	condition VARCHAR(50),
+	min_price DECIMAL(10,2),
+	max_price DECIMAL(10,2),
+	email_alerts BOOLEAN NOT NULL DEFAULT FALSE,
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_saved_searches_user_id ON saved_searches(user_id);
+
+-- In-app notifications, e.g. a new listing matching a saved search
+CREATE TABLE IF NOT EXISTS notifications (
+	id VARCHAR(255) PRIMARY KEY,
+	user_id VARCHAR(255) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	type VARCHAR(50) NOT NULL,
+	message TEXT NOT NULL,
+	post_id VARCHAR(255) REFERENCES posts(id) ON DELETE CASCADE,
+	read BOOLEAN NOT NULL DEFAULT FALSE,
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_notifications_user_id ON notifications(user_id, created_at DESC);
@@ -0,0 +1,9 @@
+-- Users' watchlists; favorite counts are computed from this table
+CREATE TABLE IF NOT EXISTS favorites (
+	user_id VARCHAR(255) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	post_id VARCHAR(255) NOT NULL REFERENCES posts(id) ON DELETE CASCADE,
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	PRIMARY KEY (user_id, post_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_favorites_post_id ON favorites(post_id);
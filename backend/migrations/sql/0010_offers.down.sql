@@ -0,0 +1,4 @@
+ALTER TABLE messages DROP COLUMN IF EXISTS offer_id;
+ALTER TABLE messages DROP COLUMN IF EXISTS type;
+DROP TABLE IF EXISTS offers;
+ALTER TABLE posts DROP COLUMN IF EXISTS reserved;
@@ -0,0 +1,28 @@
+-- A post can be held for a buyer once an offer is accepted
+ALTER TABLE posts ADD COLUMN IF NOT EXISTS reserved BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- Price offers on a post. A counter-offer is a new row whose parent_id is
+-- the offer it answers; proposed_by says which side made it.
+CREATE TABLE IF NOT EXISTS offers (
+	id VARCHAR(255) PRIMARY KEY,
+	post_id VARCHAR(255) NOT NULL REFERENCES posts(id) ON DELETE CASCADE,
+	buyer_id VARCHAR(255) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	seller_id VARCHAR(255) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	proposed_by VARCHAR(255) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	parent_id VARCHAR(255) REFERENCES offers(id) ON DELETE SET NULL,
+	amount DECIMAL(10,2) NOT NULL,
+	message TEXT,
+	status VARCHAR(20) NOT NULL DEFAULT 'pending',
+	expires_at TIMESTAMP NOT NULL,
+	responded_at TIMESTAMP,
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_offers_post_id ON offers(post_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_offers_buyer_id ON offers(buyer_id);
+CREATE INDEX IF NOT EXISTS idx_offers_seller_id ON offers(seller_id);
+CREATE INDEX IF NOT EXISTS idx_offers_pending_expiry ON offers(expires_at) WHERE status = 'pending';
+
+-- Offer events show up in the conversation as typed system messages
+ALTER TABLE messages ADD COLUMN IF NOT EXISTS type VARCHAR(50) NOT NULL DEFAULT 'message';
+ALTER TABLE messages ADD COLUMN IF NOT EXISTS offer_id VARCHAR(255) REFERENCES offers(id) ON DELETE SET NULL;
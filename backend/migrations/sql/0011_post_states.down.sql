@@ -0,0 +1,8 @@
+DROP TABLE IF EXISTS post_state_history;
+
+ALTER TABLE posts ADD COLUMN IF NOT EXISTS sold BOOLEAN DEFAULT FALSE;
+ALTER TABLE posts ADD COLUMN IF NOT EXISTS reserved BOOLEAN NOT NULL DEFAULT FALSE;
+UPDATE posts SET sold = (state = 'sold'), reserved = (state = 'reserved');
+
+DROP INDEX IF EXISTS idx_posts_state;
+ALTER TABLE posts DROP COLUMN IF EXISTS state;
@@ -0,0 +1,25 @@
+-- Replace the sold/reserved flags with an explicit listing state
+ALTER TABLE posts ADD COLUMN IF NOT EXISTS state VARCHAR(20) NOT NULL DEFAULT 'active';
+
+UPDATE posts SET state = CASE
+	WHEN COALESCE(sold, false) THEN 'sold'
+	WHEN reserved THEN 'reserved'
+	ELSE 'active'
+END;
+
+ALTER TABLE posts DROP COLUMN IF EXISTS sold;
+ALTER TABLE posts DROP COLUMN IF EXISTS reserved;
+
+CREATE INDEX IF NOT EXISTS idx_posts_state ON posts(state);
+
+-- Who moved a post between states, and when
+CREATE TABLE IF NOT EXISTS post_state_history (
+	id VARCHAR(255) PRIMARY KEY,
+	post_id VARCHAR(255) NOT NULL REFERENCES posts(id) ON DELETE CASCADE,
+	from_state VARCHAR(20) NOT NULL,
+	to_state VARCHAR(20) NOT NULL,
+	changed_by VARCHAR(255) REFERENCES users(id) ON DELETE SET NULL,
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_post_state_history_post_id ON post_state_history(post_id, created_at);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_posts_active_expiry;
+DROP INDEX IF EXISTS idx_posts_bumped_at_id;
+ALTER TABLE posts DROP COLUMN IF EXISTS bumped_at;
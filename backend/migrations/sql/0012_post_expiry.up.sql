@@ -0,0 +1,9 @@
+-- Bumping moves a listing back to the top of "newest" without reposting it
+ALTER TABLE posts ADD COLUMN IF NOT EXISTS bumped_at TIMESTAMP;
+UPDATE posts SET bumped_at = COALESCE(created_at, CURRENT_TIMESTAMP) WHERE bumped_at IS NULL;
+ALTER TABLE posts ALTER COLUMN bumped_at SET NOT NULL;
+
+CREATE INDEX IF NOT EXISTS idx_posts_bumped_at_id ON posts(bumped_at DESC, id DESC);
+
+-- The expiry job looks for active posts past expires_at
+CREATE INDEX IF NOT EXISTS idx_posts_active_expiry ON posts(expires_at) WHERE state = 'active';
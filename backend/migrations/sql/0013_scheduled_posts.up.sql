@@ -0,0 +1,5 @@
+-- Drafts can be scheduled to go live at a set time
+ALTER TABLE posts ADD COLUMN IF NOT EXISTS publish_at TIMESTAMP;
+
+-- The scheduler looks for drafts whose publish time has come
+CREATE INDEX IF NOT EXISTS idx_posts_scheduled ON posts(publish_at) WHERE state = 'draft' AND publish_at IS NOT NULL;
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS reviews;
+ALTER TABLE posts DROP COLUMN IF EXISTS buyer_id;
@@ -0,0 +1,18 @@
+-- Who a sold post went to; only kept while the post is sold
+ALTER TABLE posts ADD COLUMN IF NOT EXISTS buyer_id VARCHAR(255) REFERENCES users(id) ON DELETE SET NULL;
+
+-- The seller and buyer of a sold post can each rate the other once. Reviews
+-- outlive the post so deleting it doesn't wipe a bad review.
+CREATE TABLE IF NOT EXISTS reviews (
+	id VARCHAR(255) PRIMARY KEY,
+	post_id VARCHAR(255) REFERENCES posts(id) ON DELETE SET NULL,
+	post_title VARCHAR(255) NOT NULL,
+	reviewer_id VARCHAR(255) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	reviewee_id VARCHAR(255) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	rating SMALLINT NOT NULL CHECK (rating BETWEEN 1 AND 5),
+	comment TEXT,
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	UNIQUE (post_id, reviewer_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_reviews_reviewee_id ON reviews(reviewee_id, created_at DESC);
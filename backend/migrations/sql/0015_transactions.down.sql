@@ -0,0 +1,10 @@
+ALTER TABLE posts ADD COLUMN IF NOT EXISTS buyer_id VARCHAR(255) REFERENCES users(id) ON DELETE SET NULL;
+
+UPDATE posts p SET buyer_id = (
+	SELECT t.buyer_id FROM transactions t
+	WHERE t.post_id = p.id AND t.status IN ('pending', 'confirmed')
+	ORDER BY t.created_at DESC LIMIT 1
+)
+WHERE p.state = 'sold';
+
+DROP TABLE IF EXISTS transactions;
@@ -0,0 +1,27 @@
+-- Sales recorded against a buyer, who confirms or declines them. These
+-- replace posts.buyer_id and outlive the post as purchase history.
+CREATE TABLE IF NOT EXISTS transactions (
+	id VARCHAR(255) PRIMARY KEY,
+	post_id VARCHAR(255) REFERENCES posts(id) ON DELETE SET NULL,
+	post_title VARCHAR(255) NOT NULL,
+	seller_id VARCHAR(255) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	buyer_id VARCHAR(255) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	final_price DECIMAL(10,2) NOT NULL,
+	status VARCHAR(20) NOT NULL DEFAULT 'pending',
+	responded_at TIMESTAMP,
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_transactions_post_id ON transactions(post_id);
+CREATE INDEX IF NOT EXISTS idx_transactions_seller_id ON transactions(seller_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_transactions_buyer_id ON transactions(buyer_id, created_at DESC);
+
+-- Buyers recorded before confirmation existed count as confirmed
+INSERT INTO transactions (id, post_id, post_title, seller_id, buyer_id, final_price, status, responded_at, created_at)
+SELECT 'sale-' || p.id, p.id, p.title, p.user_id, p.buyer_id, p.price, 'confirmed', CURRENT_TIMESTAMP,
+	COALESCE((SELECT MAX(h.created_at) FROM post_state_history h WHERE h.post_id = p.id AND h.to_state = 'sold'), CURRENT_TIMESTAMP)
+FROM posts p
+WHERE p.buyer_id IS NOT NULL
+ON CONFLICT (id) DO NOTHING;
+
+ALTER TABLE posts DROP COLUMN IF EXISTS buyer_id;
@@ -0,0 +1,5 @@
+ALTER TABLE users DROP COLUMN IF EXISTS suspended_at;
+ALTER TABLE users DROP COLUMN IF EXISTS hidden;
+ALTER TABLE messages DROP COLUMN IF EXISTS hidden;
+ALTER TABLE posts DROP COLUMN IF EXISTS hidden;
+DROP TABLE IF EXISTS reports;
@@ -0,0 +1,24 @@
+-- Users flag posts, users and messages; enough distinct reporters hide the
+-- target until a moderator reviews it
+CREATE TABLE IF NOT EXISTS reports (
+	id VARCHAR(255) PRIMARY KEY,
+	reporter_id VARCHAR(255) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	target_type VARCHAR(20) NOT NULL,
+	target_id VARCHAR(255) NOT NULL,
+	target_user_id VARCHAR(255) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	reason VARCHAR(50) NOT NULL,
+	note TEXT,
+	status VARCHAR(20) NOT NULL DEFAULT 'open',
+	reviewed_by VARCHAR(255) REFERENCES users(id) ON DELETE SET NULL,
+	reviewed_at TIMESTAMP,
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- One open report per reporter and target; they can report again once it is closed
+CREATE UNIQUE INDEX IF NOT EXISTS idx_reports_open_reporter ON reports(target_type, target_id, reporter_id) WHERE status = 'open';
+CREATE INDEX IF NOT EXISTS idx_reports_status ON reports(status, created_at);
+
+ALTER TABLE posts ADD COLUMN IF NOT EXISTS hidden BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE messages ADD COLUMN IF NOT EXISTS hidden BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS hidden BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS suspended_at TIMESTAMP;
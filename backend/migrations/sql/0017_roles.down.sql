@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS categories;
+ALTER TABLE users DROP CONSTRAINT IF EXISTS users_role_check;
+ALTER TABLE users DROP COLUMN IF EXISTS role;
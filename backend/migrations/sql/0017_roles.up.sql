@@ -0,0 +1,17 @@
+-- Staff roles; every existing account starts as a regular user
+ALTER TABLE users ADD COLUMN IF NOT EXISTS role VARCHAR(20) NOT NULL DEFAULT 'user';
+ALTER TABLE users DROP CONSTRAINT IF EXISTS users_role_check;
+ALTER TABLE users ADD CONSTRAINT users_role_check CHECK (role IN ('user', 'moderator', 'admin'));
+
+-- Listing categories, managed by admins
+CREATE TABLE IF NOT EXISTS categories (
+	name VARCHAR(100) PRIMARY KEY,
+	position INT NOT NULL,
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+INSERT INTO categories (name, position) VALUES
+	('Clothing', 1), ('Sports Equipment', 2), ('Shoes', 3), ('Class Supplies', 4),
+	('Electronics', 5), ('Tickets', 6), ('Swipes', 7), ('Rideshare', 8),
+	('Parking Spots', 9), ('Furniture', 10), ('Decorations', 11), ('Other', 12)
+ON CONFLICT (name) DO NOTHING;
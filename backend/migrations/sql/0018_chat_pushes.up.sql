@@ -0,0 +1,10 @@
+-- Chat pushes too big for a NOTIFY payload, read by every backend instance
+-- and cleared out after a few minutes
+CREATE TABLE IF NOT EXISTS chat_pushes (
+	id BIGSERIAL PRIMARY KEY,
+	user_id VARCHAR(255) NOT NULL,
+	payload TEXT NOT NULL,
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_chat_pushes_created_at ON chat_pushes(created_at);
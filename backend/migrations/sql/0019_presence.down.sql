@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS user_connections;
+ALTER TABLE users DROP COLUMN IF EXISTS last_active_at;
+ALTER TABLE users DROP COLUMN IF EXISTS last_seen_at;
@@ -0,0 +1,14 @@
+-- When users were last connected and last did something, for presence
+ALTER TABLE users ADD COLUMN IF NOT EXISTS last_seen_at TIMESTAMP;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS last_active_at TIMESTAMP;
+
+-- Open WebSocket connections on every backend instance. Rows left behind by
+-- an instance that went away stop counting once seen_at goes stale.
+CREATE TABLE IF NOT EXISTS user_connections (
+	id VARCHAR(255) PRIMARY KEY,
+	user_id VARCHAR(255) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	connected_at TIMESTAMP NOT NULL,
+	seen_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_connections_user ON user_connections(user_id, seen_at);
@@ -0,0 +1 @@
+DROP INDEX IF EXISTS idx_offers_one_pending;
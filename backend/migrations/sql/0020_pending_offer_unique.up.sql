@@ -0,0 +1,10 @@
+-- A buyer has at most one pending offer per post. Older duplicates left by
+-- concurrent requests before this index existed are expired first.
+UPDATE offers o SET status = 'expired', responded_at = NOW() 
+WHERE o.status = 'pending' AND EXISTS (
+	SELECT 1 FROM offers n 
+	WHERE n.post_id = o.post_id AND n.buyer_id = o.buyer_id AND n.status = 'pending' 
+		AND (n.created_at > o.created_at OR (n.created_at = o.created_at AND n.id > o.id))
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_offers_one_pending ON offers(post_id, buyer_id) WHERE status = 'pending';
@@ -0,0 +1,13 @@
+package models
+
+// Category is one of the listing categories admins manage
+type Category struct {
+	Name     string `json:"name"`
+	Position int    `json:"position"` // Order in the category picker
+}
+
+// DefaultCategories are the categories a new database starts with
+var DefaultCategories = []string{
+	"Clothing", "Sports Equipment", "Shoes", "Class Supplies", "Electronics", "Tickets",
+	"Swipes", "Rideshare", "Parking Spots", "Furniture", "Decorations", "Other",
+}
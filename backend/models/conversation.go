@@ -0,0 +1,50 @@
+package models
+
+import (
+	"time"
+)
+
+type Conversation struct {
+	ID              string    `json:"id"`
+	User1ID         string    `json:"user1_id"`
+	User2ID         string    `json:"user2_id"`
+	User1Name       string    `json:"user1_name"`
+	User2Name       string    `json:"user2_name"`
+	User1PictureURL string    `json:"user1_picture_url"`
+	User2PictureURL string    `json:"user2_picture_url"`
+	LastMessage     string    `json:"last_message"`
+	LastMessageTime time.Time `json:"last_message_time"`
+	CreatedAt       time.Time `json:"created_at"`
+	User1Presence   *Presence `json:"user1_presence,omitempty"`
+	User2Presence   *Presence `json:"user2_presence,omitempty"`
+}
+
+// HasMember reports whether userID is one of the two participants
+func (c *Conversation) HasMember(userID string) bool {
+	return c.User1ID == userID || c.User2ID == userID
+}
+
+// Message types. Anything other than MessageText is a system message
+// recording an offer event; OfferID links it to the offer.
+const (
+	MessageText           = "message"
+	MessageOfferCreated   = "offer_created"
+	MessageOfferCountered = "offer_countered"
+	MessageOfferAccepted  = "offer_accepted"
+	MessageOfferDeclined  = "offer_declined"
+	MessageOfferWithdrawn = "offer_withdrawn"
+	MessageOfferExpired   = "offer_expired"
+)
+
+type Message struct {
+	ID             string    `json:"id"`
+	ConversationID string    `json:"conversation_id"`
+	SenderID       string    `json:"sender_id"`
+	ReceiverID     string    `json:"receiver_id"`
+	Type           string    `json:"type"`
+	Content        string    `json:"content"`
+	OfferID        string    `json:"offer_id,omitempty"`
+	CreatedAt      time.Time `json:"created_at"`
+	Read           bool      `json:"read"`
+	Hidden         bool      `json:"hidden,omitempty"` // Content is withheld from the receiver
+}
@@ -0,0 +1,24 @@
+package models
+
+import (
+	"time"
+)
+
+// Notification types
+const (
+	NotificationSavedSearchMatch = "saved_search_match"
+	NotificationPriceDrop        = "price_drop"
+	NotificationPostSold         = "post_sold"
+	NotificationPostExpired      = "post_expired"
+	NotificationSaleConfirmation = "sale_confirmation"
+)
+
+type Notification struct {
+	ID        string    `json:"id"`
+	UserID    string    `json:"-"`
+	Type      string    `json:"type"`
+	Message   string    `json:"message"`
+	PostID    string    `json:"post_id,omitempty"`
+	Read      bool      `json:"read"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -0,0 +1,43 @@
+package models
+
+import (
+	"time"
+)
+
+// Offer statuses. Only pending offers can be accepted, declined, countered
+// or withdrawn.
+const (
+	OfferPending   = "pending"
+	OfferAccepted  = "accepted"
+	OfferDeclined  = "declined"
+	OfferCountered = "countered"
+	OfferWithdrawn = "withdrawn"
+	OfferExpired   = "expired"
+)
+
+type Offer struct {
+	ID         string  `json:"id"`
+	PostID     string  `json:"post_id"`
+	PostTitle  string  `json:"post_title"`
+	BuyerID    string  `json:"buyer_id"`
+	BuyerName  string  `json:"buyer_name"`
+	SellerID   string  `json:"seller_id"`
+	SellerName string  `json:"seller_name"`
+	ProposedBy string  `json:"proposed_by"`
+	ParentID   string  `json:"parent_id,omitempty"`
+	Amount     float64 `json:"amount"`
+	Message    string  `json:"message"`
+	Status     string  `json:"status"`
+
+	ExpiresAt   time.Time  `json:"expires_at"`
+	RespondedAt *time.Time `json:"responded_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+// Recipient is the side that has to respond to the offer
+func (o *Offer) Recipient() string {
+	if o.ProposedBy == o.BuyerID {
+		return o.SellerID
+	}
+	return o.BuyerID
+}
@@ -0,0 +1,53 @@
+package models
+
+import (
+	"time"
+)
+
+type Post struct {
+	ID                    string     `json:"id"`
+	UserID                string     `json:"user_id"`
+	UserEmail             string     `json:"user_email"`
+	UserName              string     `json:"user_name"`
+	UserProfilePictureURL string     `json:"user_profile_picture_url"`
+	SellerReputation      float64    `json:"seller_reputation"` // The owner's Reputation
+	SellerReviewCount     int        `json:"seller_review_count"`
+	Title                 string     `json:"title" binding:"required"`
+	Description           string     `json:"description" binding:"required"`
+	Price                 float64    `json:"price"`
+	Category              string     `json:"category" binding:"required"`
+	Type                  string     `json:"type" binding:"required"`
+	Location              string     `json:"location"`
+	Condition             string     `json:"condition"`
+	State                 string     `json:"state"`
+	Sold                  bool       `json:"sold"`     // State == PostSold, for older clients
+	Reserved              bool       `json:"reserved"` // State == PostReserved
+	Hidden                bool       `json:"hidden"`   // Reported by enough users; awaiting moderation
+	ViewCount             int        `json:"view_count"`
+	FavoriteCount         int        `json:"favorite_count"`
+	Media                 []Media    `json:"media"`
+	CreatedAt             time.Time  `json:"created_at"`
+	BumpedAt              time.Time  `json:"bumped_at"` // Orders the newest sort
+	ExpiresAt             time.Time  `json:"expires_at"`
+	PublishAt             *time.Time `json:"publish_at,omitempty"` // When a scheduled draft goes live
+
+	// Set when the post came from a search; matches are wrapped in <mark>
+	TitleHighlight     string  `json:"title_highlight,omitempty"`
+	DescriptionSnippet string  `json:"description_snippet,omitempty"`
+	SearchRank         float64 `json:"-"`
+}
+
+// SetState sets State and the Sold/Reserved flags derived from it
+func (p *Post) SetState(state string) {
+	p.State = state
+	p.Sold = state == PostSold
+	p.Reserved = state == PostReserved
+}
+
+type Media struct {
+	ID     string `json:"id"`
+	PostID string `json:"post_id"`
+	URL    string `json:"url"`
+	Type   string `json:"type"`
+	Order  int    `json:"order"`
+}
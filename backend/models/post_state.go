@@ -0,0 +1,52 @@
+package models
+
+import (
+	"time"
+)
+
+// Listing states. A post starts active (or as a draft), can be held for a
+// buyer, and ends sold, expired or removed.
+const (
+	PostDraft    = "draft"
+	PostActive   = "active"
+	PostReserved = "reserved"
+	PostSold     = "sold"
+	PostExpired  = "expired"
+	PostRemoved  = "removed"
+)
+
+// postTransitions lists the states each state can move to
+var postTransitions = map[string][]string{
+	PostDraft:    {PostActive, PostRemoved},
+	PostActive:   {PostReserved, PostSold, PostExpired, PostRemoved},
+	PostReserved: {PostActive, PostSold, PostRemoved},
+	PostSold:     {PostActive, PostRemoved},
+	PostExpired:  {PostActive, PostRemoved},
+	PostRemoved:  {},
+}
+
+// ValidPostState reports whether state is one of the listing states
+func ValidPostState(state string) bool {
+	_, ok := postTransitions[state]
+	return ok
+}
+
+// CanTransition reports whether a post in state from may move to state to
+func CanTransition(from, to string) bool {
+	for _, next := range postTransitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// PostStateChange is one entry in a post's state history
+type PostStateChange struct {
+	ID        string    `json:"id"`
+	PostID    string    `json:"post_id"`
+	FromState string    `json:"from_state"`
+	ToState   string    `json:"to_state"`
+	ChangedBy string    `json:"changed_by,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+// Presence statuses. Away means connected but idle for a while.
+const (
+	PresenceOnline  = "online"
+	PresenceAway    = "away"
+	PresenceOffline = "offline"
+)
+
+// Presence is whether a user is around. The store fills in Online and the
+// timestamps; Status is worked out from them.
+type Presence struct {
+	UserID       string     `json:"user_id"`
+	Status       string     `json:"status"`
+	LastSeenAt   *time.Time `json:"last_seen_at"` // Nil if they have never connected
+	Online       bool       `json:"-"`
+	LastActiveAt *time.Time `json:"-"`
+}
@@ -0,0 +1,59 @@
+package models
+
+import (
+	"time"
+)
+
+// What a report can be about
+const (
+	ReportTargetPost    = "post"
+	ReportTargetUser    = "user"
+	ReportTargetMessage = "message"
+)
+
+// Report reasons
+const (
+	ReportSpam          = "spam"
+	ReportScam          = "scam"
+	ReportProhibited    = "prohibited_item"
+	ReportHarassment    = "harassment"
+	ReportInappropriate = "inappropriate"
+	ReportOther         = "other"
+)
+
+var reportReasons = []string{ReportSpam, ReportScam, ReportProhibited, ReportHarassment, ReportInappropriate, ReportOther}
+
+// ValidReportReason reports whether reason is one of the report reasons
+func ValidReportReason(reason string) bool {
+	for _, r := range reportReasons {
+		if r == reason {
+			return true
+		}
+	}
+	return false
+}
+
+// Report statuses. A report stays open until a moderator dismisses it or
+// acts on its target, which closes every open report on that target.
+const (
+	ReportOpen      = "open"
+	ReportDismissed = "dismissed"
+	ReportActioned  = "actioned"
+)
+
+// Report is one user flagging a post, user or message
+type Report struct {
+	ID           string `json:"id"`
+	ReporterID   string `json:"reporter_id"`
+	ReporterName string `json:"reporter_name"`
+	TargetType   string `json:"target_type"`
+	TargetID     string `json:"target_id"`
+	TargetUserID string `json:"target_user_id"` // The post's owner, the user, or the message's sender
+	Reason       string `json:"reason"`
+	Note         string `json:"note"`
+	Status       string `json:"status"`
+
+	ReviewedBy string     `json:"reviewed_by,omitempty"`
+	ReviewedAt *time.Time `json:"reviewed_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
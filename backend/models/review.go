@@ -0,0 +1,25 @@
+package models
+
+import (
+	"time"
+)
+
+// Review is one party's rating of the other after a sale
+type Review struct {
+	ID           string    `json:"id"`
+	PostID       string    `json:"post_id,omitempty"` // Empty once the post is deleted
+	PostTitle    string    `json:"post_title"`
+	ReviewerID   string    `json:"reviewer_id"`
+	ReviewerName string    `json:"reviewer_name"`
+	RevieweeID   string    `json:"reviewee_id"`
+	Rating       int       `json:"rating"`
+	Comment      string    `json:"comment"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// Reputation sums up the reviews a user has received. Score is their
+// average rating, 0 until they have a review.
+type Reputation struct {
+	Score       float64 `json:"score"`
+	ReviewCount int     `json:"review_count"`
+}
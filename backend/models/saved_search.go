@@ -0,0 +1,21 @@
+package models
+
+import (
+	"time"
+)
+
+// SavedSearch is a named set of post filters a user wants alerts for.
+// Empty fields match anything.
+type SavedSearch struct {
+	ID          string    `json:"id"`
+	UserID      string    `json:"-"`
+	Name        string    `json:"name" binding:"required"`
+	Category    string    `json:"category"`
+	Type        string    `json:"type"`
+	Search      string    `json:"search"`
+	Condition   string    `json:"condition"`
+	MinPrice    *float64  `json:"min_price"`
+	MaxPrice    *float64  `json:"max_price"`
+	EmailAlerts bool      `json:"email_alerts"`
+	CreatedAt   time.Time `json:"created_at"`
+}
@@ -0,0 +1,8 @@
+package models
+
+// SearchSuggestion is an autocomplete entry for the search box
+type SearchSuggestion struct {
+	Text string `json:"text"`
+	// Kind is "title" or "category"
+	Kind string `json:"kind"`
+}
@@ -0,0 +1,26 @@
+package models
+
+import (
+	"time"
+)
+
+// Session is a login on one device, renewed with a rotating refresh token
+type Session struct {
+	ID                       string     `json:"id"`
+	UserID                   string     `json:"-"`
+	RefreshTokenHash         string     `json:"-"`
+	PreviousRefreshTokenHash string     `json:"-"`
+	Device                   string     `json:"device"`
+	IPAddress                string     `json:"ip_address"`
+	UserAgent                string     `json:"user_agent"`
+	Current                  bool       `json:"current"`
+	CreatedAt                time.Time  `json:"created_at"`
+	LastSeenAt               time.Time  `json:"last_seen_at"`
+	ExpiresAt                time.Time  `json:"expires_at"`
+	RevokedAt                *time.Time `json:"-"`
+}
+
+// Active reports whether the session can still be used at the given time
+func (s *Session) Active(now time.Time) bool {
+	return s.RevokedAt == nil && now.Before(s.ExpiresAt)
+}
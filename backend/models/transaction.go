@@ -0,0 +1,30 @@
+package models
+
+import (
+	"time"
+)
+
+// Transaction statuses. A sale starts pending until the buyer confirms or
+// declines it; the seller unmarking the post as sold cancels it.
+const (
+	TransactionPending   = "pending"
+	TransactionConfirmed = "confirmed"
+	TransactionDeclined  = "declined"
+	TransactionCancelled = "cancelled"
+)
+
+// Transaction records a post being sold to a buyer
+type Transaction struct {
+	ID         string  `json:"id"`
+	PostID     string  `json:"post_id,omitempty"` // Empty once the post is deleted
+	PostTitle  string  `json:"post_title"`
+	SellerID   string  `json:"seller_id"`
+	SellerName string  `json:"seller_name"`
+	BuyerID    string  `json:"buyer_id"`
+	BuyerName  string  `json:"buyer_name"`
+	FinalPrice float64 `json:"final_price"`
+	Status     string  `json:"status"`
+
+	RespondedAt *time.Time `json:"responded_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
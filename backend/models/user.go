@@ -4,15 +4,40 @@ import (
 	"time"
 )
 
+// User roles. Each role can do everything the roles before it can.
+const (
+	RoleUser      = "user"
+	RoleModerator = "moderator"
+	RoleAdmin     = "admin"
+)
+
+var roleRanks = map[string]int{RoleUser: 1, RoleModerator: 2, RoleAdmin: 3}
+
+// ValidRole reports whether role is one of the user roles
+func ValidRole(role string) bool {
+	_, ok := roleRanks[role]
+	return ok
+}
+
+// RoleAtLeast reports whether role grants everything minimum does
+func RoleAtLeast(role, minimum string) bool {
+	rank, ok := roleRanks[role]
+	return ok && rank >= roleRanks[minimum]
+}
+
 type User struct {
-	ID                       int        `json:"id"`
+	ID                       string     `json:"id"`
 	Email                    string     `json:"email"`
 	Password                 string     `json:"-"` // Never return password
 	Name                     string     `json:"name"`
-	ProfilePictureURL        string     `json:"profile_picture_url,omitempty"`
+	Year                     string     `json:"year"`
+	ProfilePictureURL        string     `json:"profile_picture_url"`
 	EmailVerified            bool       `json:"email_verified"`
+	Role                     string     `json:"role"`
 	VerificationToken        *string    `json:"-"` // Never return token
 	VerificationTokenExpires *time.Time `json:"-"`
+	TokenVersion             int        `json:"-"`      // Bumped on password change to revoke JWTs
+	Hidden                   bool       `json:"hidden"` // Reported by enough users; awaiting moderation
+	SuspendedAt              *time.Time `json:"suspended_at,omitempty"`
 	CreatedAt                time.Time  `json:"created_at"`
-	UpdatedAt                time.Time  `json:"updated_at"`
 }
@@ -0,0 +1,10 @@
+package notify
+
+// PostEvents sends listing changes to everything that alerts on them: new
+// posts to the saved search matcher, price drops and sales to the watchlist,
+// and recorded sales to the buyer
+type PostEvents struct {
+	*SavedSearchMatcher
+	*Watchlist
+	*Sales
+}
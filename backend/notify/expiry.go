@@ -0,0 +1,50 @@
+package notify
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"bruinmarket-backend/models"
+	"bruinmarket-backend/store"
+)
+
+// PostExpirer marks listings expired once they pass expires_at and tells
+// their owners, with a link to renew them
+type PostExpirer struct {
+	posts    store.PostStore
+	notifier *Notifier
+	mailer   Mailer
+}
+
+// NewPostExpirer builds the expiry job; mailer may be nil to skip email
+func NewPostExpirer(posts store.PostStore, notifier *Notifier, mailer Mailer) *PostExpirer {
+	return &PostExpirer{
+		posts:    posts,
+		notifier: notifier,
+		mailer:   mailer,
+	}
+}
+
+// ExpirePosts expires every active listing whose time is up as of now
+func (e *PostExpirer) ExpirePosts(now time.Time) {
+	expired, err := e.posts.ExpirePosts(now)
+	if err != nil {
+		log.Printf("Error expiring posts: %v", err)
+		return
+	}
+
+	for i := range expired {
+		post := &expired[i]
+		message := fmt.Sprintf("Your listing %s has expired. Renew it to put it back up.", post.Title)
+		if err := e.notifier.Notify(post.UserID, models.NotificationPostExpired, message, post.ID); err != nil {
+			log.Printf("Error notifying %s of expired post %s: %v", post.UserID, post.ID, err)
+		}
+
+		if e.mailer != nil && post.UserEmail != "" {
+			if err := e.mailer.SendPostExpiredEmail(post.UserEmail, post.UserName, post); err != nil {
+				log.Printf("Error emailing %s about expired post %s: %v", post.UserEmail, post.ID, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package notify
+
+import (
+	"encoding/json"
+	"time"
+
+	"bruinmarket-backend/models"
+	"bruinmarket-backend/store"
+
+	"github.com/google/uuid"
+)
+
+// Pusher delivers a payload to a user's open WebSocket connections.
+// chat.Hub implements it.
+type Pusher interface {
+	SendToUser(userID string, payload []byte)
+}
+
+// PushMessage is the WebSocket frame for a new notification
+type PushMessage struct {
+	Type         string               `json:"type"`
+	Notification *models.Notification `json:"notification"`
+}
+
+// Notifier records in-app notifications and pushes them to connected users
+type Notifier struct {
+	notifications store.NotificationStore
+	pusher        Pusher
+}
+
+func NewNotifier(notifications store.NotificationStore, pusher Pusher) *Notifier {
+	return &Notifier{
+		notifications: notifications,
+		pusher:        pusher,
+	}
+}
+
+// Notify stores a notification for userID and pushes it if they are online
+func (n *Notifier) Notify(userID, notificationType, message, postID string) error {
+	notification := &models.Notification{
+		ID:        uuid.New().String(),
+		UserID:    userID,
+		Type:      notificationType,
+		Message:   message,
+		PostID:    postID,
+		CreatedAt: time.Now(),
+	}
+	if err := n.notifications.CreateNotification(notification); err != nil {
+		return err
+	}
+
+	if n.pusher != nil {
+		payload, err := json.Marshal(PushMessage{Type: "notification", Notification: notification})
+		if err != nil {
+			return err
+		}
+		n.pusher.SendToUser(userID, payload)
+	}
+	return nil
+}
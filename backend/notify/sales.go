@@ -0,0 +1,25 @@
+package notify
+
+import (
+	"fmt"
+	"log"
+
+	"bruinmarket-backend/models"
+)
+
+// Sales asks buyers to confirm the sales sellers record against them
+type Sales struct {
+	notifier *Notifier
+}
+
+func NewSales(notifier *Notifier) *Sales {
+	return &Sales{notifier: notifier}
+}
+
+// SaleRecorded notifies the buyer that they have a sale to confirm
+func (s *Sales) SaleRecorded(sale *models.Transaction) {
+	message := fmt.Sprintf("%s marked %s as sold to you for $%.2f. Please confirm the purchase.", sale.SellerName, sale.PostTitle, sale.FinalPrice)
+	if err := s.notifier.Notify(sale.BuyerID, models.NotificationSaleConfirmation, message, sale.PostID); err != nil {
+		log.Printf("Error notifying %s of sale %s: %v", sale.BuyerID, sale.ID, err)
+	}
+}
@@ -0,0 +1,88 @@
+package notify
+
+import (
+	"fmt"
+	"log"
+
+	"bruinmarket-backend/models"
+	"bruinmarket-backend/store"
+)
+
+// Posts waiting to be matched; CreatePost never blocks on a full queue
+const matchQueueSize = 256
+
+// Mailer sends alert emails. services.EmailService implements it.
+type Mailer interface {
+	SendSavedSearchAlertEmail(toEmail, toName, searchName string, post *models.Post) error
+	SendPostExpiredEmail(toEmail, toName string, post *models.Post) error
+}
+
+// SavedSearchMatcher checks each new post against everyone's saved searches
+// in the background and alerts the owners of the ones it matches
+type SavedSearchMatcher struct {
+	searches store.SavedSearchStore
+	users    store.UserStore
+	notifier *Notifier
+	mailer   Mailer
+	queue    chan models.Post
+}
+
+// NewSavedSearchMatcher builds a matcher; mailer may be nil to skip email
+func NewSavedSearchMatcher(searches store.SavedSearchStore, users store.UserStore, notifier *Notifier, mailer Mailer) *SavedSearchMatcher {
+	return &SavedSearchMatcher{
+		searches: searches,
+		users:    users,
+		notifier: notifier,
+		mailer:   mailer,
+		queue:    make(chan models.Post, matchQueueSize),
+	}
+}
+
+// Run processes queued posts until the process exits
+func (m *SavedSearchMatcher) Run() {
+	for post := range m.queue {
+		m.match(&post)
+	}
+}
+
+// PostCreated queues a new post for matching
+func (m *SavedSearchMatcher) PostCreated(post *models.Post) {
+	select {
+	case m.queue <- *post:
+	default:
+		log.Printf("Saved search queue full, skipping alerts for post %s", post.ID)
+	}
+}
+
+func (m *SavedSearchMatcher) match(post *models.Post) {
+	searches, err := m.searches.ListSavedSearchesMatchingPost(post.ID)
+	if err != nil {
+		log.Printf("Error matching saved searches for post %s: %v", post.ID, err)
+		return
+	}
+
+	// One alert per user even if several of their searches match
+	alerted := map[string]bool{}
+	for _, search := range searches {
+		if alerted[search.UserID] {
+			continue
+		}
+		alerted[search.UserID] = true
+
+		message := fmt.Sprintf("New listing for \"%s\": %s ($%.2f)", search.Name, post.Title, post.Price)
+		if err := m.notifier.Notify(search.UserID, models.NotificationSavedSearchMatch, message, post.ID); err != nil {
+			log.Printf("Error notifying %s of saved search match: %v", search.UserID, err)
+		}
+
+		if search.EmailAlerts && m.mailer != nil {
+			user, err := m.users.GetUserByID(search.UserID)
+			if err != nil {
+				log.Printf("Error loading user %s for saved search email: %v", search.UserID, err)
+				continue
+			}
+			if err := m.mailer.SendSavedSearchAlertEmail(user.Email, user.Name, search.Name, post); err != nil {
+				log.Printf("Error sending saved search email to %s: %v", user.Email, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package notify
+
+import (
+	"fmt"
+	"log"
+
+	"bruinmarket-backend/models"
+	"bruinmarket-backend/store"
+)
+
+type watchEvent struct {
+	post     models.Post
+	kind     string
+	oldPrice float64
+}
+
+// Watchlist tells the users who favorited a post when its price drops or it
+// sells. Like SavedSearchMatcher it works through a queue in the background.
+type Watchlist struct {
+	favorites store.FavoriteStore
+	notifier  *Notifier
+	queue     chan watchEvent
+}
+
+func NewWatchlist(favorites store.FavoriteStore, notifier *Notifier) *Watchlist {
+	return &Watchlist{
+		favorites: favorites,
+		notifier:  notifier,
+		queue:     make(chan watchEvent, matchQueueSize),
+	}
+}
+
+// Run processes queued events until the process exits
+func (w *Watchlist) Run() {
+	for event := range w.queue {
+		w.notifyWatchers(&event)
+	}
+}
+
+// PriceDropped queues a notification that the post is now cheaper than oldPrice
+func (w *Watchlist) PriceDropped(post *models.Post, oldPrice float64) {
+	w.enqueue(watchEvent{post: *post, kind: models.NotificationPriceDrop, oldPrice: oldPrice})
+}
+
+// PostSold queues a notification that the post has been marked as sold
+func (w *Watchlist) PostSold(post *models.Post) {
+	w.enqueue(watchEvent{post: *post, kind: models.NotificationPostSold})
+}
+
+func (w *Watchlist) enqueue(event watchEvent) {
+	select {
+	case w.queue <- event:
+	default:
+		log.Printf("Watchlist queue full, skipping %s alerts for post %s", event.kind, event.post.ID)
+	}
+}
+
+func (w *Watchlist) notifyWatchers(event *watchEvent) {
+	post := &event.post
+	watchers, err := w.favorites.ListPostWatchers(post.ID)
+	if err != nil {
+		log.Printf("Error loading watchers for post %s: %v", post.ID, err)
+		return
+	}
+
+	var message string
+	switch event.kind {
+	case models.NotificationPriceDrop:
+		message = fmt.Sprintf("Price drop: %s is now $%.2f (was $%.2f)", post.Title, post.Price, event.oldPrice)
+	case models.NotificationPostSold:
+		message = fmt.Sprintf("%s has been marked as sold", post.Title)
+	}
+
+	for _, userID := range watchers {
+		if userID == post.UserID {
+			continue
+		}
+		if err := w.notifier.Notify(userID, event.kind, message, post.ID); err != nil {
+			log.Printf("Error notifying %s about post %s: %v", userID, post.ID, err)
+		}
+	}
+}
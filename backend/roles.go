@@ -0,0 +1,42 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+
+	"bruinmarket-backend/models"
+	"bruinmarket-backend/store"
+)
+
+const setRoleUsage = `usage: bruinmarket-backend set-role EMAIL ROLE
+
+Gives the account with EMAIL the role user, moderator or admin. Use it to
+create the first admin, who can then manage roles from /api/admin/users.`
+
+// runSetRole implements the "set-role" subcommand
+func runSetRole(args []string) {
+	if len(args) != 2 || !models.ValidRole(args[1]) {
+		fmt.Fprintln(os.Stderr, setRoleUsage)
+		os.Exit(2)
+	}
+
+	conn, err := openDB()
+	if err != nil {
+		log.Fatal("Failed to connect to database:", err)
+	}
+	defer conn.Close()
+
+	users := store.NewPostgres(conn)
+	user, err := users.GetUserByEmail(args[0])
+	if err == store.ErrNotFound {
+		log.Fatalf("No user with email %s", args[0])
+	}
+	if err != nil {
+		log.Fatal("Failed to look up user:", err)
+	}
+	if err := users.SetUserRole(user.ID, args[1]); err != nil {
+		log.Fatal("Failed to set role:", err)
+	}
+	fmt.Printf("%s is now %s\n", user.Email, args[1])
+}
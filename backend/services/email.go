@@ -2,7 +2,12 @@ package services
 
 import (
 	"fmt"
+	"html"
+	"net/url"
 	"os"
+	"strings"
+
+	"bruinmarket-backend/models"
 
 	"github.com/sendgrid/sendgrid-go"
 	"github.com/sendgrid/sendgrid-go/helpers/mail"
@@ -179,3 +184,171 @@ func (e *EmailService) SendWelcomeEmail(toEmail, toName string) error {
 	_, err := e.client.Send(message)
 	return err
 }
+
+func (e *EmailService) SendPasswordResetEmail(toEmail, toName, token string) error {
+	from := mail.NewEmail(e.fromName, e.fromEmail)
+	to := mail.NewEmail(toName, toEmail)
+
+	resetURL := fmt.Sprintf("%s/reset-password?token=%s", e.frontendURL, token)
+
+	subject := "Reset your BruinMarket password"
+
+	htmlContent := fmt.Sprintf(`
+		<!DOCTYPE html>
+		<html>
+		<head>
+			<style>
+				body { font-family: Arial, sans-serif; line-height: 1.6; color: #333; }
+				.container { max-width: 600px; margin: 0 auto; padding: 20px; }
+				.header { background: linear-gradient(135deg, #3b82f6 0%%, #0ea5e9 100%%); color: white; padding: 30px; text-align: center; border-radius: 10px 10px 0 0; }
+				.content { background: #f9fafb; padding: 30px; border-radius: 0 0 10px 10px; }
+				.button { display: inline-block; background: #3b82f6; color: white; padding: 15px 30px; text-decoration: none; border-radius: 5px; margin: 20px 0; font-weight: bold; }
+				.footer { text-align: center; color: #666; font-size: 12px; margin-top: 20px; }
+			</style>
+		</head>
+		<body>
+			<div class="container">
+				<div class="header">
+					<h1>Password Reset 🔑</h1>
+				</div>
+				<div class="content">
+					<p>Hi %s,</p>
+					<p>We received a request to reset the password for your BruinMarket account.</p>
+					<p>Click the button below to choose a new password:</p>
+					<div style="text-align: center;">
+						<a href="%s" class="button" style="display: inline-block; background: #3b82f6; color: white !important; padding: 15px 30px; text-decoration: none; border-radius: 5px; margin: 20px 0; font-weight: bold;">Reset Password</a>
+					</div>
+					<p>Or copy and paste this link into your browser:</p>
+					<p style="word-break: break-all; color: #3b82f6;">%s</p>
+					<p><strong>This link will expire in 1 hour and can only be used once.</strong></p>
+					<p>If you didn't request a password reset, you can safely ignore this email. Your password will not change.</p>
+					<p>Best regards,<br>The BruinMarket Team</p>
+				</div>
+				<div class="footer">
+					<p>BruinMarket - UCLA Student Marketplace</p>
+					<p>This is an automated email. Please do not reply.</p>
+					<p style="margin-top: 10px; color: #999; font-size: 11px;">© 2025 BruinMarket. All rights reserved.</p>
+				</div>
+			</div>
+		</body>
+		</html>
+	`, toName, resetURL, resetURL)
+
+	// Plain text fallback
+	plainTextContent := fmt.Sprintf(`
+		Hi %s,
+
+		We received a request to reset the password for your BruinMarket account.
+
+		Choose a new password by visiting this link:
+		%s
+
+		This link will expire in 1 hour and can only be used once.
+
+		If you didn't request a password reset, you can safely ignore this email.
+
+		Best regards,
+		The BruinMarket Team
+	`, toName, resetURL)
+
+	message := mail.NewSingleEmail(from, subject, to, plainTextContent, htmlContent)
+
+	response, err := e.client.Send(message)
+	if err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+
+	if response.StatusCode >= 400 {
+		return fmt.Errorf("sendgrid error: status code %d, body: %s", response.StatusCode, response.Body)
+	}
+
+	return nil
+}
+
+// sendAlertEmail sends a short notification email with one call to action.
+// The heading and lines are HTML-escaped here.
+func (e *EmailService) sendAlertEmail(toEmail, toName, subject, heading string, lines []string, buttonLabel, buttonURL string) error {
+	from := mail.NewEmail(e.fromName, e.fromEmail)
+	to := mail.NewEmail(toName, toEmail)
+
+	var htmlLines, textLines strings.Builder
+	for _, line := range lines {
+		fmt.Fprintf(&htmlLines, "<p>%s</p>\n", html.EscapeString(line))
+		fmt.Fprintf(&textLines, "%s\n\n", line)
+	}
+
+	htmlContent := fmt.Sprintf(`
+		<!DOCTYPE html>
+		<html>
+		<head>
+			<style>
+				body { font-family: Arial, sans-serif; line-height: 1.6; color: #333; }
+				.container { max-width: 600px; margin: 0 auto; padding: 20px; }
+				.header { background: linear-gradient(135deg, #3b82f6 0%%, #0ea5e9 100%%); color: white; padding: 30px; text-align: center; border-radius: 10px 10px 0 0; }
+				.content { background: #f9fafb; padding: 30px; border-radius: 0 0 10px 10px; }
+				.footer { text-align: center; color: #666; font-size: 12px; margin-top: 20px; }
+			</style>
+		</head>
+		<body>
+			<div class="container">
+				<div class="header">
+					<h1>%s</h1>
+				</div>
+				<div class="content">
+					<p>Hi %s,</p>
+					%s
+					<div style="text-align: center;">
+						<a href="%s" class="button" style="display: inline-block; background: #3b82f6; color: white !important; padding: 15px 30px; text-decoration: none; border-radius: 5px; margin: 20px 0; font-weight: bold;">%s</a>
+					</div>
+					<p>Best regards,<br>The BruinMarket Team</p>
+				</div>
+				<div class="footer">
+					<p>BruinMarket - UCLA Student Marketplace</p>
+					<p>This is an automated email. Please do not reply.</p>
+					<p style="margin-top: 10px; color: #999; font-size: 11px;">© 2025 BruinMarket. All rights reserved.</p>
+				</div>
+			</div>
+		</body>
+		</html>
+	`, html.EscapeString(heading), html.EscapeString(toName), htmlLines.String(), buttonURL, html.EscapeString(buttonLabel))
+
+	plainTextContent := fmt.Sprintf("Hi %s,\n\n%s%s: %s\n\nBest regards,\nThe BruinMarket Team\n",
+		toName, textLines.String(), buttonLabel, buttonURL)
+
+	message := mail.NewSingleEmail(from, subject, to, plainTextContent, htmlContent)
+
+	response, err := e.client.Send(message)
+	if err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+
+	if response.StatusCode >= 400 {
+		return fmt.Errorf("sendgrid error: status code %d, body: %s", response.StatusCode, response.Body)
+	}
+
+	return nil
+}
+
+func (e *EmailService) SendSavedSearchAlertEmail(toEmail, toName, searchName string, post *models.Post) error {
+	return e.sendAlertEmail(toEmail, toName,
+		fmt.Sprintf("New BruinMarket listing for \"%s\"", searchName),
+		"New Listing Alert 🔔",
+		[]string{
+			fmt.Sprintf("A new listing matches your saved search \"%s\":", searchName),
+			fmt.Sprintf("%s - $%.2f", post.Title, post.Price),
+		},
+		"View on BruinMarket", e.frontendURL,
+	)
+}
+
+func (e *EmailService) SendPostExpiredEmail(toEmail, toName string, post *models.Post) error {
+	return e.sendAlertEmail(toEmail, toName,
+		fmt.Sprintf("Your BruinMarket listing \"%s\" has expired", post.Title),
+		"Listing Expired ⏰",
+		[]string{
+			fmt.Sprintf("Your listing \"%s\" has expired and is no longer shown to buyers.", post.Title),
+			"Still available? Renew it to put it back up.",
+		},
+		"Renew Listing", fmt.Sprintf("%s/renew?post=%s", e.frontendURL, url.QueryEscape(post.ID)),
+	)
+}
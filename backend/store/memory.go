@@ -0,0 +1,73 @@
+package store
+
+import (
+	"sync"
+	"time"
+
+	"bruinmarket-backend/models"
+)
+
+// Memory implements the stores in process. It backs the handler tests and
+// local experiments where Postgres isn't available.
+type Memory struct {
+	mu            sync.RWMutex
+	users         map[string]*memoryUser
+	sessions      map[string]*models.Session
+	posts         map[string]*models.Post
+	media         map[string][]models.Media
+	conversations map[string]*models.Conversation
+	messages      []*models.Message
+	savedSearches map[string]*models.SavedSearch
+	notifications []*models.Notification
+	// favorites maps post ID to the users watching it and when they started
+	favorites map[string]map[string]time.Time
+	offers    map[string]*models.Offer
+	// stateHistory is in the order changes were made
+	stateHistory []*models.PostStateChange
+	reviews      []*models.Review
+	transactions map[string]*models.Transaction
+	reports      []*models.Report
+	categories   map[string]*models.Category
+	// connections maps WebSocket connection IDs to the user and when the
+	// connection was last seen alive
+	connections map[string]*memoryConnection
+}
+
+func NewMemory() *Memory {
+	m := &Memory{
+		users:         make(map[string]*memoryUser),
+		sessions:      make(map[string]*models.Session),
+		posts:         make(map[string]*models.Post),
+		media:         make(map[string][]models.Media),
+		conversations: make(map[string]*models.Conversation),
+		savedSearches: make(map[string]*models.SavedSearch),
+		favorites:     make(map[string]map[string]time.Time),
+		offers:        make(map[string]*models.Offer),
+		transactions:  make(map[string]*models.Transaction),
+		categories:    make(map[string]*models.Category),
+		connections:   make(map[string]*memoryConnection),
+	}
+	// Seeded like the categories migration
+	for i, name := range models.DefaultCategories {
+		m.categories[name] = &models.Category{Name: name, Position: i + 1}
+	}
+	return m
+}
+
+var (
+	_ UserStore         = (*Memory)(nil)
+	_ SessionStore      = (*Memory)(nil)
+	_ PostStore         = (*Memory)(nil)
+	_ MediaStore        = (*Memory)(nil)
+	_ ConversationStore = (*Memory)(nil)
+	_ MessageStore      = (*Memory)(nil)
+	_ SavedSearchStore  = (*Memory)(nil)
+	_ NotificationStore = (*Memory)(nil)
+	_ FavoriteStore     = (*Memory)(nil)
+	_ OfferStore        = (*Memory)(nil)
+	_ ReviewStore       = (*Memory)(nil)
+	_ TransactionStore  = (*Memory)(nil)
+	_ ReportStore       = (*Memory)(nil)
+	_ CategoryStore     = (*Memory)(nil)
+	_ PresenceStore     = (*Memory)(nil)
+)
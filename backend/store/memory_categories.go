@@ -0,0 +1,81 @@
+package store
+
+import (
+	"sort"
+
+	"bruinmarket-backend/models"
+)
+
+func (m *Memory) ListCategories() ([]models.Category, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	categories := make([]models.Category, 0, len(m.categories))
+	for _, category := range m.categories {
+		categories = append(categories, *category)
+	}
+	sort.Slice(categories, func(i, j int) bool {
+		if categories[i].Position != categories[j].Position {
+			return categories[i].Position < categories[j].Position
+		}
+		return categories[i].Name < categories[j].Name
+	})
+	return categories, nil
+}
+
+func (m *Memory) CreateCategory(name string) (*models.Category, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.categories[name]; ok {
+		return nil, ErrConflict
+	}
+	category := &models.Category{Name: name, Position: 1}
+	for _, c := range m.categories {
+		if c.Position >= category.Position {
+			category.Position = c.Position + 1
+		}
+	}
+	m.categories[name] = category
+	created := *category
+	return &created, nil
+}
+
+func (m *Memory) RenameCategory(oldName, newName string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	category, ok := m.categories[oldName]
+	if !ok {
+		return ErrNotFound
+	}
+	if _, taken := m.categories[newName]; taken {
+		return ErrConflict
+	}
+	delete(m.categories, oldName)
+	category.Name = newName
+	m.categories[newName] = category
+
+	for _, p := range m.posts {
+		if p.Category == oldName {
+			p.Category = newName
+		}
+	}
+	for _, s := range m.savedSearches {
+		if s.Category == oldName {
+			s.Category = newName
+		}
+	}
+	return nil
+}
+
+func (m *Memory) DeleteCategory(name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.categories[name]; !ok {
+		return ErrNotFound
+	}
+	delete(m.categories, name)
+	return nil
+}
@@ -0,0 +1,153 @@
+package store
+
+import (
+	"sort"
+	"time"
+
+	"bruinmarket-backend/models"
+)
+
+// withNames returns a copy of the conversation with both members' names and
+// pictures filled in, mirroring the users joins in Postgres. Callers hold m.mu.
+func (m *Memory) withNames(c *models.Conversation) models.Conversation {
+	conv := *c
+	if u, ok := m.users[conv.User1ID]; ok {
+		conv.User1Name = u.user.Name
+		conv.User1PictureURL = u.user.ProfilePictureURL
+	}
+	if u, ok := m.users[conv.User2ID]; ok {
+		conv.User2Name = u.user.Name
+		conv.User2PictureURL = u.user.ProfilePictureURL
+	}
+	if conv.LastMessageTime.IsZero() {
+		conv.LastMessageTime = conv.CreatedAt
+	}
+	return conv
+}
+
+func (m *Memory) CreateConversation(conversation *models.Conversation) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, c := range m.conversations {
+		if c.User1ID == conversation.User1ID && c.User2ID == conversation.User2ID {
+			return ErrConflict
+		}
+	}
+	if _, ok := m.users[conversation.User1ID]; !ok {
+		return ErrNotFound
+	}
+	if _, ok := m.users[conversation.User2ID]; !ok {
+		return ErrNotFound
+	}
+	stored := *conversation
+	m.conversations[conversation.ID] = &stored
+	return nil
+}
+
+func (m *Memory) GetConversation(id string) (*models.Conversation, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	c, ok := m.conversations[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	conv := m.withNames(c)
+	return &conv, nil
+}
+
+func (m *Memory) FindConversation(userA, userB string) (*models.Conversation, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, c := range m.conversations {
+		if (c.User1ID == userA && c.User2ID == userB) || (c.User1ID == userB && c.User2ID == userA) {
+			conv := m.withNames(c)
+			return &conv, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (m *Memory) ListConversations(userID string) ([]models.Conversation, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	conversations := []models.Conversation{}
+	for _, c := range m.conversations {
+		if c.HasMember(userID) {
+			conversations = append(conversations, m.withNames(c))
+		}
+	}
+	sort.Slice(conversations, func(i, j int) bool {
+		return conversations[i].LastMessageTime.After(conversations[j].LastMessageTime)
+	})
+	return conversations, nil
+}
+
+func (m *Memory) UpdateLastMessage(id, content string, at time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	c, ok := m.conversations[id]
+	if !ok {
+		return ErrNotFound
+	}
+	c.LastMessage = content
+	c.LastMessageTime = at
+	return nil
+}
+
+func (m *Memory) CreateMessage(message *models.Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.conversations[message.ConversationID]; !ok {
+		return ErrNotFound
+	}
+	stored := *message
+	m.messages = append(m.messages, &stored)
+	return nil
+}
+
+func (m *Memory) GetMessage(id string) (*models.Message, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, msg := range m.messages {
+		if msg.ID == id {
+			message := *msg
+			return &message, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (m *Memory) ListMessages(conversationID string) ([]models.Message, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	messages := []models.Message{}
+	for _, msg := range m.messages {
+		if msg.ConversationID == conversationID {
+			messages = append(messages, *msg)
+		}
+	}
+	sort.SliceStable(messages, func(i, j int) bool {
+		return messages[i].CreatedAt.Before(messages[j].CreatedAt)
+	})
+	return messages, nil
+}
+
+func (m *Memory) MarkMessagesRead(conversationID, receiverID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, msg := range m.messages {
+		if msg.ConversationID == conversationID && msg.ReceiverID == receiverID {
+			msg.Read = true
+		}
+	}
+	return nil
+}
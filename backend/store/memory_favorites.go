@@ -0,0 +1,72 @@
+package store
+
+import (
+	"sort"
+	"time"
+
+	"bruinmarket-backend/models"
+)
+
+func (m *Memory) AddFavorite(userID, postID string, at time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.users[userID]; !ok {
+		return ErrNotFound
+	}
+	if _, ok := m.posts[postID]; !ok {
+		return ErrNotFound
+	}
+	watchers, ok := m.favorites[postID]
+	if !ok {
+		watchers = make(map[string]time.Time)
+		m.favorites[postID] = watchers
+	}
+	if _, ok := watchers[userID]; !ok {
+		watchers[userID] = at
+	}
+	return nil
+}
+
+func (m *Memory) RemoveFavorite(userID, postID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.favorites[postID][userID]; !ok {
+		return ErrNotFound
+	}
+	delete(m.favorites[postID], userID)
+	return nil
+}
+
+func (m *Memory) ListFavoritePosts(userID string) ([]models.Post, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	added := map[string]time.Time{}
+	posts := []models.Post{}
+	for postID, watchers := range m.favorites {
+		at, ok := watchers[userID]
+		if !ok {
+			continue
+		}
+		added[postID] = at
+		posts = append(posts, m.withOwner(m.posts[postID]))
+	}
+	sort.Slice(posts, func(i, j int) bool {
+		return added[posts[i].ID].After(added[posts[j].ID])
+	})
+	return posts, nil
+}
+
+func (m *Memory) ListPostWatchers(postID string) ([]string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	userIDs := []string{}
+	for userID := range m.favorites[postID] {
+		userIDs = append(userIDs, userID)
+	}
+	sort.Strings(userIDs)
+	return userIDs, nil
+}
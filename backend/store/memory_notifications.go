@@ -0,0 +1,66 @@
+package store
+
+import (
+	"bruinmarket-backend/models"
+)
+
+func (m *Memory) CreateNotification(n *models.Notification) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stored := *n
+	m.notifications = append(m.notifications, &stored)
+	return nil
+}
+
+func (m *Memory) ListNotifications(userID string, limit int) ([]models.Notification, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	// Stored oldest first, so walk backwards
+	notifications := []models.Notification{}
+	for i := len(m.notifications) - 1; i >= 0 && len(notifications) < limit; i-- {
+		if n := m.notifications[i]; n.UserID == userID {
+			notifications = append(notifications, *n)
+		}
+	}
+	return notifications, nil
+}
+
+func (m *Memory) CountUnreadNotifications(userID string) (int, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	count := 0
+	for _, n := range m.notifications {
+		if n.UserID == userID && !n.Read {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (m *Memory) MarkNotificationRead(id, userID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, n := range m.notifications {
+		if n.ID == id && n.UserID == userID {
+			n.Read = true
+			return nil
+		}
+	}
+	return ErrNotFound
+}
+
+func (m *Memory) MarkAllNotificationsRead(userID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, n := range m.notifications {
+		if n.UserID == userID {
+			n.Read = true
+		}
+	}
+	return nil
+}
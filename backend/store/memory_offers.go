@@ -0,0 +1,166 @@
+package store
+
+import (
+	"sort"
+	"time"
+
+	"bruinmarket-backend/models"
+)
+
+// withOfferNames returns a copy of the offer with the post title and user
+// names filled in, mirroring the joins in Postgres. Callers hold m.mu.
+func (m *Memory) withOfferNames(o *models.Offer) models.Offer {
+	offer := *o
+	if p, ok := m.posts[offer.PostID]; ok {
+		offer.PostTitle = p.Title
+	}
+	if u, ok := m.users[offer.BuyerID]; ok {
+		offer.BuyerName = u.user.Name
+	}
+	if u, ok := m.users[offer.SellerID]; ok {
+		offer.SellerName = u.user.Name
+	}
+	return offer
+}
+
+// listOffers returns the offers keep accepts, newest first. Callers hold m.mu.
+func (m *Memory) listOffers(keep func(o *models.Offer) bool) []models.Offer {
+	offers := []models.Offer{}
+	for _, o := range m.offers {
+		if keep(o) {
+			offers = append(offers, m.withOfferNames(o))
+		}
+	}
+	sort.Slice(offers, func(i, j int) bool {
+		return offers[i].CreatedAt.After(offers[j].CreatedAt)
+	})
+	return offers
+}
+
+// createOffer stores a copy of offer, enforcing one pending offer per buyer
+// and post like the unique index in Postgres. Callers hold m.mu for writing.
+func (m *Memory) createOffer(offer *models.Offer) error {
+	if _, ok := m.posts[offer.PostID]; !ok {
+		return ErrNotFound
+	}
+	for _, id := range []string{offer.BuyerID, offer.SellerID} {
+		if _, ok := m.users[id]; !ok {
+			return ErrNotFound
+		}
+	}
+	if offer.Status == models.OfferPending {
+		for _, o := range m.offers {
+			if o.Status == models.OfferPending && o.PostID == offer.PostID && o.BuyerID == offer.BuyerID {
+				return ErrConflict
+			}
+		}
+	}
+	stored := *offer
+	m.offers[offer.ID] = &stored
+	return nil
+}
+
+func (m *Memory) CreateOffer(offer *models.Offer) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.createOffer(offer)
+}
+
+func (m *Memory) GetOffer(id string) (*models.Offer, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	o, ok := m.offers[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	offer := m.withOfferNames(o)
+	return &offer, nil
+}
+
+func (m *Memory) ListOffersForPost(postID string) ([]models.Offer, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.listOffers(func(o *models.Offer) bool { return o.PostID == postID }), nil
+}
+
+func (m *Memory) ListOffersForUser(userID string) ([]models.Offer, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.listOffers(func(o *models.Offer) bool { return o.BuyerID == userID || o.SellerID == userID }), nil
+}
+
+func (m *Memory) ResolveOffer(id, status string, at time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	o, ok := m.offers[id]
+	if !ok || o.Status != models.OfferPending {
+		return ErrNotFound
+	}
+	o.Status = status
+	o.RespondedAt = &at
+	return nil
+}
+
+func (m *Memory) AcceptOffer(id string, reserve bool, at time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	o, ok := m.offers[id]
+	if !ok || o.Status != models.OfferPending {
+		return ErrNotFound
+	}
+	if reserve {
+		p, ok := m.posts[o.PostID]
+		if !ok || p.State != models.PostActive {
+			return ErrConflict
+		}
+		m.setState(p, models.PostReserved, o.SellerID, at)
+	}
+	o.Status = models.OfferAccepted
+	o.RespondedAt = &at
+	return nil
+}
+
+func (m *Memory) CounterOffer(parentID string, counter *models.Offer) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	parent, ok := m.offers[parentID]
+	if !ok || parent.Status != models.OfferPending {
+		return ErrNotFound
+	}
+	// The parent stops being pending first so the counter doesn't clash with it
+	at := counter.CreatedAt
+	parent.Status = models.OfferCountered
+	parent.RespondedAt = &at
+	if err := m.createOffer(counter); err != nil {
+		parent.Status = models.OfferPending
+		parent.RespondedAt = nil
+		return err
+	}
+	return nil
+}
+
+func (m *Memory) ExpireOffers(now time.Time) ([]models.Offer, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	expired := []models.Offer{}
+	for _, o := range m.offers {
+		if o.Status == models.OfferPending && !o.ExpiresAt.After(now) {
+			at := now
+			o.Status = models.OfferExpired
+			o.RespondedAt = &at
+			expired = append(expired, m.withOfferNames(o))
+		}
+	}
+	sort.Slice(expired, func(i, j int) bool {
+		return expired[i].CreatedAt.Before(expired[j].CreatedAt)
+	})
+	return expired, nil
+}
@@ -0,0 +1,524 @@
+package store
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"bruinmarket-backend/models"
+
+	"github.com/google/uuid"
+)
+
+// withOwner returns a copy of the post with the owner's details, reputation
+// and favorite count filled in, mirroring the joins in the Postgres queries. Callers hold m.mu.
+func (m *Memory) withOwner(p *models.Post) models.Post {
+	post := *p
+	post.Media = nil
+	if u, ok := m.users[post.UserID]; ok {
+		post.UserEmail = u.user.Email
+		post.UserName = u.user.Name
+		post.UserProfilePictureURL = u.user.ProfilePictureURL
+	}
+	post.FavoriteCount = len(m.favorites[post.ID])
+	reputation := m.reputation(post.UserID)
+	post.SellerReputation = reputation.Score
+	post.SellerReviewCount = reputation.ReviewCount
+	post.SetState(post.State)
+	return post
+}
+
+func (m *Memory) CreatePost(post *models.Post) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.users[post.UserID]; !ok {
+		return ErrNotFound
+	}
+	stored := *post
+	stored.Media = nil
+	m.posts[post.ID] = &stored
+	return nil
+}
+
+func (m *Memory) GetPost(id string) (*models.Post, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	p, ok := m.posts[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	post := m.withOwner(p)
+	return &post, nil
+}
+
+func (m *Memory) GetPostOwner(id string) (string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	p, ok := m.posts[id]
+	if !ok {
+		return "", ErrNotFound
+	}
+	return p.UserID, nil
+}
+
+// matchesFilter applies every PostFilter field except Search and paging.
+// Callers hold m.mu.
+func (m *Memory) matchesFilter(filter PostFilter, p *models.Post) bool {
+	if filter.UserID != "" && p.UserID != filter.UserID {
+		return false
+	}
+	if filter.Category != "" && p.Category != filter.Category {
+		return false
+	}
+	if filter.Type != "" && p.Type != filter.Type {
+		return false
+	}
+	if filter.Condition != "" && p.Condition != filter.Condition {
+		return false
+	}
+	if filter.MinPrice != nil && p.Price < *filter.MinPrice {
+		return false
+	}
+	if filter.MaxPrice != nil && p.Price > *filter.MaxPrice {
+		return false
+	}
+	if len(filter.States) > 0 && !containsString(filter.States, p.State) {
+		return false
+	}
+	if filter.ExcludeHidden && (p.Hidden || m.userHidden(p.UserID)) {
+		return false
+	}
+	return true
+}
+
+// userHidden mirrors hiddenUsers in Postgres. Callers hold m.mu.
+func (m *Memory) userHidden(userID string) bool {
+	u, ok := m.users[userID]
+	return ok && (u.user.Hidden || u.user.SuspendedAt != nil)
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *Memory) ListPosts(filter PostFilter) (*PostPage, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	sortBy := filter.Sort
+	if sortBy == "" {
+		sortBy = SortNewest
+	}
+	var after *models.Post
+	if filter.After != nil {
+		if filter.After.Sort != sortBy {
+			return nil, ErrInvalidCursor
+		}
+		key, err := filter.After.keyPost()
+		if err != nil {
+			return nil, err
+		}
+		after = &key
+	}
+
+	terms := parseSearch(filter.Search)
+	if sortBy == SortRelevance && len(terms) == 0 {
+		return nil, fmt.Errorf("relevance sort requires a search")
+	}
+
+	posts := []models.Post{}
+	for _, p := range m.posts {
+		if !m.matchesFilter(filter, p) {
+			continue
+		}
+		post := m.withOwner(p)
+		if filter.Search != "" {
+			rank, ok := matchSearch(terms, p)
+			if !ok {
+				continue
+			}
+			post.SearchRank = rank
+			post.TitleHighlight = highlightSearch(terms, p.Title)
+			post.DescriptionSnippet = highlightSearch(terms, p.Description)
+		}
+		posts = append(posts, post)
+	}
+
+	sort.Slice(posts, func(i, j int) bool {
+		return postBefore(sortBy, posts[i], posts[j])
+	})
+
+	page := &PostPage{Posts: []models.Post{}, Total: len(posts)}
+	for _, post := range posts {
+		if after != nil && !postBefore(sortBy, *after, post) {
+			continue
+		}
+		if filter.Limit > 0 && len(page.Posts) == filter.Limit {
+			page.Next = cursorFor(sortBy, page.Posts[len(page.Posts)-1])
+			break
+		}
+		page.Posts = append(page.Posts, post)
+	}
+	return page, nil
+}
+
+func (m *Memory) IncrementViewCount(id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	p, ok := m.posts[id]
+	if !ok {
+		return ErrNotFound
+	}
+	p.ViewCount++
+	return nil
+}
+
+func (m *Memory) UpdatePost(post *models.Post) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	p, ok := m.posts[post.ID]
+	if !ok {
+		return ErrNotFound
+	}
+	p.Title = post.Title
+	p.Description = post.Description
+	p.Price = post.Price
+	p.Category = post.Category
+	p.Type = post.Type
+	p.Location = post.Location
+	p.Condition = post.Condition
+	return nil
+}
+
+// setState moves p to state to and records the change, cancelling pending
+// sales when it leaves sold. Callers hold m.mu for writing.
+func (m *Memory) setState(p *models.Post, to, changedBy string, at time.Time) {
+	if p.State == models.PostSold && to != models.PostSold {
+		for _, t := range m.transactions {
+			if t.PostID == p.ID && t.Status == models.TransactionPending {
+				respondedAt := at
+				t.Status = models.TransactionCancelled
+				t.RespondedAt = &respondedAt
+			}
+		}
+	}
+	m.stateHistory = append(m.stateHistory, &models.PostStateChange{
+		ID:        uuid.New().String(),
+		PostID:    p.ID,
+		FromState: p.State,
+		ToState:   to,
+		ChangedBy: changedBy,
+		CreatedAt: at,
+	})
+	p.State = to
+}
+
+func (m *Memory) SetPostState(id, from, to, changedBy string, at time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	p, ok := m.posts[id]
+	if !ok || p.State != from {
+		return ErrNotFound
+	}
+	m.setState(p, to, changedBy, at)
+	return nil
+}
+
+func (m *Memory) SellPost(id, from, changedBy string, sale *models.Transaction, at time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	p, ok := m.posts[id]
+	if !ok || p.State != from {
+		return ErrNotFound
+	}
+	m.setState(p, models.PostSold, changedBy, at)
+	if sale != nil {
+		stored := *sale
+		m.transactions[sale.ID] = &stored
+	}
+	return nil
+}
+
+func (m *Memory) ListPostStateHistory(postID string) ([]models.PostStateChange, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	changes := []models.PostStateChange{}
+	for _, change := range m.stateHistory {
+		if change.PostID == postID {
+			changes = append(changes, *change)
+		}
+	}
+	return changes, nil
+}
+
+func (m *Memory) RenewPost(id, changedBy string, expiresAt, at time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	p, ok := m.posts[id]
+	if !ok || (p.State != models.PostActive && p.State != models.PostExpired) {
+		return ErrNotFound
+	}
+	if p.State == models.PostExpired {
+		m.setState(p, models.PostActive, changedBy, at)
+	}
+	p.ExpiresAt = expiresAt
+	return nil
+}
+
+func (m *Memory) BumpPost(id string, at, lastBumpedBefore time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	p, ok := m.posts[id]
+	if !ok || p.State != models.PostActive || !p.BumpedAt.Before(lastBumpedBefore) {
+		return ErrNotFound
+	}
+	p.BumpedAt = at
+	return nil
+}
+
+func (m *Memory) SchedulePost(id string, publishAt *time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	p, ok := m.posts[id]
+	if !ok || p.State != models.PostDraft {
+		return ErrNotFound
+	}
+	p.PublishAt = publishAt
+	return nil
+}
+
+func (m *Memory) ListScheduledPosts(now time.Time) ([]models.Post, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	posts := []models.Post{}
+	for _, p := range m.posts {
+		if p.State == models.PostDraft && p.PublishAt != nil && !p.PublishAt.After(now) {
+			posts = append(posts, m.withOwner(p))
+		}
+	}
+	sort.Slice(posts, func(i, j int) bool {
+		if !posts[i].PublishAt.Equal(*posts[j].PublishAt) {
+			return posts[i].PublishAt.Before(*posts[j].PublishAt)
+		}
+		return posts[i].ID < posts[j].ID
+	})
+	return posts, nil
+}
+
+func (m *Memory) PublishPost(id, changedBy string, expiresAt, at time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	p, ok := m.posts[id]
+	if !ok || p.State != models.PostDraft {
+		return ErrNotFound
+	}
+	m.setState(p, models.PostActive, changedBy, at)
+	p.BumpedAt = at
+	p.ExpiresAt = expiresAt
+	p.PublishAt = nil
+	return nil
+}
+
+func (m *Memory) ExpirePosts(now time.Time) ([]models.Post, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	expired := []models.Post{}
+	for _, p := range m.posts {
+		if p.State == models.PostActive && !p.ExpiresAt.After(now) {
+			m.setState(p, models.PostExpired, "", now)
+			expired = append(expired, m.withOwner(p))
+		}
+	}
+	return expired, nil
+}
+
+func (m *Memory) DeletePost(id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.posts[id]; !ok {
+		return ErrNotFound
+	}
+	delete(m.posts, id)
+	delete(m.media, id)
+
+	// Mirror ON DELETE CASCADE
+	delete(m.favorites, id)
+	for offerID, o := range m.offers {
+		if o.PostID == id {
+			delete(m.offers, offerID)
+			for _, msg := range m.messages {
+				if msg.OfferID == offerID {
+					msg.OfferID = ""
+				}
+			}
+		}
+	}
+	history := m.stateHistory[:0]
+	for _, change := range m.stateHistory {
+		if change.PostID != id {
+			history = append(history, change)
+		}
+	}
+	m.stateHistory = history
+	for _, review := range m.reviews {
+		if review.PostID == id {
+			review.PostID = ""
+		}
+	}
+	for _, t := range m.transactions {
+		if t.PostID == id {
+			t.PostID = ""
+		}
+	}
+	kept := m.notifications[:0]
+	for _, n := range m.notifications {
+		if n.PostID != id {
+			kept = append(kept, n)
+		}
+	}
+	m.notifications = kept
+	return nil
+}
+
+func (m *Memory) ListMediaForPost(postID string) ([]models.Media, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return append([]models.Media{}, m.media[postID]...), nil
+}
+
+func (m *Memory) ListMediaForPosts(postIDs []string) (map[string][]models.Media, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	byPost := make(map[string][]models.Media, len(postIDs))
+	for _, id := range postIDs {
+		if media := m.media[id]; len(media) > 0 {
+			byPost[id] = append([]models.Media{}, media...)
+		}
+	}
+	return byPost, nil
+}
+
+func (m *Memory) ReplacePostMedia(postID string, media []models.Media) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stored := make([]models.Media, len(media))
+	for i, item := range media {
+		stored[i] = models.Media{
+			ID:     uuid.New().String(),
+			PostID: postID,
+			URL:    item.URL,
+			Type:   item.Type,
+			Order:  i,
+		}
+	}
+	m.media[postID] = stored
+	return nil
+}
+
+func (m *Memory) SimilarPosts(filter PostFilter) ([]models.Post, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	posts := []models.Post{}
+	for _, p := range m.posts {
+		if !m.matchesFilter(filter, p) {
+			continue
+		}
+		score := wordSimilarity(filter.Search, p.Title)
+		if score < wordSimilarityThreshold {
+			continue
+		}
+		post := m.withOwner(p)
+		post.SearchRank = score
+		posts = append(posts, post)
+	}
+
+	sort.Slice(posts, func(i, j int) bool {
+		return postBefore(SortRelevance, posts[i], posts[j])
+	})
+	if filter.Limit > 0 && len(posts) > filter.Limit {
+		posts = posts[:filter.Limit]
+	}
+	return posts, nil
+}
+
+func (m *Memory) SuggestSearch(prefix string, limit int) ([]models.SearchSuggestion, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	prefix = strings.ToLower(strings.TrimSpace(prefix))
+
+	// Same scoring as the Postgres query: prefix matches first, then spelling
+	type scored struct {
+		models.SearchSuggestion
+		score float64
+	}
+	best := map[string]*scored{}
+	consider := func(text, kind string) {
+		lower := strings.ToLower(text)
+		score := similarity(text, prefix)
+		switch {
+		case strings.HasPrefix(lower, prefix):
+			score += 2
+		case strings.Contains(lower, " "+prefix):
+			score++
+		case score < similarityThreshold:
+			return
+		}
+		key := kind + "\x00" + lower
+		if cur, ok := best[key]; !ok || score > cur.score {
+			best[key] = &scored{models.SearchSuggestion{Text: text, Kind: kind}, score}
+		}
+	}
+	for _, p := range m.posts {
+		if (p.State != models.PostActive && p.State != models.PostReserved) || p.Hidden || m.userHidden(p.UserID) {
+			continue
+		}
+		consider(p.Title, "title")
+		consider(p.Category, "category")
+	}
+
+	ranked := make([]*scored, 0, len(best))
+	for _, s := range best {
+		ranked = append(ranked, s)
+	}
+	sort.Slice(ranked, func(i, j int) bool {
+		if ranked[i].score != ranked[j].score {
+			return ranked[i].score > ranked[j].score
+		}
+		return ranked[i].Text < ranked[j].Text
+	})
+
+	suggestions := []models.SearchSuggestion{}
+	for _, s := range ranked {
+		if len(suggestions) == limit {
+			break
+		}
+		suggestions = append(suggestions, s.SearchSuggestion)
+	}
+	return suggestions, nil
+}
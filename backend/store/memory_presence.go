@@ -0,0 +1,76 @@
+package store
+
+import (
+	"time"
+
+	"bruinmarket-backend/models"
+)
+
+type memoryConnection struct {
+	userID string
+	seenAt time.Time
+}
+
+func (m *Memory) AddConnection(connectionID, userID string, at time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.connections[connectionID] = &memoryConnection{userID: userID, seenAt: at}
+	m.touchUser(userID, at, true)
+	return nil
+}
+
+func (m *Memory) TouchConnection(connectionID, userID string, at time.Time, active bool) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if conn, ok := m.connections[connectionID]; ok {
+		conn.seenAt = at
+	}
+	m.touchUser(userID, at, active)
+	return nil
+}
+
+func (m *Memory) RemoveConnection(connectionID, userID string, at time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.connections, connectionID)
+	m.touchUser(userID, at, false)
+	return nil
+}
+
+// touchUser records that userID was seen, and if active also active, at at.
+// Callers hold m.mu.
+func (m *Memory) touchUser(userID string, at time.Time, active bool) {
+	u, ok := m.users[userID]
+	if !ok {
+		return
+	}
+	u.lastSeenAt = &at
+	if active {
+		u.lastActiveAt = &at
+	}
+}
+
+func (m *Memory) ListPresence(userIDs []string, staleBefore time.Time) ([]models.Presence, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	presence := []models.Presence{}
+	for _, id := range userIDs {
+		u, ok := m.users[id]
+		if !ok {
+			continue
+		}
+		p := models.Presence{UserID: id, LastSeenAt: u.lastSeenAt, LastActiveAt: u.lastActiveAt}
+		for _, conn := range m.connections {
+			if conn.userID == id && !conn.seenAt.Before(staleBefore) {
+				p.Online = true
+				break
+			}
+		}
+		presence = append(presence, p)
+	}
+	return presence, nil
+}
@@ -0,0 +1,125 @@
+package store
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"bruinmarket-backend/models"
+)
+
+// withReporterName returns a copy of the report with the reporter's name
+// filled in. Callers hold m.mu.
+func (m *Memory) withReporterName(r *models.Report) models.Report {
+	report := *r
+	if u, ok := m.users[r.ReporterID]; ok {
+		report.ReporterName = u.user.Name
+	}
+	return report
+}
+
+func (m *Memory) CreateReport(report *models.Report) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, r := range m.reports {
+		if r.Status == models.ReportOpen && r.TargetType == report.TargetType && r.TargetID == report.TargetID && r.ReporterID == report.ReporterID {
+			return ErrConflict
+		}
+	}
+	stored := *report
+	m.reports = append(m.reports, &stored)
+	return nil
+}
+
+func (m *Memory) GetReport(id string) (*models.Report, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, r := range m.reports {
+		if r.ID == id {
+			report := m.withReporterName(r)
+			return &report, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (m *Memory) ListReports(status string) ([]models.Report, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	reports := []models.Report{}
+	for _, r := range m.reports {
+		if r.Status == status {
+			reports = append(reports, m.withReporterName(r))
+		}
+	}
+	sort.SliceStable(reports, func(i, j int) bool {
+		return reports[i].CreatedAt.Before(reports[j].CreatedAt)
+	})
+	return reports, nil
+}
+
+func (m *Memory) CountReporters(targetType, targetID string) (int, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	reporters := map[string]bool{}
+	for _, r := range m.reports {
+		if r.Status == models.ReportOpen && r.TargetType == targetType && r.TargetID == targetID {
+			reporters[r.ReporterID] = true
+		}
+	}
+	return len(reporters), nil
+}
+
+func (m *Memory) ResolveReports(targetType, targetID, status, reviewedBy string, at time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	resolved := false
+	for _, r := range m.reports {
+		if r.Status == models.ReportOpen && r.TargetType == targetType && r.TargetID == targetID {
+			r.Status = status
+			r.ReviewedBy = reviewedBy
+			r.ReviewedAt = &at
+			resolved = true
+		}
+	}
+	if !resolved {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (m *Memory) SetTargetHidden(targetType, targetID string, hidden bool) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	switch targetType {
+	case models.ReportTargetPost:
+		p, ok := m.posts[targetID]
+		if !ok {
+			return ErrNotFound
+		}
+		p.Hidden = hidden
+	case models.ReportTargetUser:
+		u, ok := m.users[targetID]
+		if !ok {
+			return ErrNotFound
+		}
+		u.user.Hidden = hidden
+	case models.ReportTargetMessage:
+		for _, msg := range m.messages {
+			if msg.ID == targetID {
+				msg.Hidden = hidden
+				return nil
+			}
+		}
+		return ErrNotFound
+	default:
+		return fmt.Errorf("unknown report target %q", targetType)
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+package store
+
+import (
+	"sort"
+
+	"bruinmarket-backend/models"
+)
+
+func (m *Memory) CreateReview(review *models.Review) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.users[review.ReviewerID]; !ok {
+		return ErrNotFound
+	}
+	if _, ok := m.users[review.RevieweeID]; !ok {
+		return ErrNotFound
+	}
+	for _, r := range m.reviews {
+		if r.PostID == review.PostID && r.ReviewerID == review.ReviewerID {
+			return ErrConflict
+		}
+	}
+	stored := *review
+	m.reviews = append(m.reviews, &stored)
+	return nil
+}
+
+func (m *Memory) ListReviewsForUser(userID string) ([]models.Review, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	reviews := []models.Review{}
+	for _, r := range m.reviews {
+		if r.RevieweeID != userID {
+			continue
+		}
+		review := *r
+		if u, ok := m.users[r.ReviewerID]; ok {
+			review.ReviewerName = u.user.Name
+		}
+		reviews = append(reviews, review)
+	}
+	sort.Slice(reviews, func(i, j int) bool {
+		if !reviews[i].CreatedAt.Equal(reviews[j].CreatedAt) {
+			return reviews[i].CreatedAt.After(reviews[j].CreatedAt)
+		}
+		return reviews[i].ID > reviews[j].ID
+	})
+	return reviews, nil
+}
+
+func (m *Memory) GetReputation(userID string) (*models.Reputation, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	reputation := m.reputation(userID)
+	return &reputation, nil
+}
+
+// reputation averages the user's reviews. Callers hold m.mu.
+func (m *Memory) reputation(userID string) models.Reputation {
+	var reputation models.Reputation
+	total := 0
+	for _, r := range m.reviews {
+		if r.RevieweeID == userID {
+			total += r.Rating
+			reputation.ReviewCount++
+		}
+	}
+	if reputation.ReviewCount > 0 {
+		reputation.Score = float64(total) / float64(reputation.ReviewCount)
+	}
+	return reputation
+}
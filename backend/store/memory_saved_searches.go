@@ -0,0 +1,75 @@
+package store
+
+import (
+	"sort"
+
+	"bruinmarket-backend/models"
+)
+
+func (m *Memory) CreateSavedSearch(search *models.SavedSearch) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.users[search.UserID]; !ok {
+		return ErrNotFound
+	}
+	stored := *search
+	m.savedSearches[search.ID] = &stored
+	return nil
+}
+
+func (m *Memory) ListSavedSearches(userID string) ([]models.SavedSearch, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	searches := []models.SavedSearch{}
+	for _, s := range m.savedSearches {
+		if s.UserID == userID {
+			searches = append(searches, *s)
+		}
+	}
+	sort.Slice(searches, func(i, j int) bool {
+		return searches[i].CreatedAt.After(searches[j].CreatedAt)
+	})
+	return searches, nil
+}
+
+func (m *Memory) DeleteSavedSearch(id, userID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	s, ok := m.savedSearches[id]
+	if !ok || s.UserID != userID {
+		return ErrNotFound
+	}
+	delete(m.savedSearches, id)
+	return nil
+}
+
+func (m *Memory) ListSavedSearchesMatchingPost(postID string) ([]models.SavedSearch, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	p, ok := m.posts[postID]
+	if !ok {
+		return []models.SavedSearch{}, nil
+	}
+
+	searches := []models.SavedSearch{}
+	for _, s := range m.savedSearches {
+		if s.UserID == p.UserID {
+			continue
+		}
+		filter := PostFilter{Category: s.Category, Type: s.Type, Condition: s.Condition, MinPrice: s.MinPrice, MaxPrice: s.MaxPrice}
+		if !m.matchesFilter(filter, p) {
+			continue
+		}
+		if s.Search != "" {
+			if _, ok := matchSearch(parseSearch(s.Search), p); !ok {
+				continue
+			}
+		}
+		searches = append(searches, *s)
+	}
+	return searches, nil
+}
@@ -0,0 +1,184 @@
+package store
+
+import (
+	"strings"
+	"unicode"
+
+	"bruinmarket-backend/models"
+)
+
+// searchTerm is one word or "quoted phrase" from a search, possibly negated
+type searchTerm struct {
+	text   string
+	negate bool
+}
+
+// parseSearch approximates websearch_to_tsquery for the in-memory store:
+// quoted phrases, -exclusions, and every other term required. There is no
+// stemming.
+func parseSearch(q string) []searchTerm {
+	var terms []searchTerm
+	q = strings.ToLower(q)
+	for len(q) > 0 {
+		q = strings.TrimLeft(q, " \t")
+		if q == "" {
+			break
+		}
+
+		negate := false
+		if q[0] == '-' {
+			negate = true
+			q = q[1:]
+		}
+
+		var text string
+		if strings.HasPrefix(q, `"`) {
+			end := strings.Index(q[1:], `"`)
+			if end < 0 {
+				text, q = q[1:], ""
+			} else {
+				text, q = q[1:end+1], q[end+2:]
+			}
+		} else {
+			end := strings.IndexAny(q, " \t")
+			if end < 0 {
+				text, q = q, ""
+			} else {
+				text, q = q[:end], q[end:]
+			}
+		}
+
+		text = strings.TrimSpace(text)
+		if text == "" || (!negate && text == "or") {
+			continue
+		}
+		terms = append(terms, searchTerm{text: text, negate: negate})
+	}
+	return terms
+}
+
+// matchSearch reports whether the post matches the terms and scores it,
+// weighting the title above the description above category and condition
+func matchSearch(terms []searchTerm, p *models.Post) (float64, bool) {
+	fields := []struct {
+		text   string
+		weight float64
+	}{
+		{strings.ToLower(p.Title), 1.0},
+		{strings.ToLower(p.Description), 0.4},
+		{strings.ToLower(p.Category), 0.2},
+		{strings.ToLower(p.Condition), 0.2},
+	}
+
+	rank := 0.0
+	matched := false
+	for _, term := range terms {
+		score := 0.0
+		for _, f := range fields {
+			if strings.Contains(f.text, term.text) {
+				score += f.weight
+			}
+		}
+		if term.negate {
+			if score > 0 {
+				return 0, false
+			}
+			continue
+		}
+		if score == 0 {
+			return 0, false
+		}
+		rank += score
+		matched = true
+	}
+	return rank, matched
+}
+
+// highlightSearch wraps each case-insensitive match of a positive term in <mark>
+func highlightSearch(terms []searchTerm, text string) string {
+	lower := strings.ToLower(text)
+	marked := make([]bool, len(text))
+	for _, term := range terms {
+		if term.negate {
+			continue
+		}
+		for start := 0; ; {
+			i := strings.Index(lower[start:], term.text)
+			if i < 0 {
+				break
+			}
+			for j := start + i; j < start+i+len(term.text); j++ {
+				marked[j] = true
+			}
+			start += i + len(term.text)
+		}
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(text); i++ {
+		if marked[i] && (i == 0 || !marked[i-1]) {
+			b.WriteString("<mark>")
+		}
+		b.WriteByte(text[i])
+		if marked[i] && (i == len(text)-1 || !marked[i+1]) {
+			b.WriteString("</mark>")
+		}
+	}
+	return b.String()
+}
+
+// Same defaults as pg_trgm's similarity_threshold and word_similarity_threshold
+const (
+	similarityThreshold     = 0.3
+	wordSimilarityThreshold = 0.6
+)
+
+// trigrams splits s into words and returns their trigrams the way pg_trgm
+// does: lowercased, each word padded with two leading spaces and one trailing
+func trigrams(s string) map[string]bool {
+	set := map[string]bool{}
+	words := strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for _, word := range words {
+		padded := []rune("  " + word + " ")
+		for i := 0; i+3 <= len(padded); i++ {
+			set[string(padded[i:i+3])] = true
+		}
+	}
+	return set
+}
+
+func jaccard(a, b map[string]bool) float64 {
+	if len(a) == 0 && len(b) == 0 {
+		return 0
+	}
+	shared := 0
+	for t := range a {
+		if b[t] {
+			shared++
+		}
+	}
+	return float64(shared) / float64(len(a)+len(b)-shared)
+}
+
+// similarity mirrors pg_trgm's similarity()
+func similarity(a, b string) float64 {
+	return jaccard(trigrams(a), trigrams(b))
+}
+
+// wordSimilarity approximates pg_trgm's word_similarity(): the best match
+// between needle and any run of whole words in haystack
+func wordSimilarity(needle, haystack string) float64 {
+	want := trigrams(needle)
+	words := strings.Fields(haystack)
+	best := 0.0
+	for i := range words {
+		for j := i + 1; j <= len(words); j++ {
+			if score := jaccard(want, trigrams(strings.Join(words[i:j], " "))); score > best {
+				best = score
+			}
+		}
+	}
+	return best
+}
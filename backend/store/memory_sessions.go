@@ -0,0 +1,124 @@
+package store
+
+import (
+	"sort"
+	"time"
+
+	"bruinmarket-backend/models"
+)
+
+func (m *Memory) CreateSession(session *models.Session) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stored := *session
+	m.sessions[session.ID] = &stored
+	return nil
+}
+
+func (m *Memory) GetSessionByRefreshToken(tokenHash string) (*models.Session, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, s := range m.sessions {
+		if s.RefreshTokenHash == tokenHash {
+			session := *s
+			return &session, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (m *Memory) GetActiveSession(id string, now time.Time) (*models.Session, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	s, ok := m.sessions[id]
+	if !ok || !s.Active(now) {
+		return nil, ErrNotFound
+	}
+	session := *s
+	return &session, nil
+}
+
+func (m *Memory) RotateRefreshToken(id, oldHash, newHash, ipAddress, userAgent string, now time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	s, ok := m.sessions[id]
+	if !ok || s.RefreshTokenHash != oldHash {
+		return ErrNotFound
+	}
+	s.PreviousRefreshTokenHash = oldHash
+	s.RefreshTokenHash = newHash
+	s.LastSeenAt = now
+	s.IPAddress = ipAddress
+	s.UserAgent = userAgent
+	return nil
+}
+
+func (m *Memory) RevokeSessionByPreviousToken(tokenHash string, now time.Time) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for id, s := range m.sessions {
+		if s.PreviousRefreshTokenHash == tokenHash && s.RevokedAt == nil {
+			revokedAt := now
+			s.RevokedAt = &revokedAt
+			return id, nil
+		}
+	}
+	return "", ErrNotFound
+}
+
+func (m *Memory) TouchSession(id string, now time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if s, ok := m.sessions[id]; ok && s.LastSeenAt.Before(now.Add(-time.Minute)) {
+		s.LastSeenAt = now
+	}
+	return nil
+}
+
+func (m *Memory) ListActiveSessions(userID string, now time.Time) ([]models.Session, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	sessions := []models.Session{}
+	for _, s := range m.sessions {
+		if s.UserID == userID && s.Active(now) {
+			sessions = append(sessions, *s)
+		}
+	}
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].LastSeenAt.After(sessions[j].LastSeenAt)
+	})
+	return sessions, nil
+}
+
+func (m *Memory) RevokeSession(id, userID string, now time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	s, ok := m.sessions[id]
+	if !ok || s.UserID != userID || s.RevokedAt != nil {
+		return ErrNotFound
+	}
+	revokedAt := now
+	s.RevokedAt = &revokedAt
+	return nil
+}
+
+func (m *Memory) RevokeUserSessions(userID string, now time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, s := range m.sessions {
+		if s.UserID == userID && s.RevokedAt == nil {
+			revokedAt := now
+			s.RevokedAt = &revokedAt
+		}
+	}
+	return nil
+}
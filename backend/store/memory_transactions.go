@@ -0,0 +1,87 @@
+package store
+
+import (
+	"sort"
+	"time"
+
+	"bruinmarket-backend/models"
+)
+
+// withTransactionNames returns a copy of the transaction with the buyer and seller
+// names filled in. Callers hold m.mu.
+func (m *Memory) withTransactionNames(t *models.Transaction) models.Transaction {
+	transaction := *t
+	if u, ok := m.users[t.SellerID]; ok {
+		transaction.SellerName = u.user.Name
+	}
+	if u, ok := m.users[t.BuyerID]; ok {
+		transaction.BuyerName = u.user.Name
+	}
+	return transaction
+}
+
+func (m *Memory) GetTransaction(id string) (*models.Transaction, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	t, ok := m.transactions[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	transaction := m.withTransactionNames(t)
+	return &transaction, nil
+}
+
+// newestTransactionsFirst sorts like ORDER BY created_at DESC, id DESC
+func newestTransactionsFirst(transactions []models.Transaction) {
+	sort.Slice(transactions, func(i, j int) bool {
+		if !transactions[i].CreatedAt.Equal(transactions[j].CreatedAt) {
+			return transactions[i].CreatedAt.After(transactions[j].CreatedAt)
+		}
+		return transactions[i].ID > transactions[j].ID
+	})
+}
+
+func (m *Memory) ListTransactionsForUser(userID string) ([]models.Transaction, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	transactions := []models.Transaction{}
+	for _, t := range m.transactions {
+		if t.SellerID == userID || t.BuyerID == userID {
+			transactions = append(transactions, m.withTransactionNames(t))
+		}
+	}
+	newestTransactionsFirst(transactions)
+	return transactions, nil
+}
+
+func (m *Memory) RespondToTransaction(id, status string, at time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	t, ok := m.transactions[id]
+	if !ok || t.Status != models.TransactionPending {
+		return ErrNotFound
+	}
+	t.Status = status
+	t.RespondedAt = &at
+	return nil
+}
+
+func (m *Memory) FindSale(postID, userID string) (*models.Transaction, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	sales := []models.Transaction{}
+	for _, t := range m.transactions {
+		if t.PostID == postID && t.Status == models.TransactionConfirmed && (t.SellerID == userID || t.BuyerID == userID) {
+			sales = append(sales, m.withTransactionNames(t))
+		}
+	}
+	if len(sales) == 0 {
+		return nil, ErrNotFound
+	}
+	newestTransactionsFirst(sales)
+	return &sales[0], nil
+}
@@ -0,0 +1,214 @@
+package store
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"bruinmarket-backend/models"
+)
+
+type memoryUser struct {
+	user                 models.User
+	passwordResetHash    string
+	passwordResetExpires time.Time
+	lastSeenAt           *time.Time
+	lastActiveAt         *time.Time
+}
+
+func (m *Memory) CreateUser(user *models.User) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, existing := range m.users {
+		if strings.EqualFold(existing.user.Email, user.Email) {
+			return ErrConflict
+		}
+	}
+	m.users[user.ID] = &memoryUser{user: *user}
+	return nil
+}
+
+func (m *Memory) GetUserByID(id string) (*models.User, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if u, ok := m.users[id]; ok {
+		user := u.user
+		return &user, nil
+	}
+	return nil, ErrNotFound
+}
+
+func (m *Memory) GetUserByEmail(email string) (*models.User, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, u := range m.users {
+		if u.user.Email == email {
+			user := u.user
+			return &user, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (m *Memory) GetUserByVerificationToken(token string) (*models.User, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, u := range m.users {
+		if !u.user.EmailVerified && u.user.VerificationToken != nil && *u.user.VerificationToken == token {
+			user := u.user
+			return &user, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (m *Memory) SetVerificationToken(userID, token string, expires time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	u, ok := m.users[userID]
+	if !ok {
+		return ErrNotFound
+	}
+	u.user.VerificationToken = &token
+	u.user.VerificationTokenExpires = &expires
+	return nil
+}
+
+func (m *Memory) MarkEmailVerified(userID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	u, ok := m.users[userID]
+	if !ok {
+		return ErrNotFound
+	}
+	u.user.EmailVerified = true
+	u.user.VerificationToken = nil
+	u.user.VerificationTokenExpires = nil
+	return nil
+}
+
+func (m *Memory) SetPasswordResetToken(userID, tokenHash string, expires time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	u, ok := m.users[userID]
+	if !ok {
+		return ErrNotFound
+	}
+	u.passwordResetHash = tokenHash
+	u.passwordResetExpires = expires
+	return nil
+}
+
+func (m *Memory) ResetPassword(tokenHash, passwordHash string, now time.Time) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for id, u := range m.users {
+		if u.passwordResetHash != "" && u.passwordResetHash == tokenHash && u.passwordResetExpires.After(now) {
+			u.user.Password = passwordHash
+			u.user.TokenVersion++
+			u.passwordResetHash = ""
+			u.passwordResetExpires = time.Time{}
+			return id, nil
+		}
+	}
+	return "", ErrNotFound
+}
+
+func (m *Memory) UpdateYear(userID, year string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	u, ok := m.users[userID]
+	if !ok {
+		return ErrNotFound
+	}
+	u.user.Year = year
+	return nil
+}
+
+func (m *Memory) UpdateProfilePicture(userID, url string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	u, ok := m.users[userID]
+	if !ok {
+		return ErrNotFound
+	}
+	u.user.ProfilePictureURL = url
+	return nil
+}
+
+func (m *Memory) SuspendUser(userID string, at time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	u, ok := m.users[userID]
+	if !ok || u.user.SuspendedAt != nil {
+		return ErrNotFound
+	}
+	u.user.SuspendedAt = &at
+	return nil
+}
+
+func (m *Memory) UnsuspendUser(userID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	u, ok := m.users[userID]
+	if !ok || u.user.SuspendedAt == nil {
+		return ErrNotFound
+	}
+	u.user.SuspendedAt = nil
+	return nil
+}
+
+func (m *Memory) SetUserRole(userID, role string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	u, ok := m.users[userID]
+	if !ok {
+		return ErrNotFound
+	}
+	u.user.Role = role
+	u.user.TokenVersion++
+	return nil
+}
+
+func (m *Memory) ListUsers(filter UserFilter) ([]models.User, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	search := strings.ToLower(filter.Search)
+	users := []models.User{}
+	for _, u := range m.users {
+		if search != "" && !strings.Contains(strings.ToLower(u.user.Email), search) && !strings.Contains(strings.ToLower(u.user.Name), search) {
+			continue
+		}
+		if filter.Role != "" && u.user.Role != filter.Role {
+			continue
+		}
+		if filter.Suspended && u.user.SuspendedAt == nil {
+			continue
+		}
+		users = append(users, u.user)
+	}
+	sort.Slice(users, func(i, j int) bool {
+		if !users[i].CreatedAt.Equal(users[j].CreatedAt) {
+			return users[i].CreatedAt.After(users[j].CreatedAt)
+		}
+		return users[i].ID > users[j].ID
+	})
+	if filter.Limit > 0 && len(users) > filter.Limit {
+		users = users[:filter.Limit]
+	}
+	return users, nil
+}
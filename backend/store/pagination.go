@@ -0,0 +1,148 @@
+package store
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"time"
+
+	"bruinmarket-backend/models"
+)
+
+// PostSort is an ordering for ListPosts
+type PostSort string
+
+const (
+	// SortNewest orders by when the post was created or last bumped
+	SortNewest     PostSort = "newest"
+	SortPriceAsc   PostSort = "price_asc"
+	SortPriceDesc  PostSort = "price_desc"
+	SortMostViewed PostSort = "most_viewed"
+	SortEndingSoon PostSort = "ending_soon"
+	// SortRelevance ranks search matches; it requires PostFilter.Search
+	SortRelevance PostSort = "relevance"
+)
+
+// ErrInvalidCursor is returned when a cursor can't be decoded
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// ParsePostSort validates a sort name; empty means SortNewest
+func ParsePostSort(s string) (PostSort, bool) {
+	switch PostSort(s) {
+	case "":
+		return SortNewest, true
+	case SortNewest, SortPriceAsc, SortPriceDesc, SortMostViewed, SortEndingSoon, SortRelevance:
+		return PostSort(s), true
+	}
+	return "", false
+}
+
+// PostCursor is the sort key and ID of the last post on a page
+type PostCursor struct {
+	Sort PostSort `json:"s"`
+	Key  string   `json:"k"`
+	ID   string   `json:"id"`
+}
+
+// cursorFor builds the cursor pointing just past post
+func cursorFor(sort PostSort, post models.Post) *PostCursor {
+	c := &PostCursor{Sort: sort, ID: post.ID}
+	switch sort {
+	case SortPriceAsc, SortPriceDesc:
+		c.Key = strconv.FormatFloat(post.Price, 'f', -1, 64)
+	case SortMostViewed:
+		c.Key = strconv.Itoa(post.ViewCount)
+	case SortEndingSoon:
+		c.Key = post.ExpiresAt.Format(time.RFC3339Nano)
+	case SortRelevance:
+		c.Key = strconv.FormatFloat(post.SearchRank, 'g', -1, 64)
+	default:
+		c.Key = post.BumpedAt.Format(time.RFC3339Nano)
+	}
+	return c
+}
+
+// keyPost turns the cursor back into a post carrying just the sort key and ID
+func (c *PostCursor) keyPost() (models.Post, error) {
+	post := models.Post{ID: c.ID}
+	var err error
+	switch c.Sort {
+	case SortPriceAsc, SortPriceDesc:
+		post.Price, err = strconv.ParseFloat(c.Key, 64)
+	case SortMostViewed:
+		post.ViewCount, err = strconv.Atoi(c.Key)
+	case SortEndingSoon:
+		post.ExpiresAt, err = time.Parse(time.RFC3339Nano, c.Key)
+	case SortRelevance:
+		post.SearchRank, err = strconv.ParseFloat(c.Key, 64)
+	default:
+		post.BumpedAt, err = time.Parse(time.RFC3339Nano, c.Key)
+	}
+	if err != nil {
+		return post, ErrInvalidCursor
+	}
+	return post, nil
+}
+
+// Encode returns the opaque string handed to API clients
+func (c *PostCursor) Encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodePostCursor parses a string produced by PostCursor.Encode
+func DecodePostCursor(s string) (*PostCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var c PostCursor
+	if err := json.Unmarshal(data, &c); err != nil || c.ID == "" {
+		return nil, ErrInvalidCursor
+	}
+	if _, ok := ParsePostSort(string(c.Sort)); !ok {
+		return nil, ErrInvalidCursor
+	}
+	if _, err := c.keyPost(); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// postBefore reports whether a sorts ahead of b, breaking ties on ID in the
+// same direction as the sort
+func postBefore(sort PostSort, a, b models.Post) bool {
+	switch sort {
+	case SortPriceAsc:
+		if a.Price != b.Price {
+			return a.Price < b.Price
+		}
+		return a.ID < b.ID
+	case SortPriceDesc:
+		if a.Price != b.Price {
+			return a.Price > b.Price
+		}
+		return a.ID > b.ID
+	case SortMostViewed:
+		if a.ViewCount != b.ViewCount {
+			return a.ViewCount > b.ViewCount
+		}
+		return a.ID > b.ID
+	case SortEndingSoon:
+		if !a.ExpiresAt.Equal(b.ExpiresAt) {
+			return a.ExpiresAt.Before(b.ExpiresAt)
+		}
+		return a.ID < b.ID
+	case SortRelevance:
+		if a.SearchRank != b.SearchRank {
+			return a.SearchRank > b.SearchRank
+		}
+		return a.ID > b.ID
+	default:
+		if !a.BumpedAt.Equal(b.BumpedAt) {
+			return a.BumpedAt.After(b.BumpedAt)
+		}
+		return a.ID > b.ID
+	}
+}
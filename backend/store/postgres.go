@@ -0,0 +1,71 @@
+package store
+
+import (
+	"database/sql"
+
+	"github.com/lib/pq"
+)
+
+// Postgres implements the stores on top of a *sql.DB
+type Postgres struct {
+	db *sql.DB
+}
+
+func NewPostgres(db *sql.DB) *Postgres {
+	return &Postgres{db: db}
+}
+
+// rowScanner is satisfied by both *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// execer is satisfied by both *sql.DB and *sql.Tx
+type execer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+}
+
+// nullIfEmpty stores empty strings as NULL, e.g. saved search fields that
+// match anything
+func nullIfEmpty(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
+
+// requireRow maps an UPDATE/DELETE that touched nothing to ErrNotFound
+func requireRow(result sql.Result, err error) error {
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// isUniqueViolation reports whether err is Postgres error 23505
+func isUniqueViolation(err error) bool {
+	pqErr, ok := err.(*pq.Error)
+	return ok && pqErr.Code == "23505"
+}
+
+var (
+	_ UserStore         = (*Postgres)(nil)
+	_ SessionStore      = (*Postgres)(nil)
+	_ PostStore         = (*Postgres)(nil)
+	_ MediaStore        = (*Postgres)(nil)
+	_ ConversationStore = (*Postgres)(nil)
+	_ MessageStore      = (*Postgres)(nil)
+	_ SavedSearchStore  = (*Postgres)(nil)
+	_ NotificationStore = (*Postgres)(nil)
+	_ FavoriteStore     = (*Postgres)(nil)
+	_ OfferStore        = (*Postgres)(nil)
+	_ ReviewStore       = (*Postgres)(nil)
+	_ TransactionStore  = (*Postgres)(nil)
+	_ ReportStore       = (*Postgres)(nil)
+	_ CategoryStore     = (*Postgres)(nil)
+	_ PresenceStore     = (*Postgres)(nil)
+)
@@ -0,0 +1,65 @@
+package store
+
+import (
+	"bruinmarket-backend/models"
+)
+
+func (s *Postgres) ListCategories() ([]models.Category, error) {
+	rows, err := s.db.Query("SELECT name, position FROM categories ORDER BY position, name")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	categories := []models.Category{}
+	for rows.Next() {
+		var category models.Category
+		if err := rows.Scan(&category.Name, &category.Position); err != nil {
+			return nil, err
+		}
+		categories = append(categories, category)
+	}
+	return categories, rows.Err()
+}
+
+func (s *Postgres) CreateCategory(name string) (*models.Category, error) {
+	category := models.Category{Name: name}
+	err := s.db.QueryRow(
+		"INSERT INTO categories (name, position) SELECT $1, COALESCE(MAX(position), 0) + 1 FROM categories RETURNING position",
+		name,
+	).Scan(&category.Position)
+	if isUniqueViolation(err) {
+		return nil, ErrConflict
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &category, nil
+}
+
+func (s *Postgres) RenameCategory(oldName, newName string) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = requireRow(tx.Exec("UPDATE categories SET name = $1 WHERE name = $2", newName, oldName))
+	if isUniqueViolation(err) {
+		return ErrConflict
+	}
+	if err != nil {
+		return err
+	}
+	if _, err := tx.Exec("UPDATE posts SET category = $1 WHERE category = $2", newName, oldName); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("UPDATE saved_searches SET category = $1 WHERE category = $2", newName, oldName); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s *Postgres) DeleteCategory(name string) error {
+	return requireRow(s.db.Exec("DELETE FROM categories WHERE name = $1", name))
+}
@@ -0,0 +1,142 @@
+package store
+
+import (
+	"database/sql"
+	"time"
+
+	"bruinmarket-backend/models"
+)
+
+const conversationColumns = `c.id, c.user1_id, c.user2_id, u1.name, u2.name, 
+	COALESCE(u1.profile_picture_url, ''), COALESCE(u2.profile_picture_url, ''), 
+	COALESCE(c.last_message, ''), COALESCE(c.last_message_time, c.created_at), c.created_at`
+
+const conversationJoins = ` FROM conversations c 
+	JOIN users u1 ON c.user1_id = u1.id 
+	JOIN users u2 ON c.user2_id = u2.id`
+
+func scanConversation(row rowScanner) (*models.Conversation, error) {
+	var conv models.Conversation
+	err := row.Scan(&conv.ID, &conv.User1ID, &conv.User2ID,
+		&conv.User1Name, &conv.User2Name,
+		&conv.User1PictureURL, &conv.User2PictureURL,
+		&conv.LastMessage, &conv.LastMessageTime, &conv.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &conv, nil
+}
+
+func (s *Postgres) CreateConversation(conversation *models.Conversation) error {
+	_, err := s.db.Exec(
+		"INSERT INTO conversations (id, user1_id, user2_id, created_at) VALUES ($1, $2, $3, $4)",
+		conversation.ID, conversation.User1ID, conversation.User2ID, conversation.CreatedAt,
+	)
+	if isUniqueViolation(err) {
+		return ErrConflict
+	}
+	return err
+}
+
+func (s *Postgres) GetConversation(id string) (*models.Conversation, error) {
+	return scanConversation(s.db.QueryRow("SELECT "+conversationColumns+conversationJoins+" WHERE c.id = $1", id))
+}
+
+func (s *Postgres) FindConversation(userA, userB string) (*models.Conversation, error) {
+	return scanConversation(s.db.QueryRow(
+		"SELECT "+conversationColumns+conversationJoins+
+			" WHERE (c.user1_id = $1 AND c.user2_id = $2) OR (c.user1_id = $2 AND c.user2_id = $1)",
+		userA, userB,
+	))
+}
+
+func (s *Postgres) ListConversations(userID string) ([]models.Conversation, error) {
+	rows, err := s.db.Query(
+		"SELECT "+conversationColumns+conversationJoins+` 
+		 WHERE c.user1_id = $1 OR c.user2_id = $1 
+		 ORDER BY COALESCE(c.last_message_time, c.created_at) DESC`,
+		userID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	conversations := []models.Conversation{}
+	for rows.Next() {
+		conv, err := scanConversation(rows)
+		if err != nil {
+			return nil, err
+		}
+		conversations = append(conversations, *conv)
+	}
+	return conversations, rows.Err()
+}
+
+func (s *Postgres) UpdateLastMessage(id, content string, at time.Time) error {
+	return requireRow(s.db.Exec(
+		"UPDATE conversations SET last_message = $1, last_message_time = $2 WHERE id = $3",
+		content, at, id,
+	))
+}
+
+func (s *Postgres) CreateMessage(message *models.Message) error {
+	_, err := s.db.Exec(
+		"INSERT INTO messages (id, conversation_id, sender_id, receiver_id, type, content, offer_id, created_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)",
+		message.ID, message.ConversationID, message.SenderID, message.ReceiverID, message.Type, message.Content, nullIfEmpty(message.OfferID), message.CreatedAt,
+	)
+	return err
+}
+
+const messageColumns = `id, conversation_id, sender_id, receiver_id, type, content, COALESCE(offer_id, ''), read, hidden, created_at`
+
+func scanMessage(row rowScanner) (*models.Message, error) {
+	var msg models.Message
+	err := row.Scan(&msg.ID, &msg.ConversationID, &msg.SenderID, &msg.ReceiverID, &msg.Type, &msg.Content, &msg.OfferID, &msg.Read, &msg.Hidden, &msg.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &msg, nil
+}
+
+func (s *Postgres) GetMessage(id string) (*models.Message, error) {
+	return scanMessage(s.db.QueryRow("SELECT "+messageColumns+" FROM messages WHERE id = $1", id))
+}
+
+func (s *Postgres) ListMessages(conversationID string) ([]models.Message, error) {
+	rows, err := s.db.Query(
+		`SELECT `+messageColumns+` 
+		 FROM messages 
+		 WHERE conversation_id = $1 
+		 ORDER BY created_at ASC`,
+		conversationID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	messages := []models.Message{}
+	for rows.Next() {
+		msg, err := scanMessage(rows)
+		if err != nil {
+			return nil, err
+		}
+		messages = append(messages, *msg)
+	}
+	return messages, rows.Err()
+}
+
+func (s *Postgres) MarkMessagesRead(conversationID, receiverID string) error {
+	_, err := s.db.Exec(
+		"UPDATE messages SET read = TRUE WHERE conversation_id = $1 AND receiver_id = $2 AND read = FALSE",
+		conversationID, receiverID,
+	)
+	return err
+}
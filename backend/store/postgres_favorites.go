@@ -0,0 +1,63 @@
+package store
+
+import (
+	"time"
+
+	"bruinmarket-backend/models"
+)
+
+func (s *Postgres) AddFavorite(userID, postID string, at time.Time) error {
+	_, err := s.db.Exec(
+		"INSERT INTO favorites (user_id, post_id, created_at) VALUES ($1, $2, $3) ON CONFLICT DO NOTHING",
+		userID, postID, at,
+	)
+	return err
+}
+
+func (s *Postgres) RemoveFavorite(userID, postID string) error {
+	return requireRow(s.db.Exec("DELETE FROM favorites WHERE user_id = $1 AND post_id = $2", userID, postID))
+}
+
+func (s *Postgres) ListFavoritePosts(userID string) ([]models.Post, error) {
+	rows, err := s.db.Query(
+		`SELECT `+postColumns+` 
+		FROM favorites fav 
+		JOIN posts p ON fav.post_id = p.id 
+		JOIN users u ON p.user_id = u.id 
+		WHERE fav.user_id = $1 
+		ORDER BY fav.created_at DESC`,
+		userID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	posts := []models.Post{}
+	for rows.Next() {
+		post, err := scanPost(rows)
+		if err != nil {
+			return nil, err
+		}
+		posts = append(posts, *post)
+	}
+	return posts, rows.Err()
+}
+
+func (s *Postgres) ListPostWatchers(postID string) ([]string, error) {
+	rows, err := s.db.Query("SELECT user_id FROM favorites WHERE post_id = $1", postID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	userIDs := []string{}
+	for rows.Next() {
+		var userID string
+		if err := rows.Scan(&userID); err != nil {
+			return nil, err
+		}
+		userIDs = append(userIDs, userID)
+	}
+	return userIDs, rows.Err()
+}
@@ -0,0 +1,79 @@
+package store
+
+import (
+	"bruinmarket-backend/models"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+func (s *Postgres) ListMediaForPost(postID string) ([]models.Media, error) {
+	rows, err := s.db.Query(
+		"SELECT id, url, type, order_index FROM media WHERE post_id = $1 ORDER BY order_index",
+		postID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	media := []models.Media{}
+	for rows.Next() {
+		var m models.Media
+		if err := rows.Scan(&m.ID, &m.URL, &m.Type, &m.Order); err != nil {
+			return nil, err
+		}
+		m.PostID = postID
+		media = append(media, m)
+	}
+	return media, rows.Err()
+}
+
+func (s *Postgres) ListMediaForPosts(postIDs []string) (map[string][]models.Media, error) {
+	byPost := make(map[string][]models.Media, len(postIDs))
+	if len(postIDs) == 0 {
+		return byPost, nil
+	}
+
+	rows, err := s.db.Query(
+		"SELECT id, post_id, url, type, order_index FROM media WHERE post_id = ANY($1) ORDER BY post_id, order_index",
+		pq.Array(postIDs),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var m models.Media
+		if err := rows.Scan(&m.ID, &m.PostID, &m.URL, &m.Type, &m.Order); err != nil {
+			return nil, err
+		}
+		byPost[m.PostID] = append(byPost[m.PostID], m)
+	}
+	return byPost, rows.Err()
+}
+
+func (s *Postgres) ReplacePostMedia(postID string, media []models.Media) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM media WHERE post_id = $1", postID); err != nil {
+		return err
+	}
+
+	for i, m := range media {
+		_, err := tx.Exec(
+			"INSERT INTO media (id, post_id, url, type, order_index) VALUES ($1, $2, $3, $4, $5)",
+			uuid.New().String(), postID, m.URL, m.Type, i,
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,94 @@
+package store
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"bruinmarket-backend/migrations"
+	"bruinmarket-backend/models"
+
+	"github.com/google/uuid"
+	_ "github.com/lib/pq"
+)
+
+// openTestPostgres connects to TEST_DATABASE_URL and migrates it, skipping
+// when no database is configured
+func openTestPostgres(tb testing.TB) *sql.DB {
+	tb.Helper()
+	url := os.Getenv("TEST_DATABASE_URL")
+	if url == "" {
+		tb.Skip("TEST_DATABASE_URL not set")
+	}
+
+	db, err := sql.Open("postgres", url)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	tb.Cleanup(func() { db.Close() })
+
+	migrator, err := migrations.New(db)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	if err := migrator.Up(); err != nil {
+		tb.Fatal(err)
+	}
+	return db
+}
+
+// BenchmarkLoadMedia compares fetching media one post at a time with the
+// batched query used by the post list handlers, for a page of 1,000 posts:
+//
+//	TEST_DATABASE_URL=postgres://localhost/bruinmarket_test?sslmode=disable go test -bench LoadMedia ./store/
+func BenchmarkLoadMedia(b *testing.B) {
+	db := openTestPostgres(b)
+	s := NewPostgres(db)
+
+	user := &models.User{ID: uuid.New().String(), Email: uuid.New().String() + "@ucla.edu", Name: "Bench", Password: "x", CreatedAt: time.Now()}
+	if err := s.CreateUser(user); err != nil {
+		b.Fatal(err)
+	}
+	b.Cleanup(func() { db.Exec("DELETE FROM users WHERE id = $1", user.ID) })
+
+	const numPosts = 1000
+	ids := make([]string, numPosts)
+	media := []models.Media{{URL: "/uploads/a.jpg", Type: "image"}, {URL: "/uploads/b.jpg", Type: "image"}, {URL: "/uploads/c.mp4", Type: "video"}}
+	for i := range ids {
+		post := &models.Post{
+			ID: uuid.New().String(), UserID: user.ID, Title: fmt.Sprintf("Post %d", i), Description: "bench",
+			Category: "Other", Type: "selling", CreatedAt: time.Now(), ExpiresAt: time.Now().Add(time.Hour),
+		}
+		if err := s.CreatePost(post); err != nil {
+			b.Fatal(err)
+		}
+		if err := s.ReplacePostMedia(post.ID, media); err != nil {
+			b.Fatal(err)
+		}
+		ids[i] = post.ID
+	}
+
+	b.Run("per_post", func(b *testing.B) {
+		for n := 0; n < b.N; n++ {
+			for _, id := range ids {
+				if _, err := s.ListMediaForPost(id); err != nil {
+					b.Fatal(err)
+				}
+			}
+		}
+	})
+
+	b.Run("batched", func(b *testing.B) {
+		for n := 0; n < b.N; n++ {
+			byPost, err := s.ListMediaForPosts(ids)
+			if err != nil {
+				b.Fatal(err)
+			}
+			if len(byPost) != numPosts {
+				b.Fatalf("got media for %d posts, want %d", len(byPost), numPosts)
+			}
+		}
+	})
+}
@@ -0,0 +1,53 @@
+package store
+
+import (
+	"bruinmarket-backend/models"
+)
+
+func (s *Postgres) CreateNotification(n *models.Notification) error {
+	_, err := s.db.Exec(
+		"INSERT INTO notifications (id, user_id, type, message, post_id, read, created_at) VALUES ($1, $2, $3, $4, $5, $6, $7)",
+		n.ID, n.UserID, n.Type, n.Message, nullIfEmpty(n.PostID), n.Read, n.CreatedAt,
+	)
+	return err
+}
+
+func (s *Postgres) ListNotifications(userID string, limit int) ([]models.Notification, error) {
+	rows, err := s.db.Query(
+		`SELECT id, user_id, type, message, COALESCE(post_id, ''), read, created_at 
+		FROM notifications 
+		WHERE user_id = $1 
+		ORDER BY created_at DESC 
+		LIMIT $2`,
+		userID, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	notifications := []models.Notification{}
+	for rows.Next() {
+		var n models.Notification
+		if err := rows.Scan(&n.ID, &n.UserID, &n.Type, &n.Message, &n.PostID, &n.Read, &n.CreatedAt); err != nil {
+			return nil, err
+		}
+		notifications = append(notifications, n)
+	}
+	return notifications, rows.Err()
+}
+
+func (s *Postgres) CountUnreadNotifications(userID string) (int, error) {
+	var count int
+	err := s.db.QueryRow("SELECT COUNT(*) FROM notifications WHERE user_id = $1 AND NOT read", userID).Scan(&count)
+	return count, err
+}
+
+func (s *Postgres) MarkNotificationRead(id, userID string) error {
+	return requireRow(s.db.Exec("UPDATE notifications SET read = TRUE WHERE id = $1 AND user_id = $2", id, userID))
+}
+
+func (s *Postgres) MarkAllNotificationsRead(userID string) error {
+	_, err := s.db.Exec("UPDATE notifications SET read = TRUE WHERE user_id = $1 AND NOT read", userID)
+	return err
+}
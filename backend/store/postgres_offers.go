@@ -0,0 +1,162 @@
+package store
+
+import (
+	"database/sql"
+	"time"
+
+	"bruinmarket-backend/models"
+)
+
+const offerColumns = `o.id, o.post_id, p.title, o.buyer_id, b.name, o.seller_id, s.name, o.proposed_by, 
+	COALESCE(o.parent_id, ''), o.amount, COALESCE(o.message, ''), o.status, o.expires_at, o.responded_at, o.created_at`
+
+const offerJoins = ` JOIN posts p ON o.post_id = p.id 
+	JOIN users b ON o.buyer_id = b.id 
+	JOIN users s ON o.seller_id = s.id`
+
+func scanOffer(row rowScanner) (*models.Offer, error) {
+	var o models.Offer
+	var respondedAt sql.NullTime
+	err := row.Scan(&o.ID, &o.PostID, &o.PostTitle, &o.BuyerID, &o.BuyerName, &o.SellerID, &o.SellerName, &o.ProposedBy,
+		&o.ParentID, &o.Amount, &o.Message, &o.Status, &o.ExpiresAt, &respondedAt, &o.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if respondedAt.Valid {
+		o.RespondedAt = &respondedAt.Time
+	}
+	return &o, nil
+}
+
+func scanOffers(rows *sql.Rows) ([]models.Offer, error) {
+	defer rows.Close()
+
+	offers := []models.Offer{}
+	for rows.Next() {
+		o, err := scanOffer(rows)
+		if err != nil {
+			return nil, err
+		}
+		offers = append(offers, *o)
+	}
+	return offers, rows.Err()
+}
+
+func insertOffer(exec execer, o *models.Offer) error {
+	_, err := exec.Exec(
+		`INSERT INTO offers (id, post_id, buyer_id, seller_id, proposed_by, parent_id, amount, message, status, expires_at, created_at) 
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)`,
+		o.ID, o.PostID, o.BuyerID, o.SellerID, o.ProposedBy, nullIfEmpty(o.ParentID), o.Amount, nullIfEmpty(o.Message), o.Status, o.ExpiresAt, o.CreatedAt,
+	)
+	return err
+}
+
+func (s *Postgres) CreateOffer(offer *models.Offer) error {
+	err := insertOffer(s.db, offer)
+	if isUniqueViolation(err) {
+		return ErrConflict
+	}
+	return err
+}
+
+func (s *Postgres) GetOffer(id string) (*models.Offer, error) {
+	return scanOffer(s.db.QueryRow("SELECT "+offerColumns+" FROM offers o"+offerJoins+" WHERE o.id = $1", id))
+}
+
+func (s *Postgres) ListOffersForPost(postID string) ([]models.Offer, error) {
+	rows, err := s.db.Query("SELECT "+offerColumns+" FROM offers o"+offerJoins+" WHERE o.post_id = $1 ORDER BY o.created_at DESC", postID)
+	if err != nil {
+		return nil, err
+	}
+	return scanOffers(rows)
+}
+
+func (s *Postgres) ListOffersForUser(userID string) ([]models.Offer, error) {
+	rows, err := s.db.Query(
+		"SELECT "+offerColumns+" FROM offers o"+offerJoins+" WHERE o.buyer_id = $1 OR o.seller_id = $1 ORDER BY o.created_at DESC",
+		userID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return scanOffers(rows)
+}
+
+func (s *Postgres) ResolveOffer(id, status string, at time.Time) error {
+	return requireRow(s.db.Exec(
+		"UPDATE offers SET status = $1, responded_at = $2 WHERE id = $3 AND status = 'pending'",
+		status, at, id,
+	))
+}
+
+func (s *Postgres) AcceptOffer(id string, reserve bool, at time.Time) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var postID, sellerID string
+	err = tx.QueryRow(
+		"UPDATE offers SET status = 'accepted', responded_at = $1 WHERE id = $2 AND status = 'pending' RETURNING post_id, seller_id",
+		at, id,
+	).Scan(&postID, &sellerID)
+	if err == sql.ErrNoRows {
+		return ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if reserve {
+		err := requireRow(tx.Exec("UPDATE posts SET state = $1 WHERE id = $2 AND state = $3", models.PostReserved, postID, models.PostActive))
+		if err == ErrNotFound {
+			return ErrConflict
+		}
+		if err != nil {
+			return err
+		}
+		if err := insertPostStateChange(tx, postID, models.PostActive, models.PostReserved, sellerID, at); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (s *Postgres) CounterOffer(parentID string, counter *models.Offer) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = requireRow(tx.Exec(
+		"UPDATE offers SET status = 'countered', responded_at = $1 WHERE id = $2 AND status = 'pending'",
+		counter.CreatedAt, parentID,
+	))
+	if err != nil {
+		return err
+	}
+	if err := insertOffer(tx, counter); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s *Postgres) ExpireOffers(now time.Time) ([]models.Offer, error) {
+	rows, err := s.db.Query(
+		`WITH o AS (
+			UPDATE offers SET status = 'expired', responded_at = $1 
+			WHERE status = 'pending' AND expires_at <= $1 
+			RETURNING *
+		) 
+		SELECT `+offerColumns+` FROM o`+offerJoins+` ORDER BY o.created_at`,
+		now,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return scanOffers(rows)
+}
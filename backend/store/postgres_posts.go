@@ -0,0 +1,495 @@
+package store
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"bruinmarket-backend/models"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+const postColumns = `p.id, p.user_id, u.email, u.name, COALESCE(u.profile_picture_url, ''), 
+	(SELECT COALESCE(AVG(r.rating), 0) FROM reviews r WHERE r.reviewee_id = p.user_id), 
+	(SELECT COUNT(*) FROM reviews r WHERE r.reviewee_id = p.user_id), p.title, p.description, p.price, 
+	p.category, p.type, COALESCE(p.location, ''), COALESCE(p.condition, ''), p.state, p.view_count, 
+	(SELECT COUNT(*) FROM favorites f WHERE f.post_id = p.id), p.created_at, p.bumped_at, p.expires_at, p.publish_at, p.hidden`
+
+// hiddenUsers selects users whose posts are kept out of public lists
+const hiddenUsers = `SELECT id FROM users WHERE hidden OR suspended_at IS NOT NULL`
+
+// Extra columns selected when searching; %[1]s is the tsquery
+const postSearchColumns = `, ts_rank_cd(p.search_vector, %[1]s), 
+	ts_headline('english', p.title, %[1]s, 'StartSel="<mark>", StopSel="</mark>", HighlightAll=true'), 
+	ts_headline('english', p.description, %[1]s, 'StartSel="<mark>", StopSel="</mark>", MaxFragments=2, MaxWords=20, MinWords=8')`
+
+// scanPost reads postColumns followed by any extra columns into post
+func scanPost(row rowScanner, extra ...func(post *models.Post) []interface{}) (*models.Post, error) {
+	var post models.Post
+	dest := []interface{}{&post.ID, &post.UserID, &post.UserEmail, &post.UserName, &post.UserProfilePictureURL,
+		&post.SellerReputation, &post.SellerReviewCount, &post.Title, &post.Description,
+		&post.Price, &post.Category, &post.Type, &post.Location, &post.Condition, &post.State, &post.ViewCount, &post.FavoriteCount, &post.CreatedAt, &post.BumpedAt, &post.ExpiresAt, &post.PublishAt, &post.Hidden}
+	for _, fields := range extra {
+		dest = append(dest, fields(&post)...)
+	}
+	err := row.Scan(dest...)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	post.SetState(post.State)
+	return &post, nil
+}
+
+func (s *Postgres) CreatePost(post *models.Post) error {
+	_, err := s.db.Exec(
+		"INSERT INTO posts (id, user_id, title, description, price, category, type, location, condition, state, created_at, bumped_at, expires_at, publish_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)",
+		post.ID, post.UserID, post.Title, post.Description, post.Price, post.Category, post.Type, post.Location, post.Condition, post.State, post.CreatedAt, post.BumpedAt, post.ExpiresAt, post.PublishAt,
+	)
+	return err
+}
+
+func (s *Postgres) GetPost(id string) (*models.Post, error) {
+	return scanPost(s.db.QueryRow(
+		`SELECT `+postColumns+` 
+		FROM posts p 
+		JOIN users u ON p.user_id = u.id 
+		WHERE p.id = $1`,
+		id,
+	))
+}
+
+func (s *Postgres) GetPostOwner(id string) (string, error) {
+	var ownerID string
+	err := s.db.QueryRow("SELECT user_id FROM posts WHERE id = $1", id).Scan(&ownerID)
+	if err == sql.ErrNoRows {
+		return "", ErrNotFound
+	}
+	return ownerID, err
+}
+
+// postSortColumns maps each sort to its key column and direction
+var postSortColumns = map[PostSort]struct {
+	column string
+	desc   bool
+}{
+	SortNewest:     {"p.bumped_at", true},
+	SortPriceAsc:   {"p.price", false},
+	SortPriceDesc:  {"p.price", true},
+	SortMostViewed: {"p.view_count", true},
+	SortEndingSoon: {"p.expires_at", false},
+	// The rank expression depends on the query, see ListPosts
+	SortRelevance: {"", true},
+}
+
+func searchFields(post *models.Post) []interface{} {
+	return []interface{}{&post.SearchRank, &post.TitleHighlight, &post.DescriptionSnippet}
+}
+
+// postFilterWhere builds the WHERE clause for every PostFilter field except
+// Search and paging
+func postFilterWhere(filter PostFilter) (string, []interface{}) {
+	where := " WHERE 1=1"
+	args := []interface{}{}
+	argCount := 1
+
+	if filter.UserID != "" {
+		where += fmt.Sprintf(" AND p.user_id = $%d", argCount)
+		args = append(args, filter.UserID)
+		argCount++
+	}
+
+	if filter.Category != "" {
+		where += fmt.Sprintf(" AND p.category = $%d", argCount)
+		args = append(args, filter.Category)
+		argCount++
+	}
+
+	if filter.Type != "" {
+		where += fmt.Sprintf(" AND p.type = $%d", argCount)
+		args = append(args, filter.Type)
+		argCount++
+	}
+
+	if filter.Condition != "" {
+		where += fmt.Sprintf(" AND p.condition = $%d", argCount)
+		args = append(args, filter.Condition)
+		argCount++
+	}
+
+	if filter.MinPrice != nil {
+		where += fmt.Sprintf(" AND p.price >= $%d", argCount)
+		args = append(args, *filter.MinPrice)
+		argCount++
+	}
+
+	if filter.MaxPrice != nil {
+		where += fmt.Sprintf(" AND p.price <= $%d", argCount)
+		args = append(args, *filter.MaxPrice)
+		argCount++
+	}
+
+	if len(filter.States) > 0 {
+		where += fmt.Sprintf(" AND p.state = ANY($%d)", argCount)
+		args = append(args, pq.Array(filter.States))
+		argCount++
+	}
+
+	if filter.ExcludeHidden {
+		where += " AND NOT p.hidden AND p.user_id NOT IN (" + hiddenUsers + ")"
+	}
+
+	return where, args
+}
+
+func (s *Postgres) ListPosts(filter PostFilter) (*PostPage, error) {
+	where, args := postFilterWhere(filter)
+	argCount := len(args) + 1
+
+	tsquery := ""
+	if filter.Search != "" {
+		tsquery = fmt.Sprintf("websearch_to_tsquery('english', $%d)", argCount)
+		where += " AND p.search_vector @@ " + tsquery
+		args = append(args, filter.Search)
+		argCount++
+	}
+
+	page := &PostPage{Posts: []models.Post{}}
+	if err := s.db.QueryRow(`SELECT COUNT(*) FROM posts p`+where, args...).Scan(&page.Total); err != nil {
+		return nil, err
+	}
+
+	sort := filter.Sort
+	if sort == "" {
+		sort = SortNewest
+	}
+	order, ok := postSortColumns[sort]
+	if !ok {
+		return nil, fmt.Errorf("unknown sort %q", sort)
+	}
+	column := order.column
+	if sort == SortRelevance {
+		if tsquery == "" {
+			return nil, fmt.Errorf("relevance sort requires a search")
+		}
+		column = "ts_rank_cd(p.search_vector, " + tsquery + ")"
+	}
+	direction, comparison := "ASC", ">"
+	if order.desc {
+		direction, comparison = "DESC", "<"
+	}
+
+	if filter.After != nil {
+		if filter.After.Sort != sort {
+			return nil, ErrInvalidCursor
+		}
+		after, err := filter.After.keyPost()
+		if err != nil {
+			return nil, err
+		}
+		var key interface{}
+		switch sort {
+		case SortPriceAsc, SortPriceDesc:
+			key = after.Price
+		case SortMostViewed:
+			key = after.ViewCount
+		case SortEndingSoon:
+			key = after.ExpiresAt
+		case SortRelevance:
+			key = after.SearchRank
+		default:
+			key = after.BumpedAt
+		}
+		where += fmt.Sprintf(" AND (%s, p.id) %s ($%d, $%d)", column, comparison, argCount, argCount+1)
+		args = append(args, key, after.ID)
+		argCount += 2
+	}
+
+	columns := postColumns
+	var extra []func(*models.Post) []interface{}
+	if tsquery != "" {
+		columns += fmt.Sprintf(postSearchColumns, tsquery)
+		extra = append(extra, searchFields)
+	}
+
+	query := `SELECT ` + columns + ` 
+			  FROM posts p 
+			  JOIN users u ON p.user_id = u.id` + where +
+		fmt.Sprintf(" ORDER BY %s %s, p.id %s", column, direction, direction)
+	if filter.Limit > 0 {
+		// Fetch one extra row to learn whether there is another page
+		query += fmt.Sprintf(" LIMIT $%d", argCount)
+		args = append(args, filter.Limit+1)
+	}
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		post, err := scanPost(rows, extra...)
+		if err != nil {
+			return nil, err
+		}
+		page.Posts = append(page.Posts, *post)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if filter.Limit > 0 && len(page.Posts) > filter.Limit {
+		page.Posts = page.Posts[:filter.Limit]
+		page.Next = cursorFor(sort, page.Posts[filter.Limit-1])
+	}
+	return page, nil
+}
+
+func (s *Postgres) IncrementViewCount(id string) error {
+	return requireRow(s.db.Exec("UPDATE posts SET view_count = view_count + 1 WHERE id = $1", id))
+}
+
+func (s *Postgres) UpdatePost(post *models.Post) error {
+	return requireRow(s.db.Exec(
+		"UPDATE posts SET title = $1, description = $2, price = $3, category = $4, type = $5, location = $6, condition = $7 WHERE id = $8",
+		post.Title, post.Description, post.Price, post.Category, post.Type, post.Location, post.Condition, post.ID,
+	))
+}
+
+func (s *Postgres) SetPostState(id, from, to, changedBy string, at time.Time) error {
+	return s.setPostState(id, from, to, changedBy, nil, at)
+}
+
+func (s *Postgres) SellPost(id, from, changedBy string, sale *models.Transaction, at time.Time) error {
+	return s.setPostState(id, from, models.PostSold, changedBy, sale, at)
+}
+
+// setPostState is SetPostState that also records sale, if any. Taking a
+// post off sold cancels any sale still waiting on the buyer.
+func (s *Postgres) setPostState(id, from, to, changedBy string, sale *models.Transaction, at time.Time) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := requireRow(tx.Exec("UPDATE posts SET state = $1 WHERE id = $2 AND state = $3", to, id, from)); err != nil {
+		return err
+	}
+	if from == models.PostSold && to != models.PostSold {
+		_, err := tx.Exec(
+			"UPDATE transactions SET status = 'cancelled', responded_at = $1 WHERE post_id = $2 AND status = 'pending'",
+			at, id,
+		)
+		if err != nil {
+			return err
+		}
+	}
+	if sale != nil {
+		if err := insertTransaction(tx, sale); err != nil {
+			return err
+		}
+	}
+	if err := insertPostStateChange(tx, id, from, to, changedBy, at); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func insertPostStateChange(exec execer, postID, from, to, changedBy string, at time.Time) error {
+	_, err := exec.Exec(
+		"INSERT INTO post_state_history (id, post_id, from_state, to_state, changed_by, created_at) VALUES ($1, $2, $3, $4, $5, $6)",
+		uuid.New().String(), postID, from, to, nullIfEmpty(changedBy), at,
+	)
+	return err
+}
+
+func (s *Postgres) ListPostStateHistory(postID string) ([]models.PostStateChange, error) {
+	rows, err := s.db.Query(
+		`SELECT id, post_id, from_state, to_state, COALESCE(changed_by, ''), created_at 
+		FROM post_state_history 
+		WHERE post_id = $1 
+		ORDER BY created_at, id`,
+		postID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	changes := []models.PostStateChange{}
+	for rows.Next() {
+		var change models.PostStateChange
+		if err := rows.Scan(&change.ID, &change.PostID, &change.FromState, &change.ToState, &change.ChangedBy, &change.CreatedAt); err != nil {
+			return nil, err
+		}
+		changes = append(changes, change)
+	}
+	return changes, rows.Err()
+}
+
+func (s *Postgres) RenewPost(id, changedBy string, expiresAt, at time.Time) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var state string
+	err = tx.QueryRow("SELECT state FROM posts WHERE id = $1 FOR UPDATE", id).Scan(&state)
+	if err == sql.ErrNoRows {
+		return ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if state != models.PostActive && state != models.PostExpired {
+		return ErrNotFound
+	}
+
+	if _, err := tx.Exec("UPDATE posts SET state = 'active', expires_at = $1 WHERE id = $2", expiresAt, id); err != nil {
+		return err
+	}
+	if state == models.PostExpired {
+		_, err = tx.Exec(
+			"INSERT INTO post_state_history (id, post_id, from_state, to_state, changed_by, created_at) VALUES ($1, $2, $3, $4, $5, $6)",
+			uuid.New().String(), id, state, models.PostActive, nullIfEmpty(changedBy), at,
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (s *Postgres) SchedulePost(id string, publishAt *time.Time) error {
+	return requireRow(s.db.Exec("UPDATE posts SET publish_at = $1 WHERE id = $2 AND state = 'draft'", publishAt, id))
+}
+
+func (s *Postgres) ListScheduledPosts(now time.Time) ([]models.Post, error) {
+	rows, err := s.db.Query(
+		`SELECT `+postColumns+` 
+		FROM posts p 
+		JOIN users u ON p.user_id = u.id 
+		WHERE p.state = 'draft' AND p.publish_at <= $1 
+		ORDER BY p.publish_at, p.id`,
+		now,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	posts := []models.Post{}
+	for rows.Next() {
+		post, err := scanPost(rows)
+		if err != nil {
+			return nil, err
+		}
+		posts = append(posts, *post)
+	}
+	return posts, rows.Err()
+}
+
+func (s *Postgres) PublishPost(id, changedBy string, expiresAt, at time.Time) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = requireRow(tx.Exec(
+		"UPDATE posts SET state = 'active', bumped_at = $1, expires_at = $2, publish_at = NULL WHERE id = $3 AND state = 'draft'",
+		at, expiresAt, id,
+	))
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(
+		"INSERT INTO post_state_history (id, post_id, from_state, to_state, changed_by, created_at) VALUES ($1, $2, $3, $4, $5, $6)",
+		uuid.New().String(), id, models.PostDraft, models.PostActive, nullIfEmpty(changedBy), at,
+	)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s *Postgres) BumpPost(id string, at, lastBumpedBefore time.Time) error {
+	return requireRow(s.db.Exec(
+		"UPDATE posts SET bumped_at = $1 WHERE id = $2 AND state = 'active' AND bumped_at < $3",
+		at, id, lastBumpedBefore,
+	))
+}
+
+func (s *Postgres) ExpirePosts(now time.Time) ([]models.Post, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query("UPDATE posts SET state = 'expired' WHERE state = 'active' AND expires_at <= $1 RETURNING id", now)
+	if err != nil {
+		return nil, err
+	}
+	ids := []string{}
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(ids) == 0 {
+		return []models.Post{}, nil
+	}
+
+	for _, id := range ids {
+		_, err := tx.Exec(
+			"INSERT INTO post_state_history (id, post_id, from_state, to_state, created_at) VALUES ($1, $2, 'active', 'expired', $3)",
+			uuid.New().String(), id, now,
+		)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	rows, err = tx.Query(`SELECT `+postColumns+` 
+		FROM posts p 
+		JOIN users u ON p.user_id = u.id 
+		WHERE p.id = ANY($1)`,
+		pq.Array(ids),
+	)
+	if err != nil {
+		return nil, err
+	}
+	posts := []models.Post{}
+	for rows.Next() {
+		post, err := scanPost(rows)
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+		posts = append(posts, *post)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return posts, tx.Commit()
+}
+
+func (s *Postgres) DeletePost(id string) error {
+	return requireRow(s.db.Exec("DELETE FROM posts WHERE id = $1", id))
+}
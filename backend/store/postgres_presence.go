@@ -0,0 +1,65 @@
+package store
+
+import (
+	"time"
+
+	"bruinmarket-backend/models"
+
+	"github.com/lib/pq"
+)
+
+func (s *Postgres) AddConnection(connectionID, userID string, at time.Time) error {
+	_, err := s.db.Exec(
+		"INSERT INTO user_connections (id, user_id, connected_at, seen_at) VALUES ($1, $2, $3, $3)",
+		connectionID, userID, at,
+	)
+	if err != nil {
+		return err
+	}
+	_, err = s.db.Exec("UPDATE users SET last_seen_at = $1, last_active_at = $1 WHERE id = $2", at, userID)
+	return err
+}
+
+func (s *Postgres) TouchConnection(connectionID, userID string, at time.Time, active bool) error {
+	if _, err := s.db.Exec("UPDATE user_connections SET seen_at = $1 WHERE id = $2", at, connectionID); err != nil {
+		return err
+	}
+	query := "UPDATE users SET last_seen_at = $1 WHERE id = $2"
+	if active {
+		query = "UPDATE users SET last_seen_at = $1, last_active_at = $1 WHERE id = $2"
+	}
+	_, err := s.db.Exec(query, at, userID)
+	return err
+}
+
+func (s *Postgres) RemoveConnection(connectionID, userID string, at time.Time) error {
+	if _, err := s.db.Exec("DELETE FROM user_connections WHERE id = $1", connectionID); err != nil {
+		return err
+	}
+	_, err := s.db.Exec("UPDATE users SET last_seen_at = $1 WHERE id = $2", at, userID)
+	return err
+}
+
+func (s *Postgres) ListPresence(userIDs []string, staleBefore time.Time) ([]models.Presence, error) {
+	rows, err := s.db.Query(`
+		SELECT u.id, u.last_seen_at, u.last_active_at,
+			EXISTS (SELECT 1 FROM user_connections c WHERE c.user_id = u.id AND c.seen_at >= $2)
+		FROM users u
+		WHERE u.id = ANY($1)`,
+		pq.Array(userIDs), staleBefore,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	presence := []models.Presence{}
+	for rows.Next() {
+		var p models.Presence
+		if err := rows.Scan(&p.UserID, &p.LastSeenAt, &p.LastActiveAt, &p.Online); err != nil {
+			return nil, err
+		}
+		presence = append(presence, p)
+	}
+	return presence, rows.Err()
+}
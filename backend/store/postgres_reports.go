@@ -0,0 +1,99 @@
+package store
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"bruinmarket-backend/models"
+)
+
+const reportColumns = `r.id, r.reporter_id, u.name, r.target_type, r.target_id, r.target_user_id, r.reason, 
+	COALESCE(r.note, ''), r.status, COALESCE(r.reviewed_by, ''), r.reviewed_at, r.created_at`
+
+// reportTargetTables maps each report target type to the table it hides
+var reportTargetTables = map[string]string{
+	models.ReportTargetPost:    "posts",
+	models.ReportTargetUser:    "users",
+	models.ReportTargetMessage: "messages",
+}
+
+func scanReport(row rowScanner) (*models.Report, error) {
+	var r models.Report
+	var reviewedAt sql.NullTime
+	err := row.Scan(&r.ID, &r.ReporterID, &r.ReporterName, &r.TargetType, &r.TargetID, &r.TargetUserID, &r.Reason,
+		&r.Note, &r.Status, &r.ReviewedBy, &reviewedAt, &r.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if reviewedAt.Valid {
+		r.ReviewedAt = &reviewedAt.Time
+	}
+	return &r, nil
+}
+
+func (s *Postgres) CreateReport(report *models.Report) error {
+	_, err := s.db.Exec(
+		`INSERT INTO reports (id, reporter_id, target_type, target_id, target_user_id, reason, note, status, created_at) 
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`,
+		report.ID, report.ReporterID, report.TargetType, report.TargetID, report.TargetUserID, report.Reason,
+		nullIfEmpty(report.Note), report.Status, report.CreatedAt,
+	)
+	if isUniqueViolation(err) {
+		return ErrConflict
+	}
+	return err
+}
+
+func (s *Postgres) GetReport(id string) (*models.Report, error) {
+	return scanReport(s.db.QueryRow("SELECT "+reportColumns+" FROM reports r JOIN users u ON r.reporter_id = u.id WHERE r.id = $1", id))
+}
+
+func (s *Postgres) ListReports(status string) ([]models.Report, error) {
+	rows, err := s.db.Query(
+		"SELECT "+reportColumns+" FROM reports r JOIN users u ON r.reporter_id = u.id WHERE r.status = $1 ORDER BY r.created_at, r.id",
+		status,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	reports := []models.Report{}
+	for rows.Next() {
+		r, err := scanReport(rows)
+		if err != nil {
+			return nil, err
+		}
+		reports = append(reports, *r)
+	}
+	return reports, rows.Err()
+}
+
+func (s *Postgres) CountReporters(targetType, targetID string) (int, error) {
+	var count int
+	err := s.db.QueryRow(
+		"SELECT COUNT(DISTINCT reporter_id) FROM reports WHERE target_type = $1 AND target_id = $2 AND status = 'open'",
+		targetType, targetID,
+	).Scan(&count)
+	return count, err
+}
+
+func (s *Postgres) ResolveReports(targetType, targetID, status, reviewedBy string, at time.Time) error {
+	return requireRow(s.db.Exec(
+		`UPDATE reports SET status = $1, reviewed_by = $2, reviewed_at = $3 
+		WHERE target_type = $4 AND target_id = $5 AND status = 'open'`,
+		status, reviewedBy, at, targetType, targetID,
+	))
+}
+
+func (s *Postgres) SetTargetHidden(targetType, targetID string, hidden bool) error {
+	table, ok := reportTargetTables[targetType]
+	if !ok {
+		return fmt.Errorf("unknown report target %q", targetType)
+	}
+	return requireRow(s.db.Exec("UPDATE "+table+" SET hidden = $1 WHERE id = $2", hidden, targetID))
+}
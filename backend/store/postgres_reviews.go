@@ -0,0 +1,55 @@
+package store
+
+import (
+	"bruinmarket-backend/models"
+)
+
+func (s *Postgres) CreateReview(review *models.Review) error {
+	_, err := s.db.Exec(
+		`INSERT INTO reviews (id, post_id, post_title, reviewer_id, reviewee_id, rating, comment, created_at) 
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
+		review.ID, review.PostID, review.PostTitle, review.ReviewerID, review.RevieweeID, review.Rating, nullIfEmpty(review.Comment), review.CreatedAt,
+	)
+	if isUniqueViolation(err) {
+		return ErrConflict
+	}
+	return err
+}
+
+func (s *Postgres) ListReviewsForUser(userID string) ([]models.Review, error) {
+	rows, err := s.db.Query(
+		`SELECT r.id, COALESCE(r.post_id, ''), r.post_title, r.reviewer_id, u.name, r.reviewee_id, r.rating, COALESCE(r.comment, ''), r.created_at 
+		FROM reviews r 
+		JOIN users u ON r.reviewer_id = u.id 
+		WHERE r.reviewee_id = $1 
+		ORDER BY r.created_at DESC, r.id DESC`,
+		userID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	reviews := []models.Review{}
+	for rows.Next() {
+		var r models.Review
+		err := rows.Scan(&r.ID, &r.PostID, &r.PostTitle, &r.ReviewerID, &r.ReviewerName, &r.RevieweeID, &r.Rating, &r.Comment, &r.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		reviews = append(reviews, r)
+	}
+	return reviews, rows.Err()
+}
+
+func (s *Postgres) GetReputation(userID string) (*models.Reputation, error) {
+	var reputation models.Reputation
+	err := s.db.QueryRow(
+		"SELECT COALESCE(AVG(rating), 0), COUNT(*) FROM reviews WHERE reviewee_id = $1",
+		userID,
+	).Scan(&reputation.Score, &reputation.ReviewCount)
+	if err != nil {
+		return nil, err
+	}
+	return &reputation, nil
+}
@@ -0,0 +1,77 @@
+package store
+
+import (
+	"database/sql"
+
+	"bruinmarket-backend/models"
+)
+
+const savedSearchColumns = `s.id, s.user_id, s.name, COALESCE(s.category, ''), COALESCE(s.type, ''), COALESCE(s.search, ''), 
+	COALESCE(s.condition, ''), s.min_price, s.max_price, s.email_alerts, s.created_at`
+
+func scanSavedSearches(rows *sql.Rows) ([]models.SavedSearch, error) {
+	defer rows.Close()
+
+	searches := []models.SavedSearch{}
+	for rows.Next() {
+		var s models.SavedSearch
+		var minPrice, maxPrice sql.NullFloat64
+		if err := rows.Scan(&s.ID, &s.UserID, &s.Name, &s.Category, &s.Type, &s.Search, &s.Condition,
+			&minPrice, &maxPrice, &s.EmailAlerts, &s.CreatedAt); err != nil {
+			return nil, err
+		}
+		if minPrice.Valid {
+			s.MinPrice = &minPrice.Float64
+		}
+		if maxPrice.Valid {
+			s.MaxPrice = &maxPrice.Float64
+		}
+		searches = append(searches, s)
+	}
+	return searches, rows.Err()
+}
+
+func (s *Postgres) CreateSavedSearch(search *models.SavedSearch) error {
+	_, err := s.db.Exec(
+		`INSERT INTO saved_searches (id, user_id, name, category, type, search, condition, min_price, max_price, email_alerts, created_at) 
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)`,
+		search.ID, search.UserID, search.Name, nullIfEmpty(search.Category), nullIfEmpty(search.Type), nullIfEmpty(search.Search),
+		nullIfEmpty(search.Condition), search.MinPrice, search.MaxPrice, search.EmailAlerts, search.CreatedAt,
+	)
+	return err
+}
+
+func (s *Postgres) ListSavedSearches(userID string) ([]models.SavedSearch, error) {
+	rows, err := s.db.Query(
+		`SELECT `+savedSearchColumns+` FROM saved_searches s WHERE s.user_id = $1 ORDER BY s.created_at DESC`,
+		userID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return scanSavedSearches(rows)
+}
+
+func (s *Postgres) DeleteSavedSearch(id, userID string) error {
+	return requireRow(s.db.Exec("DELETE FROM saved_searches WHERE id = $1 AND user_id = $2", id, userID))
+}
+
+func (s *Postgres) ListSavedSearchesMatchingPost(postID string) ([]models.SavedSearch, error) {
+	rows, err := s.db.Query(
+		`SELECT `+savedSearchColumns+` 
+		FROM saved_searches s 
+		JOIN posts p ON p.id = $1 
+		WHERE s.user_id <> p.user_id 
+			AND (s.category IS NULL OR s.category = p.category) 
+			AND (s.type IS NULL OR s.type = p.type) 
+			AND (s.condition IS NULL OR s.condition = p.condition) 
+			AND (s.min_price IS NULL OR p.price >= s.min_price) 
+			AND (s.max_price IS NULL OR p.price <= s.max_price) 
+			AND (s.search IS NULL OR p.search_vector @@ websearch_to_tsquery('english', s.search))`,
+		postID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return scanSavedSearches(rows)
+}
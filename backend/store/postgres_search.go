@@ -0,0 +1,94 @@
+package store
+
+import (
+	"fmt"
+	"strings"
+
+	"bruinmarket-backend/models"
+)
+
+func (s *Postgres) SimilarPosts(filter PostFilter) ([]models.Post, error) {
+	where, args := postFilterWhere(filter)
+	argCount := len(args) + 1
+
+	// <% matches when the search closely resembles some run of words in the
+	// title, which tolerates typos anywhere in a longer title
+	where += fmt.Sprintf(" AND $%d <%% p.title", argCount)
+	args = append(args, filter.Search)
+	query := `SELECT ` + postColumns + fmt.Sprintf(`, word_similarity($%d, p.title)`, argCount) + ` 
+			  FROM posts p 
+			  JOIN users u ON p.user_id = u.id` + where +
+		fmt.Sprintf(" ORDER BY word_similarity($%d, p.title) DESC, p.id DESC", argCount)
+	argCount++
+
+	if filter.Limit > 0 {
+		query += fmt.Sprintf(" LIMIT $%d", argCount)
+		args = append(args, filter.Limit)
+	}
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	posts := []models.Post{}
+	for rows.Next() {
+		post, err := scanPost(rows, func(post *models.Post) []interface{} {
+			return []interface{}{&post.SearchRank}
+		})
+		if err != nil {
+			return nil, err
+		}
+		posts = append(posts, *post)
+	}
+	return posts, rows.Err()
+}
+
+// escapeLike escapes the LIKE wildcards in s
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
+func (s *Postgres) SuggestSearch(prefix string, limit int) ([]models.SearchSuggestion, error) {
+	prefix = strings.ToLower(strings.TrimSpace(prefix))
+	startsWith := escapeLike(prefix) + "%"
+	wordStartsWith := "% " + escapeLike(prefix) + "%"
+
+	// Prefix matches outrank trigram-only matches; within each group the
+	// closest spelling wins
+	rows, err := s.db.Query(`
+		SELECT text, kind FROM (
+			SELECT MIN(title) AS text, 'title' AS kind,
+				MAX(similarity(title, $1)) + CASE WHEN LOWER(title) LIKE $2 THEN 2 WHEN LOWER(title) LIKE $3 THEN 1 ELSE 0 END AS score
+			FROM posts
+			WHERE state IN ('active', 'reserved') AND NOT hidden AND user_id NOT IN (`+hiddenUsers+`)
+				AND (LOWER(title) LIKE $2 OR LOWER(title) LIKE $3 OR title % $1)
+			GROUP BY LOWER(title)
+			UNION ALL
+			SELECT category, 'category',
+				similarity(category, $1) + CASE WHEN LOWER(category) LIKE $2 THEN 2 WHEN LOWER(category) LIKE $3 THEN 1 ELSE 0 END
+			FROM posts
+			WHERE state IN ('active', 'reserved') AND NOT hidden AND user_id NOT IN (`+hiddenUsers+`)
+				AND (LOWER(category) LIKE $2 OR LOWER(category) LIKE $3 OR category % $1)
+			GROUP BY category
+		) suggestions
+		ORDER BY score DESC, text
+		LIMIT $4`,
+		prefix, startsWith, wordStartsWith, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	suggestions := []models.SearchSuggestion{}
+	for rows.Next() {
+		var suggestion models.SearchSuggestion
+		if err := rows.Scan(&suggestion.Text, &suggestion.Kind); err != nil {
+			return nil, err
+		}
+		suggestions = append(suggestions, suggestion)
+	}
+	return suggestions, rows.Err()
+}
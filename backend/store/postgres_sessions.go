@@ -0,0 +1,116 @@
+package store
+
+import (
+	"database/sql"
+	"time"
+
+	"bruinmarket-backend/models"
+)
+
+const sessionColumns = `s.id, s.user_id, s.refresh_token_hash, COALESCE(s.previous_refresh_token_hash, ''), 
+	COALESCE(s.device, ''), COALESCE(s.ip_address, ''), COALESCE(s.user_agent, ''), 
+	s.created_at, s.last_seen_at, s.expires_at, s.revoked_at`
+
+func scanSession(scanner rowScanner) (*models.Session, error) {
+	var session models.Session
+	err := scanner.Scan(&session.ID, &session.UserID, &session.RefreshTokenHash, &session.PreviousRefreshTokenHash,
+		&session.Device, &session.IPAddress, &session.UserAgent,
+		&session.CreatedAt, &session.LastSeenAt, &session.ExpiresAt, &session.RevokedAt)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+func (s *Postgres) CreateSession(session *models.Session) error {
+	_, err := s.db.Exec(
+		`INSERT INTO sessions (id, user_id, refresh_token_hash, device, ip_address, user_agent, created_at, last_seen_at, expires_at) 
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`,
+		session.ID, session.UserID, session.RefreshTokenHash, session.Device, session.IPAddress, session.UserAgent,
+		session.CreatedAt, session.LastSeenAt, session.ExpiresAt,
+	)
+	return err
+}
+
+func (s *Postgres) GetSessionByRefreshToken(tokenHash string) (*models.Session, error) {
+	return scanSession(s.db.QueryRow("SELECT "+sessionColumns+" FROM sessions s WHERE s.refresh_token_hash = $1", tokenHash))
+}
+
+func (s *Postgres) GetActiveSession(id string, now time.Time) (*models.Session, error) {
+	return scanSession(s.db.QueryRow(
+		"SELECT "+sessionColumns+" FROM sessions s WHERE s.id = $1 AND s.revoked_at IS NULL AND s.expires_at > $2",
+		id, now,
+	))
+}
+
+func (s *Postgres) RotateRefreshToken(id, oldHash, newHash, ipAddress, userAgent string, now time.Time) error {
+	// Matching on the old hash makes concurrent refreshes race safely
+	return requireRow(s.db.Exec(
+		`UPDATE sessions 
+		 SET refresh_token_hash = $1, previous_refresh_token_hash = $2, last_seen_at = $3, ip_address = $4, user_agent = $5 
+		 WHERE id = $6 AND refresh_token_hash = $2`,
+		newHash, oldHash, now, ipAddress, userAgent, id,
+	))
+}
+
+func (s *Postgres) RevokeSessionByPreviousToken(tokenHash string, now time.Time) (string, error) {
+	var sessionID string
+	err := s.db.QueryRow(
+		"UPDATE sessions SET revoked_at = $1 WHERE previous_refresh_token_hash = $2 AND revoked_at IS NULL RETURNING id",
+		now, tokenHash,
+	).Scan(&sessionID)
+	if err == sql.ErrNoRows {
+		return "", ErrNotFound
+	}
+	return sessionID, err
+}
+
+func (s *Postgres) TouchSession(id string, now time.Time) error {
+	// Throttle last-seen writes to once a minute per session
+	_, err := s.db.Exec(
+		"UPDATE sessions SET last_seen_at = $1 WHERE id = $2 AND last_seen_at < $3",
+		now, id, now.Add(-time.Minute),
+	)
+	return err
+}
+
+func (s *Postgres) ListActiveSessions(userID string, now time.Time) ([]models.Session, error) {
+	rows, err := s.db.Query(
+		"SELECT "+sessionColumns+` FROM sessions s 
+		 WHERE s.user_id = $1 AND s.revoked_at IS NULL AND s.expires_at > $2 
+		 ORDER BY s.last_seen_at DESC`,
+		userID, now,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sessions := []models.Session{}
+	for rows.Next() {
+		session, err := scanSession(rows)
+		if err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, *session)
+	}
+	return sessions, rows.Err()
+}
+
+func (s *Postgres) RevokeSession(id, userID string, now time.Time) error {
+	return requireRow(s.db.Exec(
+		"UPDATE sessions SET revoked_at = $1 WHERE id = $2 AND user_id = $3 AND revoked_at IS NULL",
+		now, id, userID,
+	))
+}
+
+func (s *Postgres) RevokeUserSessions(userID string, now time.Time) error {
+	_, err := s.db.Exec(
+		"UPDATE sessions SET revoked_at = $1 WHERE user_id = $2 AND revoked_at IS NULL",
+		now, userID,
+	)
+	return err
+}
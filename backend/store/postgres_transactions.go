@@ -0,0 +1,82 @@
+package store
+
+import (
+	"database/sql"
+	"time"
+
+	"bruinmarket-backend/models"
+)
+
+const transactionColumns = `t.id, COALESCE(t.post_id, ''), t.post_title, t.seller_id, s.name, t.buyer_id, b.name, 
+	t.final_price, t.status, t.responded_at, t.created_at`
+
+const transactionJoins = ` JOIN users s ON t.seller_id = s.id 
+	JOIN users b ON t.buyer_id = b.id`
+
+func scanTransaction(row rowScanner) (*models.Transaction, error) {
+	var t models.Transaction
+	var respondedAt sql.NullTime
+	err := row.Scan(&t.ID, &t.PostID, &t.PostTitle, &t.SellerID, &t.SellerName, &t.BuyerID, &t.BuyerName,
+		&t.FinalPrice, &t.Status, &respondedAt, &t.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if respondedAt.Valid {
+		t.RespondedAt = &respondedAt.Time
+	}
+	return &t, nil
+}
+
+func insertTransaction(exec execer, t *models.Transaction) error {
+	_, err := exec.Exec(
+		`INSERT INTO transactions (id, post_id, post_title, seller_id, buyer_id, final_price, status, created_at) 
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
+		t.ID, t.PostID, t.PostTitle, t.SellerID, t.BuyerID, t.FinalPrice, t.Status, t.CreatedAt,
+	)
+	return err
+}
+
+func (s *Postgres) GetTransaction(id string) (*models.Transaction, error) {
+	return scanTransaction(s.db.QueryRow("SELECT "+transactionColumns+" FROM transactions t"+transactionJoins+" WHERE t.id = $1", id))
+}
+
+func (s *Postgres) ListTransactionsForUser(userID string) ([]models.Transaction, error) {
+	rows, err := s.db.Query(
+		"SELECT "+transactionColumns+" FROM transactions t"+transactionJoins+
+			" WHERE t.seller_id = $1 OR t.buyer_id = $1 ORDER BY t.created_at DESC, t.id DESC",
+		userID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	transactions := []models.Transaction{}
+	for rows.Next() {
+		t, err := scanTransaction(rows)
+		if err != nil {
+			return nil, err
+		}
+		transactions = append(transactions, *t)
+	}
+	return transactions, rows.Err()
+}
+
+func (s *Postgres) RespondToTransaction(id, status string, at time.Time) error {
+	return requireRow(s.db.Exec(
+		"UPDATE transactions SET status = $1, responded_at = $2 WHERE id = $3 AND status = 'pending'",
+		status, at, id,
+	))
+}
+
+func (s *Postgres) FindSale(postID, userID string) (*models.Transaction, error) {
+	return scanTransaction(s.db.QueryRow(
+		"SELECT "+transactionColumns+" FROM transactions t"+transactionJoins+
+			` WHERE t.post_id = $1 AND t.status = 'confirmed' AND (t.seller_id = $2 OR t.buyer_id = $2) 
+			ORDER BY t.created_at DESC LIMIT 1`,
+		postID, userID,
+	))
+}
@@ -0,0 +1,153 @@
+package store
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"bruinmarket-backend/models"
+)
+
+const userColumns = `id, email, name, COALESCE(year, ''), COALESCE(profile_picture_url, ''), password, 
+	COALESCE(email_verified, false), role, verification_token, verification_token_expires, token_version, hidden, suspended_at, created_at`
+
+func scanUser(row rowScanner) (*models.User, error) {
+	var user models.User
+	err := row.Scan(&user.ID, &user.Email, &user.Name, &user.Year, &user.ProfilePictureURL, &user.Password,
+		&user.EmailVerified, &user.Role, &user.VerificationToken, &user.VerificationTokenExpires, &user.TokenVersion, &user.Hidden, &user.SuspendedAt, &user.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (s *Postgres) CreateUser(user *models.User) error {
+	_, err := s.db.Exec(
+		`INSERT INTO users (id, email, name, year, password, email_verified, role, verification_token, verification_token_expires, created_at) 
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`,
+		user.ID, user.Email, user.Name, user.Year, user.Password, user.EmailVerified, user.Role, user.VerificationToken, user.VerificationTokenExpires, user.CreatedAt,
+	)
+	if isUniqueViolation(err) {
+		return ErrConflict
+	}
+	return err
+}
+
+func (s *Postgres) GetUserByID(id string) (*models.User, error) {
+	return scanUser(s.db.QueryRow("SELECT "+userColumns+" FROM users WHERE id = $1", id))
+}
+
+func (s *Postgres) GetUserByEmail(email string) (*models.User, error) {
+	return scanUser(s.db.QueryRow("SELECT "+userColumns+" FROM users WHERE email = $1", email))
+}
+
+func (s *Postgres) GetUserByVerificationToken(token string) (*models.User, error) {
+	return scanUser(s.db.QueryRow(
+		"SELECT "+userColumns+" FROM users WHERE verification_token = $1 AND email_verified = false",
+		token,
+	))
+}
+
+func (s *Postgres) SetVerificationToken(userID, token string, expires time.Time) error {
+	return requireRow(s.db.Exec(
+		`UPDATE users 
+		 SET verification_token = $1, verification_token_expires = $2 
+		 WHERE id = $3`,
+		token, expires, userID,
+	))
+}
+
+func (s *Postgres) MarkEmailVerified(userID string) error {
+	return requireRow(s.db.Exec(
+		`UPDATE users 
+		 SET email_verified = true, verification_token = NULL, verification_token_expires = NULL 
+		 WHERE id = $1`,
+		userID,
+	))
+}
+
+func (s *Postgres) SetPasswordResetToken(userID, tokenHash string, expires time.Time) error {
+	return requireRow(s.db.Exec(
+		`UPDATE users 
+		 SET password_reset_token_hash = $1, password_reset_expires = $2 
+		 WHERE id = $3`,
+		tokenHash, expires, userID,
+	))
+}
+
+func (s *Postgres) ResetPassword(tokenHash, passwordHash string, now time.Time) (string, error) {
+	// Consume the token and rotate the password in one statement so a token
+	// can only ever be redeemed once
+	var userID string
+	err := s.db.QueryRow(
+		`UPDATE users 
+		 SET password = $1, password_reset_token_hash = NULL, password_reset_expires = NULL, token_version = token_version + 1 
+		 WHERE password_reset_token_hash = $2 AND password_reset_expires > $3 
+		 RETURNING id`,
+		passwordHash, tokenHash, now,
+	).Scan(&userID)
+	if err == sql.ErrNoRows {
+		return "", ErrNotFound
+	}
+	return userID, err
+}
+
+func (s *Postgres) UpdateYear(userID, year string) error {
+	return requireRow(s.db.Exec("UPDATE users SET year = $1 WHERE id = $2", year, userID))
+}
+
+func (s *Postgres) UpdateProfilePicture(userID, url string) error {
+	return requireRow(s.db.Exec("UPDATE users SET profile_picture_url = $1 WHERE id = $2", url, userID))
+}
+
+func (s *Postgres) SuspendUser(userID string, at time.Time) error {
+	return requireRow(s.db.Exec("UPDATE users SET suspended_at = $1 WHERE id = $2 AND suspended_at IS NULL", at, userID))
+}
+
+func (s *Postgres) UnsuspendUser(userID string) error {
+	return requireRow(s.db.Exec("UPDATE users SET suspended_at = NULL WHERE id = $1 AND suspended_at IS NOT NULL", userID))
+}
+
+func (s *Postgres) SetUserRole(userID, role string) error {
+	return requireRow(s.db.Exec("UPDATE users SET role = $1, token_version = token_version + 1 WHERE id = $2", role, userID))
+}
+
+func (s *Postgres) ListUsers(filter UserFilter) ([]models.User, error) {
+	query := "SELECT " + userColumns + " FROM users WHERE 1=1"
+	args := []interface{}{}
+	if filter.Search != "" {
+		args = append(args, "%"+escapeLike(filter.Search)+"%")
+		query += fmt.Sprintf(" AND (email ILIKE $%[1]d OR name ILIKE $%[1]d)", len(args))
+	}
+	if filter.Role != "" {
+		args = append(args, filter.Role)
+		query += fmt.Sprintf(" AND role = $%d", len(args))
+	}
+	if filter.Suspended {
+		query += " AND suspended_at IS NOT NULL"
+	}
+	query += " ORDER BY created_at DESC, id DESC"
+	if filter.Limit > 0 {
+		args = append(args, filter.Limit)
+		query += fmt.Sprintf(" LIMIT $%d", len(args))
+	}
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	users := []models.User{}
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, *user)
+	}
+	return users, rows.Err()
+}
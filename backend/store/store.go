@@ -0,0 +1,304 @@
+package store
+
+import (
+	"errors"
+	"time"
+
+	"bruinmarket-backend/models"
+)
+
+// ErrNotFound is returned when a lookup matches no row
+var ErrNotFound = errors.New("not found")
+
+// ErrConflict is returned when an insert violates a uniqueness constraint
+var ErrConflict = errors.New("already exists")
+
+// UserStore persists user accounts and their verification/reset tokens
+type UserStore interface {
+	CreateUser(user *models.User) error
+	GetUserByID(id string) (*models.User, error)
+	GetUserByEmail(email string) (*models.User, error)
+	// GetUserByVerificationToken only matches users that are not yet verified
+	GetUserByVerificationToken(token string) (*models.User, error)
+	SetVerificationToken(userID, token string, expires time.Time) error
+	MarkEmailVerified(userID string) error
+	SetPasswordResetToken(userID, tokenHash string, expires time.Time) error
+	// ResetPassword redeems an unexpired reset token, stores the new password
+	// hash and bumps the user's token version. The token can only be used once.
+	ResetPassword(tokenHash, passwordHash string, now time.Time) (string, error)
+	UpdateYear(userID, year string) error
+	UpdateProfilePicture(userID, url string) error
+	// SuspendUser locks the user out. It returns ErrNotFound if they are
+	// already suspended.
+	SuspendUser(userID string, at time.Time) error
+	// UnsuspendUser returns ErrNotFound unless the user is suspended
+	UnsuspendUser(userID string) error
+	// SetUserRole changes the user's role and bumps their token version so
+	// tokens carrying the old role stop working
+	SetUserRole(userID, role string) error
+	// ListUsers returns matching users, newest first
+	ListUsers(filter UserFilter) ([]models.User, error)
+}
+
+// UserFilter narrows ListUsers. Zero values mean "don't filter".
+type UserFilter struct {
+	// Search matches part of the email or name, ignoring case
+	Search    string
+	Role      string
+	Suspended bool
+	Limit     int
+}
+
+// SessionStore persists login sessions and their refresh tokens
+type SessionStore interface {
+	CreateSession(session *models.Session) error
+	// GetSessionByRefreshToken returns the session regardless of whether it is still active
+	GetSessionByRefreshToken(tokenHash string) (*models.Session, error)
+	// GetActiveSession returns the session only if it is neither revoked nor expired
+	GetActiveSession(id string, now time.Time) (*models.Session, error)
+	// RotateRefreshToken swaps oldHash for newHash; it returns ErrNotFound if
+	// oldHash is no longer current (e.g. a concurrent refresh won the race)
+	RotateRefreshToken(id, oldHash, newHash, ipAddress, userAgent string, now time.Time) error
+	// RevokeSessionByPreviousToken revokes the session a replayed, already
+	// rotated refresh token belonged to and returns its ID
+	RevokeSessionByPreviousToken(tokenHash string, now time.Time) (string, error)
+	TouchSession(id string, now time.Time) error
+	ListActiveSessions(userID string, now time.Time) ([]models.Session, error)
+	RevokeSession(id, userID string, now time.Time) error
+	RevokeUserSessions(userID string, now time.Time) error
+}
+
+// PostFilter narrows ListPosts. Zero values mean "don't filter".
+type PostFilter struct {
+	UserID    string
+	Category  string
+	Type      string
+	Search    string
+	Condition string
+	MinPrice  *float64
+	MaxPrice  *float64
+	// States limits results to posts in these states; empty means any state
+	States []string
+	// ExcludeHidden leaves out hidden posts and posts by hidden or suspended
+	// users
+	ExcludeHidden bool
+
+	// Sort defaults to SortNewest
+	Sort PostSort
+	// After continues from a previous page; it must use the same Sort
+	After *PostCursor
+	// Limit caps the page size; zero returns every match
+	Limit int
+}
+
+// PostPage is one page of ListPosts results
+type PostPage struct {
+	Posts []models.Post
+	// Next is nil on the last page
+	Next *PostCursor
+	// Total counts every post matching the filter, across all pages
+	Total int
+}
+
+// PostStore persists listings. Returned posts carry the owner's name, email
+// and picture but not their media; see MediaStore.
+type PostStore interface {
+	CreatePost(post *models.Post) error
+	GetPost(id string) (*models.Post, error)
+	// GetPostOwner returns the ID of the user who created the post
+	GetPostOwner(id string) (string, error)
+	ListPosts(filter PostFilter) (*PostPage, error)
+	// SimilarPosts finds posts whose titles resemble filter.Search despite
+	// typos, most similar first. Sort and After are ignored.
+	SimilarPosts(filter PostFilter) ([]models.Post, error)
+	// SuggestSearch completes a partial search from the titles and
+	// categories of active and reserved listings that aren't hidden
+	SuggestSearch(prefix string, limit int) ([]models.SearchSuggestion, error)
+	IncrementViewCount(id string) error
+	UpdatePost(post *models.Post) error
+	// SetPostState moves the post from one state to another and records the
+	// change in its history. It returns ErrNotFound if the post is no longer
+	// in state from. changedBy may be empty for changes the system makes.
+	SetPostState(id, from, to, changedBy string, at time.Time) error
+	// SellPost is SetPostState to sold that also records the sale, which may
+	// be nil if the seller doesn't say who bought the post
+	SellPost(id, from, changedBy string, sale *models.Transaction, at time.Time) error
+	// ListPostStateHistory returns the post's state changes, oldest first
+	ListPostStateHistory(postID string) ([]models.PostStateChange, error)
+	// RenewPost moves expires_at out for an active or expired post,
+	// reactivating it if it had expired. Other states return ErrNotFound.
+	RenewPost(id, changedBy string, expiresAt, at time.Time) error
+	// BumpPost moves an active post to the top of the newest sort. It returns
+	// ErrNotFound unless the post is active and was last bumped before
+	// lastBumpedBefore.
+	BumpPost(id string, at, lastBumpedBefore time.Time) error
+	// ExpirePosts moves active posts whose expires_at has passed to expired
+	// and returns them
+	ExpirePosts(now time.Time) ([]models.Post, error)
+	// SchedulePost sets or, with nil, clears a draft's publish time. Other
+	// states return ErrNotFound.
+	SchedulePost(id string, publishAt *time.Time) error
+	// ListScheduledPosts returns drafts due to be published by now, earliest
+	// first
+	ListScheduledPosts(now time.Time) ([]models.Post, error)
+	// PublishPost makes a draft active as of at, clearing its publish time
+	// and putting it at the top of the newest sort. It returns ErrNotFound
+	// unless the post is a draft.
+	PublishPost(id, changedBy string, expiresAt, at time.Time) error
+	DeletePost(id string) error
+}
+
+// TransactionStore persists sales; PostStore.SellPost creates them
+type TransactionStore interface {
+	GetTransaction(id string) (*models.Transaction, error)
+	// ListTransactionsForUser returns the user's sales and purchases, newest
+	// first
+	ListTransactionsForUser(userID string) ([]models.Transaction, error)
+	// RespondToTransaction records the buyer confirming or declining a sale.
+	// It returns ErrNotFound unless the transaction is pending.
+	RespondToTransaction(id, status string, at time.Time) error
+	// FindSale returns the latest confirmed sale of the post that userID was
+	// the buyer or seller in
+	FindSale(postID, userID string) (*models.Transaction, error)
+}
+
+// ReviewStore persists the ratings the two sides of a sale leave each other
+type ReviewStore interface {
+	// CreateReview returns ErrConflict if the reviewer already reviewed the post
+	CreateReview(review *models.Review) error
+	// ListReviewsForUser returns the reviews the user received, newest first
+	ListReviewsForUser(userID string) ([]models.Review, error)
+	GetReputation(userID string) (*models.Reputation, error)
+}
+
+// ReportStore persists users' reports and what moderators did about them.
+// Returned reports carry the reporter's name.
+type ReportStore interface {
+	// CreateReport returns ErrConflict if the reporter already has an open
+	// report on the target
+	CreateReport(report *models.Report) error
+	GetReport(id string) (*models.Report, error)
+	// ListReports returns the reports with the given status, oldest first
+	ListReports(status string) ([]models.Report, error)
+	// CountReporters counts the distinct users with open reports on the target
+	CountReporters(targetType, targetID string) (int, error)
+	// ResolveReports closes every open report on the target with status
+	ResolveReports(targetType, targetID, status, reviewedBy string, at time.Time) error
+	// SetTargetHidden hides or shows a post, user or message
+	SetTargetHidden(targetType, targetID string, hidden bool) error
+}
+
+// CategoryStore persists the listing categories
+type CategoryStore interface {
+	// ListCategories returns the categories in picker order
+	ListCategories() ([]models.Category, error)
+	// CreateCategory adds a category at the end of the list. It returns
+	// ErrConflict if the name is taken.
+	CreateCategory(name string) (*models.Category, error)
+	// RenameCategory also moves posts and saved searches to the new name. It
+	// returns ErrConflict if the new name is taken.
+	RenameCategory(oldName, newName string) error
+	// DeleteCategory leaves posts in the category as they are
+	DeleteCategory(name string) error
+}
+
+// PresenceStore tracks users' open WebSocket connections, across every
+// backend instance, and when they were last seen and active
+type PresenceStore interface {
+	// AddConnection records a newly opened connection
+	AddConnection(connectionID, userID string, at time.Time) error
+	// TouchConnection marks the connection as still alive at at. active
+	// means the user did something, rather than their client just
+	// answering a ping.
+	TouchConnection(connectionID, userID string, at time.Time, active bool) error
+	// RemoveConnection forgets a closed connection
+	RemoveConnection(connectionID, userID string, at time.Time) error
+	// ListPresence returns the presence of the given users that exist.
+	// Connections not touched since staleBefore, left behind by an instance
+	// that went away, don't count as online.
+	ListPresence(userIDs []string, staleBefore time.Time) ([]models.Presence, error)
+}
+
+// MediaStore persists the images and videos attached to posts
+type MediaStore interface {
+	ListMediaForPost(postID string) ([]models.Media, error)
+	// ListMediaForPosts loads the media for many posts in one round trip,
+	// keyed by post ID. Posts without media are absent from the map.
+	ListMediaForPosts(postIDs []string) (map[string][]models.Media, error)
+	// ReplacePostMedia swaps the post's media for the given list, in order
+	ReplacePostMedia(postID string, media []models.Media) error
+}
+
+// ConversationStore persists the one-to-one chats between users
+type ConversationStore interface {
+	CreateConversation(conversation *models.Conversation) error
+	GetConversation(id string) (*models.Conversation, error)
+	// FindConversation returns the conversation between two users in either order
+	FindConversation(userA, userB string) (*models.Conversation, error)
+	ListConversations(userID string) ([]models.Conversation, error)
+	UpdateLastMessage(id, content string, at time.Time) error
+}
+
+// MessageStore persists chat messages
+type MessageStore interface {
+	CreateMessage(message *models.Message) error
+	GetMessage(id string) (*models.Message, error)
+	ListMessages(conversationID string) ([]models.Message, error)
+	MarkMessagesRead(conversationID, receiverID string) error
+}
+
+// SavedSearchStore persists users' saved post filters
+type SavedSearchStore interface {
+	CreateSavedSearch(search *models.SavedSearch) error
+	ListSavedSearches(userID string) ([]models.SavedSearch, error)
+	DeleteSavedSearch(id, userID string) error
+	// ListSavedSearchesMatchingPost returns other users' saved searches the
+	// post satisfies, using the same matching as ListPosts
+	ListSavedSearchesMatchingPost(postID string) ([]models.SavedSearch, error)
+}
+
+// NotificationStore persists in-app notifications
+type NotificationStore interface {
+	CreateNotification(notification *models.Notification) error
+	// ListNotifications returns the user's most recent notifications first
+	ListNotifications(userID string, limit int) ([]models.Notification, error)
+	CountUnreadNotifications(userID string) (int, error)
+	MarkNotificationRead(id, userID string) error
+	MarkAllNotificationsRead(userID string) error
+}
+
+// FavoriteStore persists the posts users are watching
+type FavoriteStore interface {
+	// AddFavorite is a no-op if the user already watches the post
+	AddFavorite(userID, postID string, at time.Time) error
+	RemoveFavorite(userID, postID string) error
+	// ListFavoritePosts returns the user's watched posts, most recently added first
+	ListFavoritePosts(userID string) ([]models.Post, error)
+	// ListPostWatchers returns the IDs of users watching the post
+	ListPostWatchers(postID string) ([]string, error)
+}
+
+// OfferStore persists offers and counter-offers on posts. Returned offers
+// carry the post title and both users' names.
+type OfferStore interface {
+	// CreateOffer returns ErrConflict if the buyer already has a pending
+	// offer on the post
+	CreateOffer(offer *models.Offer) error
+	GetOffer(id string) (*models.Offer, error)
+	// ListOffersForPost returns the post's offers, newest first
+	ListOffersForPost(postID string) ([]models.Offer, error)
+	// ListOffersForUser returns offers where the user is buyer or seller, newest first
+	ListOffersForUser(userID string) ([]models.Offer, error)
+	// ResolveOffer moves a pending offer to status. It returns ErrNotFound if
+	// the offer is no longer pending, e.g. a concurrent response won the race.
+	ResolveOffer(id, status string, at time.Time) error
+	// AcceptOffer accepts a pending offer and, if reserve, moves its post from
+	// active to reserved in the same transaction. It returns ErrNotFound like
+	// ResolveOffer, or ErrConflict if the post is no longer active.
+	AcceptOffer(id string, reserve bool, at time.Time) error
+	// CounterOffer marks the parent offer countered and creates the counter in
+	// one step, with the same guarantee as ResolveOffer
+	CounterOffer(parentID string, counter *models.Offer) error
+	// ExpireOffers marks pending offers that expired before now and returns them
+	ExpireOffers(now time.Time) ([]models.Offer, error)
+}
@@ -2,25 +2,35 @@ import React, { useState, useEffect, useRef } from 'react';
 import { Search, Plus, X, Upload, DollarSign, CircleParking, Tag, CreditCard, Package, Car, Dumbbell, Laptop, Ticket, Sofa, Lamp, Grid3x3, User, LogOut, Shirt, NotebookPen, CircleQuestionMark, Footprints, MessageCircle, MoreVertical, Trash2, Edit, CheckCircle, Github, Menu } from 'lucide-react';
 import logo from './BruinMarketTransparent.svg';
 import Chat from './Chat.js';
+import { authFetch, logoutSession, onTokenChange, saveSession } from './auth';
 
 const API_URL = process.env.REACT_APP_API_URL || 'http://localhost:8080/api';
 
-const categories = [
+// Icons for the known categories; categories admins add later get a tag
+const categoryIcons = {
+  'Clothing': Shirt,
+  'Sports Equipment': Dumbbell,
+  'Shoes': Footprints,
+  'Class Supplies': NotebookPen,
+  'Electronics': Laptop,
+  'Tickets': Ticket,
+  'Swipes': CreditCard,
+  'Rideshare': Car,
+  'Parking Spots': CircleParking,
+  'Furniture': Sofa,
+  'Decorations': Lamp,
+  'Other': CircleQuestionMark,
+};
+
+// Builds the filter list from category names, with "All" first
+const toCategoryList = (names) => [
   { name: 'All', value: 'all', icon: Grid3x3 },
-  { name: 'Clothing', value: 'Clothing', icon: Shirt },
-  { name: 'Sports Equipment', value: 'Sports Equipment', icon: Dumbbell },
-  { name: 'Shoes', value: 'Shoes', icon: Footprints },
-  { name: 'Class Supplies', value: 'Class Supplies', icon: NotebookPen },
-  { name: 'Electronics', value: 'Electronics', icon: Laptop },
-  { name: 'Tickets', value: 'Tickets', icon: Ticket },
-  { name: 'Swipes', value: 'Swipes', icon: CreditCard},
-  { name: 'Rideshare', value: 'Rideshare', icon: Car },
-  { name: 'Parking Spots', value: 'Parking Spots', icon: CircleParking },
-  { name: 'Furniture', value: 'Furniture', icon: Sofa },
-  { name: 'Decorations', value: 'Decorations', icon: Lamp },
-  { name: 'Other', value: 'Other', icon: CircleQuestionMark },
+  ...names.map(name => ({ name, value: name, icon: categoryIcons[name] || Tag })),
 ];
 
+// Shown until GET /api/categories answers
+const defaultCategories = toCategoryList(Object.keys(categoryIcons));
+
 const formatDate = (dateString) => {
   if (!dateString) return '';
   
@@ -44,14 +54,31 @@ const formatDate = (dateString) => {
   }
 };
 
+// Renders search highlights from the API; only <mark> tags are honored and
+// everything else stays plain text
+const Highlighted = ({ text }) => (
+  <>
+    {text.split(/(<mark>.*?<\/mark>)/g).map((part, i) =>
+      part.startsWith('<mark>') ? (
+        <mark key={i} className="bg-amber-200 rounded px-0.5">{part.slice(6, -7)}</mark>
+      ) : (
+        part
+      )
+    )}
+  </>
+);
+
 const BruinMarket = () => {
   const [user, setUser] = useState(null);
   const [token, setToken] = useState(localStorage.getItem('token'));
   const [showAuthModal, setShowAuthModal] = useState({ show: false, isSignUp: false });
   const [showProfile, setShowProfile] = useState(false);
   const [posts, setPosts] = useState([]);
+  const [nextCursor, setNextCursor] = useState(null);
+  const [sortBy, setSortBy] = useState('');
   const [showCreateModal, setShowCreateModal] = useState(false);
   const [searchTerm, setSearchTerm] = useState('');
+  const [suggestions, setSuggestions] = useState([]);
   const [filterCategory, setFilterCategory] = useState('all');
   const [filterType, setFilterType] = useState('all');
   const [priceRange, setPriceRange] = useState({ min: '', max: '' });
@@ -66,6 +93,24 @@ const BruinMarket = () => {
   const [viewMarketplaceWithoutLogin, setViewMarketplaceWithoutLogin] = useState(false);
   const [showMobileSidebar, setShowMobileSidebar] = useState(false);
   const [mobileSidebarVisible, setMobileSidebarVisible] = useState(false);
+  const [categories, setCategories] = useState(defaultCategories);
+
+  // Categories are managed by admins, so load the current list
+  useEffect(() => {
+    fetch(`${API_URL}/categories`)
+      .then(res => (res.ok ? res.json() : Promise.reject(res.status)))
+      .then(data => setCategories(toCategoryList(data.map(c => c.name))))
+      .catch(error => console.error('Error loading categories:', error));
+  }, []);
+
+  // Follow token refreshes; a refresh the server turns down ends the session
+  useEffect(() => onTokenChange(newToken => {
+    setToken(newToken);
+    if (!newToken) {
+      setUser(null);
+      setShowProfile(false);
+    }
+  }), []);
 
   useEffect(() => {
     if (token) {
@@ -88,7 +133,18 @@ const BruinMarket = () => {
     if (!showProfile) {
       loadPosts();
     }
-  }, [filterCategory, filterType, priceRange, searchTerm, showProfile]);
+  }, [filterCategory, filterType, priceRange, searchTerm, sortBy, showProfile]);
+
+  useEffect(() => {
+    if (searchTerm.trim().length < 2) {
+      setSuggestions([]);
+      return;
+    }
+    fetch(`${API_URL}/search/suggest?q=${encodeURIComponent(searchTerm)}`)
+      .then(response => (response.ok ? response.json() : { suggestions: [] }))
+      .then(data => setSuggestions(data.suggestions || []))
+      .catch(() => setSuggestions([]));
+  }, [searchTerm]);
 
   useEffect(() => {
     if (showMobileSidebar) {
@@ -110,11 +166,7 @@ const BruinMarket = () => {
 
   const fetchUser = async () => {
     try {
-      const response = await fetch(`${API_URL}/auth/me`, {
-        headers: {
-          'Authorization': `Bearer ${token}`
-        }
-      });
+      const response = await authFetch(`${API_URL}/auth/me`);
       if (response.ok) {
         const data = await response.json();
         setUser(data);
@@ -130,9 +182,9 @@ const BruinMarket = () => {
   };
 
   const logout = () => {
+    logoutSession();
     setToken(null);
     setUser(null);
-    localStorage.removeItem('token');
     setShowProfile(false);
   };
 
@@ -152,8 +204,9 @@ const BruinMarket = () => {
     }, 250);
   };
 
-  const loadPosts = async () => {
-    setLoading(true);
+  // Pass the previous page's cursor to append the next page
+  const loadPosts = async (cursor = null) => {
+    if (!cursor) setLoading(true);
     try {
       const params = new URLSearchParams();
       if (filterCategory !== 'all') params.append('category', filterCategory);
@@ -161,12 +214,16 @@ const BruinMarket = () => {
       if (priceRange.min) params.append('min_price', priceRange.min);
       if (priceRange.max) params.append('max_price', priceRange.max);
       if (searchTerm) params.append('search', searchTerm);
+      if (sortBy) params.append('sort', sortBy);
+      if (cursor) params.append('cursor', cursor);
 
       const response = await fetch(`${API_URL}/posts?${params.toString()}`);
       if (!response.ok) throw new Error('Failed to fetch posts');
       
       const data = await response.json();
-      setPosts(data || []);
+      const page = data.posts || [];
+      setPosts(cursor ? [...posts, ...page] : page);
+      setNextCursor(data.next_cursor || null);
     } catch (error) {
       console.error('Error loading posts:', error);
     } finally {
@@ -193,11 +250,10 @@ const BruinMarket = () => {
       console.log('Payload:', payload);
       console.log('Condition in payload:', payload.condition);
 
-      const response = await fetch(`${API_URL}/posts`, {
+      const response = await authFetch(`${API_URL}/posts`, {
         method: 'POST',
         headers: {
-          'Content-Type': 'application/json',
-          'Authorization': `Bearer ${token}`
+          'Content-Type': 'application/json'
         },
         body: JSON.stringify(payload),
       });
@@ -225,11 +281,8 @@ const BruinMarket = () => {
     if (!window.confirm('Are you sure you want to delete this post?')) return;
 
     try {
-      const response = await fetch(`${API_URL}/posts/${postId}`, {
-        method: 'DELETE',
-        headers: {
-          'Authorization': `Bearer ${token}`
-        }
+      const response = await authFetch(`${API_URL}/posts/${postId}`, {
+        method: 'DELETE'
       });
 
       if (!response.ok) {
@@ -247,10 +300,9 @@ const BruinMarket = () => {
 
   const markAsSold = async (postId, soldStatus) => {
     try {
-      const response = await fetch(`${API_URL}/posts/${postId}/sold`, {
+      const response = await authFetch(`${API_URL}/posts/${postId}/sold`, {
         method: 'PATCH',
         headers: {
-          'Authorization': `Bearer ${token}`,
           'Content-Type': 'application/json'
         },
         body: JSON.stringify({ sold: soldStatus })
@@ -279,11 +331,10 @@ const BruinMarket = () => {
         price: parseFloat(postData.price)
       };
 
-      const response = await fetch(`${API_URL}/posts/${postId}`, {
+      const response = await authFetch(`${API_URL}/posts/${postId}`, {
         method: 'PUT',
         headers: {
-          'Content-Type': 'application/json',
-          'Authorization': `Bearer ${token}`
+          'Content-Type': 'application/json'
         },
         body: JSON.stringify(payload),
       });
@@ -313,11 +364,7 @@ const BruinMarket = () => {
       console.log('Opening chat with user:', userId);
       console.log('URL:', url);
       
-      const response = await fetch(url, {
-        headers: {
-          'Authorization': `Bearer ${token}`
-        }
-      });
+      const response = await authFetch(url);
       
       console.log('Response status:', response.status);
       
@@ -349,14 +396,10 @@ const BruinMarket = () => {
     }
     
     try {
-      const url = `${API_URL}/users/${userId}`;
+      const url = `${API_URL}/users/${userId}?limit=100`;
       console.log('Fetching user profile from:', url);
       
-      const response = await fetch(url, {
-        headers: {
-          'Authorization': `Bearer ${token}`
-        }
-      });
+      const response = await authFetch(url);
       
       console.log('Response status:', response.status);
       
@@ -403,7 +446,6 @@ const BruinMarket = () => {
         onAuthSuccess={(token, user) => {
           setToken(token);
           setUser(user);
-          localStorage.setItem('token', token);
           setShowAuthModal({ show: false, isSignUp: false });
         }}
         onViewMarketplace={() => {
@@ -522,8 +564,14 @@ const BruinMarket = () => {
                 placeholder="Search items..."
                 value={searchTerm}
                 onChange={(e) => setSearchTerm(e.target.value)}
+                list="search-suggestions"
                 className="w-full pl-10 pr-4 py-2 border border-gray-300 rounded-lg focus:ring-2 focus:ring-blue-500 focus:border-transparent text-sm"
               />
+              <datalist id="search-suggestions">
+                {suggestions.map(s => (
+                  <option key={`${s.kind}-${s.text}`} value={s.text} />
+                ))}
+              </datalist>
             </div>
           </div>
 
@@ -627,6 +675,7 @@ const BruinMarket = () => {
                     placeholder="Search items..."
                     value={searchTerm}
                     onChange={(e) => setSearchTerm(e.target.value)}
+                    list="search-suggestions"
                     className="w-full pl-10 pr-4 py-2 border border-gray-300 rounded-lg focus:ring-2 focus:ring-blue-500 focus:border-transparent text-sm"
                   />
                 </div>
@@ -720,6 +769,21 @@ const BruinMarket = () => {
             </div>
           ) : (
             <>
+              <div className="flex justify-end mb-4">
+                <select
+                  value={sortBy}
+                  onChange={(e) => setSortBy(e.target.value)}
+                  className="px-3 py-2 rounded-lg border border-gray-300 bg-white text-gray-700"
+                >
+                  <option value="">{searchTerm ? 'Best Match' : 'Newest'}</option>
+                  {searchTerm && <option value="newest">Newest</option>}
+                  <option value="price_asc">Price: Low to High</option>
+                  <option value="price_desc">Price: High to Low</option>
+                  <option value="most_viewed">Most Viewed</option>
+                  <option value="ending_soon">Ending Soon</option>
+                </select>
+              </div>
+
               <div className="grid grid-cols-1 sm:grid-cols-2 lg:grid-cols-3 xl:grid-cols-4 gap-4 md:gap-6 items-start">
                 {posts.map(post => (
                   <PostCard 
@@ -736,6 +800,17 @@ const BruinMarket = () => {
                 ))}
               </div>
 
+              {nextCursor && (
+                <div className="text-center mt-8">
+                  <button
+                    onClick={() => loadPosts(nextCursor)}
+                    className="px-6 py-2 bg-white text-blue-600 rounded-lg font-semibold hover:bg-gray-100 transition"
+                  >
+                    Load more
+                  </button>
+                </div>
+              )}
+
               {posts.length === 0 && (
                 <div className="text-center py-12">
                   <Package size={64} className="mx-auto text-gray-300 mb-4" />
@@ -754,7 +829,6 @@ const BruinMarket = () => {
           onSuccess={(token, user) => {
             setToken(token);
             setUser(user);
-            localStorage.setItem('token', token);
             setShowAuthModal({ show: false, isSignUp: false });
           }}
           initialIsSignUp={showAuthModal.isSignUp}
@@ -969,14 +1043,10 @@ const ProfilePage = ({ user, token, onDeletePost, onEdit }) => {
 
   const loadMyPosts = async () => {
     try {
-      const response = await fetch(`${API_URL}/auth/my-posts`, {
-        headers: {
-          'Authorization': `Bearer ${token}`
-        }
-      });
+      const response = await authFetch(`${API_URL}/auth/my-posts?limit=100`);
       if (response.ok) {
         const data = await response.json();
-        setMyPosts(data || []);
+        setMyPosts(data.posts || []);
       }
     } catch (error) {
       console.error('Error loading posts:', error);
@@ -1010,11 +1080,8 @@ const ProfilePage = ({ user, token, onDeletePost, onEdit }) => {
       const formData = new FormData();
       formData.append('file', file);
 
-      const response = await fetch(`${API_URL}/upload-profile-picture`, {
+      const response = await authFetch(`${API_URL}/upload-profile-picture`, {
         method: 'POST',
-        headers: {
-          'Authorization': `Bearer ${token}`
-        },
         body: formData,
       });
 
@@ -1042,10 +1109,9 @@ const ProfilePage = ({ user, token, onDeletePost, onEdit }) => {
     setUpdatingYear(true);
 
     try {
-      const response = await fetch(`${API_URL}/auth/year`, {
+      const response = await authFetch(`${API_URL}/auth/year`, {
         method: 'PATCH',
         headers: {
-          'Authorization': `Bearer ${token}`,
           'Content-Type': 'application/json'
         },
         body: JSON.stringify({ year: selectedYear })
@@ -1196,7 +1262,7 @@ const ProfilePage = ({ user, token, onDeletePost, onEdit }) => {
 };
 
 const OtherUserProfile = ({ profileData, token, onClose, onViewUserProfile }) => {
-  const { user, posts } = profileData;
+  const { user, posts, total, reputation } = profileData;
 
   return (
     <div>
@@ -1236,7 +1302,10 @@ const OtherUserProfile = ({ profileData, token, onClose, onViewUserProfile }) =>
             )}
             {/* <p className="text-gray-600 mb-4">{user.email}</p> */}
             <div className="flex items-center gap-4 text-sm text-gray-500">
-              <span>Total Posts: {posts.length}</span>
+              <span>Total Posts: {total ?? posts.length}</span>
+              {reputation && reputation.review_count > 0 && (
+                <span>★ {reputation.score.toFixed(1)} ({reputation.review_count} {reputation.review_count === 1 ? 'review' : 'reviews'})</span>
+              )}
             </div>
           </div>
         </div>
@@ -1358,6 +1427,7 @@ const AuthModal = ({ onClose, onSuccess, initialIsSignUp = false }) => {
 
       if (isLogin) {
         // Login successful
+        saveSession(data);
         onSuccess(data.token, data.user);
       } else {
         // Registration successful - show verification message
@@ -1514,7 +1584,9 @@ const PostCard = ({ post, onDelete, onEdit, onMarkAsSold, canDelete, token, onMe
 
           <div className="p-4">
             <div className="flex items-start justify-between gap-2 mb-2">
-              <h3 className="text-base font-semibold text-gray-900 flex-1 break-words">{post.title}</h3>
+              <h3 className="text-base font-semibold text-gray-900 flex-1 break-words">
+                {post.title_highlight ? <Highlighted text={post.title_highlight} /> : post.title}
+              </h3>
               <span className={`px-2 py-1 rounded text-xs font-semibold flex-shrink-0 ${
                 post.type === 'selling' ? 'bg-green-100 text-green-800' : 'bg-blue-100 text-blue-800'
               }`}>
@@ -1522,6 +1594,12 @@ const PostCard = ({ post, onDelete, onEdit, onMarkAsSold, canDelete, token, onMe
               </span>
             </div>
             
+            {post.description_snippet && (
+              <p className="text-sm text-gray-600 mb-2 line-clamp-2">
+                <Highlighted text={post.description_snippet} />
+              </p>
+            )}
+
             {/* User info with profile picture */}
             <div className="flex items-center gap-2 mb-2">
               {post.user_profile_picture_url ? (
@@ -1756,6 +1834,9 @@ const PostFullView = ({ post, token, onClose, onMessageUser, onViewUserProfile,
                 >
                   {post.user_name}
                 </p>
+                {post.seller_review_count > 0 && (
+                  <p className="text-xs text-gray-600">★ {post.seller_reputation.toFixed(1)} ({post.seller_review_count})</p>
+                )}
                 {post.created_at && (
                   <p className="text-xs text-gray-500 mt-1">{formatDate(post.created_at)}</p>
                 )}
@@ -1876,11 +1957,8 @@ const CreatePostModal = ({ onClose, onCreate, categories, token }) => {
         const formData = new FormData();
         formData.append('file', file);
 
-        const response = await fetch(`${API_URL}/upload`, {
+        const response = await authFetch(`${API_URL}/upload`, {
           method: 'POST',
-          headers: {
-            'Authorization': `Bearer ${token}`
-          },
           body: formData,
         });
 
@@ -2155,11 +2233,8 @@ const EditPostModal = ({ post, onClose, onUpdate, categories, token }) => {
         const formDataObj = new FormData();
         formDataObj.append('file', file);
 
-        const response = await fetch(`${API_URL}/upload`, {
+        const response = await authFetch(`${API_URL}/upload`, {
           method: 'POST',
-          headers: {
-            'Authorization': `Bearer ${token}`
-          },
           body: formDataObj,
         });
 
@@ -1,5 +1,6 @@
 import React, { useState, useEffect, useRef } from 'react';
 import { X, Send, MessageCircle, User } from 'lucide-react';
+import { authFetch, getToken, refreshSession } from './auth';
 
 const API_URL = process.env.REACT_APP_API_URL || 'http://localhost:8080/api';
 const WS_URL = process.env.REACT_APP_WS_URL || 'ws://localhost:8080/api/ws';
@@ -60,16 +61,20 @@ const Chat = ({ user, token, onClose, initialConversation }) => {
       scrollToBottom();
     }, [messages]);
   
-    const connectWebSocket = () => {
+    const connectWebSocket = (reconnecting = false) => {
         if (!isMountedRef.current) return;
         
         console.log('Attempting WebSocket connection...');
-        console.log('Token:', token ? 'Token exists' : 'NO TOKEN');
         
-        setTimeout(() => {
+        setTimeout(async () => {
           if (!isMountedRef.current) return;
-          
-          const websocket = new WebSocket(`${WS_URL}?token=${token}`);
+
+          // The access token may have expired since the last connection
+          if (reconnecting) {
+            await refreshSession();
+            if (!isMountedRef.current) return;
+          }
+          const websocket = new WebSocket(`${WS_URL}?token=${getToken()}`);
           wsRef.current = websocket;
         
           websocket.onopen = () => {
@@ -86,8 +91,16 @@ const Chat = ({ user, token, onClose, initialConversation }) => {
             
             console.log('📨 Message received:', event.data);
             const data = JSON.parse(event.data);
+
+            // The server rejected something we sent
+            if (data.type === 'error') {
+              console.error('WebSocket error frame:', data.code, data.error);
+              alert(data.error || 'Failed to send message. Please try again.');
+              return;
+            }
             
-            if (data.type === 'message') {
+            // Offer events (offer_created, offer_accepted, ...) arrive as system messages
+            if (data.type === 'message' || data.type.startsWith('offer_')) {
               // Use ref to get current conversation
               const currentConversation = selectedConversationRef.current;
               
@@ -102,7 +115,9 @@ const Chat = ({ user, token, onClose, initialConversation }) => {
                     conversation_id: data.conversation_id,
                     sender_id: data.sender_id,
                     receiver_id: data.receiver_id,
+                    type: data.type,
                     content: data.content,
+                    offer_id: data.offer_id,
                     created_at: data.created_at,
                     read: false
                   }];
@@ -130,7 +145,7 @@ const Chat = ({ user, token, onClose, initialConversation }) => {
               setTimeout(() => {
                 if (isMountedRef.current) {
                   console.log(' Attempting to reconnect...');
-                  connectWebSocket();
+                  connectWebSocket(true);
                 }
               }, 3000);
             }
@@ -144,11 +159,7 @@ const Chat = ({ user, token, onClose, initialConversation }) => {
       if (!isMountedRef.current) return;
       
       try {
-        const response = await fetch(`${API_URL}/conversations`, {
-          headers: {
-            'Authorization': `Bearer ${token}`
-          }
-        });
+        const response = await authFetch(`${API_URL}/conversations`);
         if (response.ok && isMountedRef.current) {
           const data = await response.json();
           setConversations(data || []);
@@ -164,11 +175,7 @@ const Chat = ({ user, token, onClose, initialConversation }) => {
       if (!isMountedRef.current) return;
       
       try {
-        const response = await fetch(`${API_URL}/messages/${conversationId}`, {
-          headers: {
-            'Authorization': `Bearer ${token}`
-          }
-        });
+        const response = await authFetch(`${API_URL}/messages/${conversationId}`);
         if (response.ok && isMountedRef.current) {
           const data = await response.json();
           setMessages(data || []);
@@ -195,15 +202,11 @@ const Chat = ({ user, token, onClose, initialConversation }) => {
           return;
         }
 
-      const otherUserId = selectedConversation.user1_id === user.id 
-        ? selectedConversation.user2_id 
-        : selectedConversation.user1_id;
-  
+      // The server fills in the sender and receiver from the connection and
+      // the conversation
       const message = {
         type: 'message',
         conversation_id: selectedConversation.id,
-        sender_id: user.id,
-        receiver_id: otherUserId,
         content: newMessage.trim()
       };
   
@@ -346,6 +349,15 @@ const Chat = ({ user, token, onClose, initialConversation }) => {
               <div className="flex-1 overflow-y-auto p-3 md:p-4 space-y-3 md:space-y-4 bg-gray-50">
                 {messages.map((message) => {
                   const isMe = message.sender_id === user.id;
+                  if (message.type && message.type !== 'message') {
+                    return (
+                      <div key={message.id} className="flex justify-center">
+                        <p className="text-xs md:text-sm text-gray-600 bg-gray-100 rounded-full px-3 py-1">
+                          {isMe ? 'You: ' : ''}{message.content}
+                        </p>
+                      </div>
+                    );
+                  }
                   return (
                     <div
                       key={message.id}
@@ -0,0 +1,97 @@
+import React, { useState, useEffect } from 'react';
+import { useNavigate, useSearchParams } from 'react-router-dom';
+import { CheckCircle, XCircle, Loader } from 'lucide-react';
+import { authFetch } from './auth';
+
+const API_URL = process.env.REACT_APP_API_URL || 'http://localhost:8080/api';
+
+// Landing page for the "Renew Listing" link in listing expiry emails
+const RenewPost = () => {
+  const [searchParams] = useSearchParams();
+  const navigate = useNavigate();
+  const [status, setStatus] = useState('renewing'); // 'renewing', 'success', 'error'
+  const [message, setMessage] = useState('');
+
+  useEffect(() => {
+    const postId = searchParams.get('post');
+    const token = localStorage.getItem('token');
+
+    if (!postId) {
+      setStatus('error');
+      setMessage('Invalid renew link. Please check your email for the correct link.');
+      return;
+    }
+    if (!token) {
+      setStatus('error');
+      setMessage('Please log in to BruinMarket, then click the link in your email again.');
+      return;
+    }
+
+    renewPost(postId);
+  }, [searchParams]);
+
+  const renewPost = async (postId) => {
+    try {
+      const response = await authFetch(`${API_URL}/posts/${encodeURIComponent(postId)}/renew`, {
+        method: 'POST',
+      });
+
+      const data = await response.json();
+
+      if (response.ok) {
+        setStatus('success');
+        setMessage(`Your listing is back up until ${new Date(data.expires_at).toLocaleDateString()}.`);
+      } else {
+        setStatus('error');
+        setMessage(data.error || 'Could not renew this listing. Please try again.');
+      }
+    } catch (error) {
+      setStatus('error');
+      setMessage('Failed to connect to server. Please try again later.');
+    }
+  };
+
+  return (
+    <div className="min-h-screen bg-gradient-to-br from-blue-50 to-sky-100 flex items-center justify-center p-4">
+      <div className="bg-white rounded-lg shadow-xl p-8 max-w-lg w-full text-center">
+        {status === 'renewing' && (
+          <>
+            <Loader className="w-16 h-16 text-blue-600 animate-spin mx-auto mb-4" />
+            <h2 className="text-2xl font-bold text-gray-900 mb-2">Renewing Your Listing</h2>
+            <p className="text-gray-600">Please wait...</p>
+          </>
+        )}
+
+        {status === 'success' && (
+          <>
+            <CheckCircle className="w-16 h-16 text-green-600 mx-auto mb-4" />
+            <h2 className="text-2xl font-bold text-gray-900 mb-2">Listing Renewed!</h2>
+            <p className="text-gray-600 mb-4">{message}</p>
+            <button
+              onClick={() => navigate('/')}
+              className="bg-blue-600 text-white px-8 py-3 rounded-lg hover:bg-blue-700 transition font-semibold text-lg shadow-lg hover:shadow-xl"
+            >
+              Go to Marketplace
+            </button>
+          </>
+        )}
+
+        {status === 'error' && (
+          <>
+            <XCircle className="w-16 h-16 text-red-600 mx-auto mb-4" />
+            <h2 className="text-2xl font-bold text-gray-900 mb-2">Renewal Failed</h2>
+            <p className="text-gray-600 mb-4">{message}</p>
+            <button
+              onClick={() => navigate('/')}
+              className="bg-blue-600 text-white px-6 py-2 rounded-lg hover:bg-blue-700 transition"
+            >
+              Back to Home
+            </button>
+          </>
+        )}
+      </div>
+    </div>
+  );
+};
+
+export default RenewPost;
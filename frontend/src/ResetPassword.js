@@ -0,0 +1,135 @@
+import React, { useState } from 'react';
+import { useNavigate, useSearchParams } from 'react-router-dom';
+import { CheckCircle, XCircle, Loader } from 'lucide-react';
+
+const API_URL = process.env.REACT_APP_API_URL || 'http://localhost:8080/api';
+
+// Landing page for the link in password reset emails
+const ResetPassword = () => {
+  const [searchParams] = useSearchParams();
+  const navigate = useNavigate();
+  const token = searchParams.get('token');
+  const [status, setStatus] = useState(token ? 'form' : 'error'); // 'form', 'resetting', 'success', 'error'
+  const [message, setMessage] = useState(token ? '' : 'Invalid reset link. Please check your email for the correct link.');
+  const [password, setPassword] = useState('');
+  const [confirmPassword, setConfirmPassword] = useState('');
+  const [error, setError] = useState('');
+
+  const resetPassword = async (e) => {
+    e.preventDefault();
+    if (password.length < 6) {
+      setError('Password must be at least 6 characters');
+      return;
+    }
+    if (password !== confirmPassword) {
+      setError('Passwords do not match');
+      return;
+    }
+
+    setError('');
+    setStatus('resetting');
+    try {
+      const response = await fetch(`${API_URL}/auth/reset-password`, {
+        method: 'POST',
+        headers: { 'Content-Type': 'application/json' },
+        body: JSON.stringify({ token, password }),
+      });
+
+      const data = await response.json();
+
+      if (response.ok) {
+        setStatus('success');
+        setMessage(data.message || 'Password reset successfully. Please log in with your new password.');
+      } else {
+        setStatus('error');
+        setMessage(data.error || 'Could not reset your password. Please request a new link.');
+      }
+    } catch (error) {
+      setStatus('error');
+      setMessage('Failed to connect to server. Please try again later.');
+    }
+  };
+
+  return (
+    <div className="min-h-screen bg-gradient-to-br from-blue-50 to-sky-100 flex items-center justify-center p-4">
+      <div className="bg-white rounded-lg shadow-xl p-8 max-w-lg w-full text-center">
+        {status === 'form' && (
+          <form onSubmit={resetPassword} className="text-left">
+            <h2 className="text-2xl font-bold text-gray-900 mb-6 text-center">Choose a New Password</h2>
+
+            {error && (
+              <div className="mb-4 p-3 bg-red-50 text-red-700 rounded-lg text-sm">
+                {error}
+              </div>
+            )}
+
+            <div className="space-y-4">
+              <div>
+                <label className="block text-sm font-medium text-gray-700 mb-2">New Password</label>
+                <input
+                  type="password"
+                  value={password}
+                  onChange={(e) => setPassword(e.target.value)}
+                  className="w-full px-4 py-2 border border-gray-300 rounded-lg focus:ring-2 focus:ring-blue-500 focus:border-transparent"
+                />
+              </div>
+              <div>
+                <label className="block text-sm font-medium text-gray-700 mb-2">Confirm Password</label>
+                <input
+                  type="password"
+                  value={confirmPassword}
+                  onChange={(e) => setConfirmPassword(e.target.value)}
+                  className="w-full px-4 py-2 border border-gray-300 rounded-lg focus:ring-2 focus:ring-blue-500 focus:border-transparent"
+                />
+              </div>
+              <button
+                type="submit"
+                className="w-full bg-blue-600 text-white px-6 py-3 rounded-lg hover:bg-blue-700 transition font-semibold"
+              >
+                Reset Password
+              </button>
+            </div>
+          </form>
+        )}
+
+        {status === 'resetting' && (
+          <>
+            <Loader className="w-16 h-16 text-blue-600 animate-spin mx-auto mb-4" />
+            <h2 className="text-2xl font-bold text-gray-900 mb-2">Resetting Your Password</h2>
+            <p className="text-gray-600">Please wait...</p>
+          </>
+        )}
+
+        {status === 'success' && (
+          <>
+            <CheckCircle className="w-16 h-16 text-green-600 mx-auto mb-4" />
+            <h2 className="text-2xl font-bold text-gray-900 mb-2">Password Reset!</h2>
+            <p className="text-gray-600 mb-4">{message}</p>
+            <button
+              onClick={() => navigate('/')}
+              className="bg-blue-600 text-white px-8 py-3 rounded-lg hover:bg-blue-700 transition font-semibold text-lg shadow-lg hover:shadow-xl"
+            >
+              Go to Login
+            </button>
+          </>
+        )}
+
+        {status === 'error' && (
+          <>
+            <XCircle className="w-16 h-16 text-red-600 mx-auto mb-4" />
+            <h2 className="text-2xl font-bold text-gray-900 mb-2">Reset Failed</h2>
+            <p className="text-gray-600 mb-4">{message}</p>
+            <button
+              onClick={() => navigate('/')}
+              className="bg-blue-600 text-white px-6 py-2 rounded-lg hover:bg-blue-700 transition"
+            >
+              Back to Home
+            </button>
+          </>
+        )}
+      </div>
+    </div>
+  );
+};
+
+export default ResetPassword;
@@ -1,6 +1,7 @@
 import React, { useState, useEffect } from 'react';
 import { useNavigate, useSearchParams } from 'react-router-dom';
 import { CheckCircle, XCircle, Loader } from 'lucide-react';
+import { saveSession } from './auth';
 
 const API_URL = process.env.REACT_APP_API_URL || 'http://localhost:8080/api';
 
@@ -36,7 +37,7 @@ const VerifyEmail = () => {
         
         // Store token for automatic login when user clicks the button
         if (data.token && data.user) {
-          localStorage.setItem('token', data.token);
+          saveSession(data);
         }
       } else {
         setStatus('error');
@@ -0,0 +1,113 @@
+const API_URL = process.env.REACT_APP_API_URL || 'http://localhost:8080/api';
+
+// Access tokens only last a few minutes; the refresh token trades them in
+// for a new pair. Both live in localStorage.
+const listeners = new Set();
+
+export const getToken = () => localStorage.getItem('token');
+
+// saveSession stores the tokens from a login, verification or refresh response
+export const saveSession = ({ token, refresh_token }) => {
+  localStorage.setItem('token', token);
+  if (refresh_token) {
+    localStorage.setItem('refresh_token', refresh_token);
+  }
+};
+
+const clearSession = () => {
+  localStorage.removeItem('token');
+  localStorage.removeItem('refresh_token');
+};
+
+// onTokenChange calls listener with each new access token, or null once the
+// session can't be refreshed. It returns a function that unsubscribes.
+export const onTokenChange = (listener) => {
+  listeners.add(listener);
+  return () => listeners.delete(listener);
+};
+
+// requestRefresh trades refreshToken for a new pair. It resolves to null if
+// the server turned it down and throws if the server couldn't be reached.
+const requestRefresh = async (refreshToken) => {
+  const response = await fetch(`${API_URL}/auth/refresh`, {
+    method: 'POST',
+    headers: { 'Content-Type': 'application/json' },
+    body: JSON.stringify({ refresh_token: refreshToken }),
+  });
+  return response.ok ? response.json() : null;
+};
+
+let refreshing = null;
+
+// refreshSession swaps the refresh token for a new pair and resolves to the
+// new access token, or null if the session is over. Concurrent callers share
+// one request, since each refresh token only works once.
+export const refreshSession = () => {
+  if (!refreshing) {
+    refreshing = (async () => {
+      const refreshToken = localStorage.getItem('refresh_token');
+      if (!refreshToken) {
+        return null;
+      }
+      try {
+        const data = await requestRefresh(refreshToken);
+        if (!data) {
+          clearSession();
+          listeners.forEach(listener => listener(null));
+          return null;
+        }
+        saveSession(data);
+        listeners.forEach(listener => listener(data.token));
+        return data.token;
+      } catch (error) {
+        // Offline or server down: keep the session and try again later
+        console.error('Error refreshing session:', error);
+        return null;
+      }
+    })().finally(() => {
+      refreshing = null;
+    });
+  }
+  return refreshing;
+};
+
+// authFetch is fetch with the current access token, retried once through
+// /auth/refresh if it was rejected
+export const authFetch = async (url, options = {}) => {
+  const send = (token) => fetch(url, {
+    ...options,
+    headers: { ...options.headers, Authorization: `Bearer ${token}` },
+  });
+
+  const response = await send(getToken());
+  if (response.status !== 401) {
+    return response;
+  }
+  const token = await refreshSession();
+  return token ? send(token) : response;
+};
+
+const revoke = (token) => fetch(`${API_URL}/auth/logout`, {
+  method: 'POST',
+  headers: { Authorization: `Bearer ${token}` },
+});
+
+// logoutSession forgets the tokens straight away, then revokes the session on
+// the server, refreshing first if the access token has already expired
+export const logoutSession = async () => {
+  const token = getToken();
+  const refreshToken = localStorage.getItem('refresh_token');
+  clearSession();
+
+  try {
+    const response = token ? await revoke(token) : null;
+    if ((!response || response.status === 401) && refreshToken) {
+      const data = await requestRefresh(refreshToken);
+      if (data) {
+        await revoke(data.token);
+      }
+    }
+  } catch (error) {
+    console.error('Error logging out:', error);
+  }
+};
@@ -4,6 +4,8 @@ import { BrowserRouter, Routes, Route } from 'react-router-dom';
 import './index.css';
 import App from './App';
 import VerifyEmail from './VerifyEmail';
+import RenewPost from './RenewPost';
+import ResetPassword from './ResetPassword';
 
 const root = ReactDOM.createRoot(document.getElementById('root'));
 root.render(
@@ -12,6 +14,8 @@ root.render(
       <Routes>
         <Route path="/" element={<App />} />
         <Route path="/verify-email" element={<VerifyEmail />} />
+        <Route path="/renew" element={<RenewPost />} />
+        <Route path="/reset-password" element={<ResetPassword />} />
       </Routes>
     </BrowserRouter>
   </React.StrictMode>